@@ -0,0 +1,89 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultAccessFlushInterval = time.Second
+
+// accessRecord is an access of Get which hasn't been applied to the list yet
+type accessRecord[K comparable, V any] struct {
+	linkedNode *doublyLinkedNode[K, V]
+	accessedAt time.Time
+}
+
+// accessBuffer is a shard of the buffered accesses which is guarded by its own lock
+type accessBuffer[K comparable, V any] struct {
+	sync.Mutex
+	records []accessRecord[K, V]
+}
+
+// initAccessBuffers creates GOMAXPROCS buffers if Config.AccessBufferSize is set
+// Accesses are spread across them round-robin so that concurrent Gets rarely contend
+// for the same buffer
+func (c *TLRU[K, V]) initAccessBuffers() {
+	c.accessBuffers = nil
+	if c.config.AccessBufferSize <= 0 || c.config.EvictionPolicy != LRA {
+		return
+	}
+	c.accessBuffers = make([]accessBuffer[K, V], runtime.GOMAXPROCS(0))
+}
+
+// recordAccess buffers an access of the provided node and must be called without holding
+// the lock of the cache. A full buffer is applied if the lock of the cache is available,
+// otherwise the access is dropped
+func (c *TLRU[K, V]) recordAccess(linkedNode *doublyLinkedNode[K, V], accessedAt time.Time) {
+	accessBuffer := &c.accessBuffers[atomic.AddUint32(&c.accessShard, 1)%uint32(len(c.accessBuffers))]
+	accessBuffer.Lock()
+	if len(accessBuffer.records) < c.config.AccessBufferSize {
+		accessBuffer.records = append(accessBuffer.records, accessRecord[K, V]{linkedNode: linkedNode, accessedAt: accessedAt})
+	}
+	full := len(accessBuffer.records) >= c.config.AccessBufferSize
+	accessBuffer.Unlock()
+
+	if full {
+		if c.TryLock() {
+			c.applyAccesses()
+			c.unlock()
+		}
+		return
+	}
+	if atomic.CompareAndSwapInt32(&c.accessFlushPending, 0, 1) {
+		time.AfterFunc(c.config.AccessFlushInterval, c.flushAccesses)
+	}
+}
+
+// flushAccesses applies the buffered accesses once Config.AccessFlushInterval has elapsed
+// since the first of them
+func (c *TLRU[K, V]) flushAccesses() {
+	defer c.unlock()
+	c.Lock()
+
+	atomic.StoreInt32(&c.accessFlushPending, 0)
+	c.applyAccesses()
+}
+
+// applyAccesses marks the nodes of the buffered accesses as used in the order they have been
+// recorded per buffer. Accesses of nodes which are no longer cached are discarded
+// It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) applyAccesses() {
+	for i := range c.accessBuffers {
+		accessBuffer := &c.accessBuffers[i]
+		accessBuffer.Lock()
+		for j, record := range accessBuffer.records {
+			if !c.closed && c.cache[record.linkedNode.key] == record.linkedNode {
+				c.access(record.linkedNode, record.accessedAt)
+			}
+			accessBuffer.records[j] = accessRecord[K, V]{}
+		}
+		accessBuffer.records = accessBuffer.records[:0]
+		accessBuffer.Unlock()
+	}
+}
@@ -0,0 +1,82 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheAccessBuffer(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:             3,
+		TTL:                 time.Minute,
+		EvictionPolicy:      LRA,
+		AccessBufferSize:    2,
+		AccessFlushInterval: 10 * time.Millisecond,
+	}
+	cache := New(config)
+	cache.accessBuffers = cache.accessBuffers[:1]
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+	cache.Set(entry3.Key, entry3.Value)
+
+	counter := cache.Get(entry1.Key).Counter
+	assert.Equal(counter, cache.Peek(entry1.Key).Counter)
+	lruKey, _ := cache.LRUKey()
+	assert.Equal(entry1.Key, lruKey)
+	assert.Eventually(func() bool {
+		lruKey, _ := cache.LRUKey()
+		return lruKey == entry2.Key
+	}, time.Second, time.Millisecond)
+	assert.Equal(counter+1, cache.Peek(entry1.Key).Counter)
+
+	cache.Get(entry2.Key)
+	cache.Get(entry2.Key)
+	lruKey, _ = cache.LRUKey()
+	assert.Equal(entry3.Key, lruKey)
+
+	cache.Get(entry3.Key)
+	cache.Set(entry4.Key, entry4.Value)
+	assert.True(cache.Has(entry3.Key))
+	assert.False(cache.Has(entry1.Key))
+}
+
+func TestLRUCacheAccessBufferConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:             50,
+		TTL:                 time.Minute,
+		EvictionPolicy:      LRA,
+		AccessBufferSize:    16,
+		AccessFlushInterval: time.Millisecond,
+	}
+	cache := New(config)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				key := fmt.Sprint((i * j) % 100)
+				if cache.Get(key) == nil {
+					cache.Set(key, j)
+				}
+				if j%100 == 0 {
+					cache.Clear()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(cache.Len() <= config.MaxSize)
+	checkListInvariants(t, cache)
+}
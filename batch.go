@@ -0,0 +1,124 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"time"
+)
+
+// KeyError is the failure of a batch operation for a single key
+type KeyError[K comparable] struct {
+	Key K
+	Err error
+}
+
+// Error implements the error interface
+func (e KeyError[K]) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error so that it can be inspected via errors.Is
+func (e KeyError[K]) Unwrap() error {
+	return e.Err
+}
+
+// BatchError lists the per-key failures of a batch operation
+// The operation is applied to all other keys
+type BatchError[K comparable] struct {
+	// The name of the batch operation
+	Op string
+	// The failures in the order of the provided keys
+	Errors []KeyError[K]
+}
+
+// Error implements the error interface
+func (e *BatchError[K]) Error() string {
+	return fmt.Sprintf("tlru.%s: %d key(s) failed. First error: %s", e.Op, len(e.Errors), e.Errors[0].Error())
+}
+
+// SetMany inserts all provided entries while holding the lock of the cache once
+// Entries that can't be inserted don't abort the operation but are reported
+// via a *BatchError
+func (c *TLRU[K, V]) SetMany(entries []Entry[K, V]) error {
+	if err := c.checkMisuse("SetMany", true); err != nil {
+		return err
+	}
+	defer c.unlock()
+	c.Lock()
+
+	var keyErrors []KeyError[K]
+	for _, entry := range entries {
+		if err := c.insert(entry); err != nil {
+			keyErrors = append(keyErrors, KeyError[K]{Key: entry.Key, Err: err})
+		}
+	}
+	if len(keyErrors) > 0 {
+		return &BatchError[K]{Op: "SetMany", Errors: keyErrors}
+	}
+
+	return nil
+}
+
+// GetMany retrieves the entries that correspond to the provided keys while holding
+// the lock of the cache once. Every key behaves as in Get and the keys for which
+// an entry doesn't exist, is expired or fails Config.Validator are missing from the returned map
+func (c *TLRU[K, V]) GetMany(keys []K) map[K]*CacheEntry[K, V] {
+	cacheEntries := make(map[K]*CacheEntry[K, V], len(keys))
+	if c.misused("GetMany", false) {
+		return cacheEntries
+	}
+	c.Lock()
+	c.applyAccesses()
+	now := time.Now()
+	for _, key := range keys {
+		if cacheEntry := c.getLocked(key, now); cacheEntry != nil {
+			cacheEntries[key] = cacheEntry
+		}
+	}
+	c.unlock()
+
+	for _, key := range keys {
+		cacheEntry := cacheEntries[key]
+		if c.validate(cacheEntry) == nil {
+			delete(cacheEntries, key)
+		}
+		c.countGet(cacheEntries[key])
+	}
+
+	return cacheEntries
+}
+
+// getLocked behaves like Get and must be called while holding the lock of the cache
+func (c *TLRU[K, V]) getLocked(key K, now time.Time) *CacheEntry[K, V] {
+	linkedNode, exists := c.cache[key]
+	if exists && linkedNode.isExpired(now) {
+		c.evictEntry(linkedNode, EvictionReasonExpired)
+		exists = false
+	}
+	if !exists {
+		return nil
+	}
+	if c.config.EvictionPolicy == LRA {
+		c.access(linkedNode, now)
+	}
+	cacheEntry := linkedNode.ToCacheEntry()
+
+	return &cacheEntry
+}
+
+// DeleteMany removes the entries that correspond to the provided keys while holding
+// the lock of the cache once. Keys that don't exist are ignored
+func (c *TLRU[K, V]) DeleteMany(keys []K) {
+	if c.misused("DeleteMany", true) {
+		return
+	}
+	defer c.unlock()
+	c.Lock()
+
+	for _, key := range keys {
+		c.delete(key)
+	}
+}
@@ -0,0 +1,81 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheSetManyAndDeleteMany(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		assert.NoError(cache.SetMany([]Entry[string, int]{entry1, entry2, entry3}))
+		assert.ElementsMatch([]string{entry1.Key, entry2.Key, entry3.Key}, cache.Keys())
+
+		cache.DeleteMany([]string{entry1.Key, entry3.Key, "non-existent-key"})
+		assert.ElementsMatch([]string{entry2.Key}, cache.Keys())
+	}
+}
+
+func TestLRUCacheGetMany(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		cache.SetMany([]Entry[string, int]{entry1, entry2})
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, time.Now().Add(-time.Hour))
+
+		cacheEntries := cache.GetMany([]string{entry1.Key, entry2.Key, entry3.Key, "non-existent-key"})
+		assert.Equal(2, len(cacheEntries))
+		assert.Equal(entry1.Value, cacheEntries[entry1.Key].Value)
+		assert.Equal(entry2.Value, cacheEntries[entry2.Key].Value)
+		assert.False(cache.Has(entry3.Key))
+		assert.Equal(2, cache.Len())
+		if policy == LRA {
+			assert.Equal(cache.initialCounter()+1, cacheEntries[entry1.Key].Counter)
+			assert.Equal(entry2.Key, cache.GetState().Entries[0].Key)
+		}
+
+		stats := cache.Stats()
+		assert.Equal(uint64(2), stats.GetHits)
+		assert.Equal(uint64(2), stats.GetMisses)
+	}
+}
+
+func TestLRUCacheSetManyBatchError(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:        10,
+		TTL:            time.Minute,
+		EvictionPolicy: LRA,
+	}
+	cache := New(config)
+	cache.Set(entry2.Key, entry2.Value)
+
+	err := cache.SetMany([]Entry[string, int]{entry1, entry2, entry3})
+	var batchError *BatchError[string]
+	assert.True(errors.As(err, &batchError))
+	assert.Equal(1, len(batchError.Errors))
+	assert.Equal(entry2.Key, batchError.Errors[0].Key)
+	assert.True(errors.Is(batchError.Errors[0], ErrReplacementNotAllowed))
+	assert.Equal("tlru.SetMany: 1 key(s) failed. First error: Key 'entry2' already exist. Entry replacement is not allowed in LRA EvictionPolicy", err.Error())
+	assert.ElementsMatch([]string{entry1.Key, entry2.Key, entry3.Key}, cache.Keys())
+
+	assert.True(errors.Is(cache.Set(entry1.Key, entry1.Value), ErrReplacementNotAllowed))
+}
@@ -0,0 +1,203 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+)
+
+// binaryConfig holds the serializable fields of Config
+type binaryConfig struct {
+	MaxSize                   int
+	TTL                       time.Duration
+	EvictionPolicy            evictionPolicy
+	GarbageCollectionInterval time.Duration
+	EntryLocking              bool
+	StaleRefreshTimeout       time.Duration
+	MaxLifetime               time.Duration
+	LoadTimeout               time.Duration
+	NegativeTTL               time.Duration
+	LoadErrorPolicy           loadErrorPolicy
+	LoadErrorBackoff          time.Duration
+	LoadErrorMaxBackoff       time.Duration
+	PromoteAfter              int
+	PromoteAge                time.Duration
+	PromoteInterval           time.Duration
+	ExpirationTimers          bool
+	SoftMaxSize               int
+	OversizedState            oversizedStatePolicy
+	MaxConcurrentLoads        int
+	LoadLimitPolicy           loadLimitPolicy
+	ResetCountersOnRestore    bool
+	TrackChurn                bool
+	GhostAdmission            bool
+	ProviderPollInterval      time.Duration
+	EvictionSamples           int
+	AccessBufferSize          int
+	AccessFlushInterval       time.Duration
+	CoalesceWindow            time.Duration
+	ExpiryWarning             float64
+	LeaseTimeout              time.Duration
+	MisuseDetection           misuseDetection
+	Name                      string
+	OnEvictWorkers            int
+	GetLatencyBudget          time.Duration
+	EvictionDelivery          evictionDeliveryPolicy
+	ExpirationMode            expirationMode
+	MaxCost                   int64
+}
+
+type binaryCache[K comparable, V any] struct {
+	Config binaryConfig
+	State  State[K, V]
+}
+
+// binaryEncodedCache is the encoding of a cache whose values are encoded by Config.ValueMarshaler
+type binaryEncodedCache[K comparable] struct {
+	Config binaryConfig
+	State  encodedState[K]
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+// It encodes the serializable fields of the Config along with the State of the cache
+// via encoding/gob so K must be gob-encodable and so must V unless Config.ValueMarshaler is set
+func (c *TLRU[K, V]) MarshalBinary() ([]byte, error) {
+	c.RLock()
+	config := c.config
+	c.RUnlock()
+
+	bc := binaryConfig{
+		MaxSize:                   config.MaxSize,
+		TTL:                       config.TTL,
+		EvictionPolicy:            config.EvictionPolicy,
+		GarbageCollectionInterval: config.GarbageCollectionInterval,
+		EntryLocking:              config.EntryLocking,
+		StaleRefreshTimeout:       config.StaleRefreshTimeout,
+		MaxLifetime:               config.MaxLifetime,
+		LoadTimeout:               config.LoadTimeout,
+		NegativeTTL:               config.NegativeTTL,
+		LoadErrorPolicy:           config.LoadErrorPolicy,
+		LoadErrorBackoff:          config.LoadErrorBackoff,
+		LoadErrorMaxBackoff:       config.LoadErrorMaxBackoff,
+		PromoteAfter:              config.PromoteAfter,
+		PromoteAge:                config.PromoteAge,
+		PromoteInterval:           config.PromoteInterval,
+		ExpirationTimers:          config.ExpirationTimers,
+		SoftMaxSize:               config.SoftMaxSize,
+		OversizedState:            config.OversizedState,
+		MaxConcurrentLoads:        config.MaxConcurrentLoads,
+		LoadLimitPolicy:           config.LoadLimitPolicy,
+		ResetCountersOnRestore:    config.ResetCountersOnRestore,
+		TrackChurn:                config.TrackChurn,
+		GhostAdmission:            config.GhostAdmission,
+		ProviderPollInterval:      config.ProviderPollInterval,
+		EvictionSamples:           config.EvictionSamples,
+		AccessBufferSize:          config.AccessBufferSize,
+		AccessFlushInterval:       config.AccessFlushInterval,
+		CoalesceWindow:            config.CoalesceWindow,
+		ExpiryWarning:             config.ExpiryWarning,
+		LeaseTimeout:              config.LeaseTimeout,
+		MisuseDetection:           config.MisuseDetection,
+		Name:                      config.Name,
+		OnEvictWorkers:            config.OnEvictWorkers,
+		GetLatencyBudget:          config.GetLatencyBudget,
+		EvictionDelivery:          config.EvictionDelivery,
+		ExpirationMode:            config.ExpirationMode,
+		MaxCost:                   config.MaxCost,
+	}
+
+	state := c.GetState()
+	var b any = binaryCache[K, V]{Config: bc, State: state}
+	if config.ValueMarshaler != nil {
+		encoded, err := encodeState(state, config.ValueMarshaler)
+		if err != nil {
+			return nil, fmt.Errorf("tlru.MarshalBinary: %w", err)
+		}
+		b = binaryEncodedCache[K]{Config: bc, State: encoded}
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+		return nil, fmt.Errorf("tlru.MarshalBinary: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// It replaces the configuration and the entries of the cache with the decoded ones.
+// Channels, callbacks and the RandSource of the existing Config are kept so a zero
+// value TLRU can be used as well as one returned by New. Values are decoded via
+// Config.ValueUnmarshaler of the existing Config if it is set
+// Replaced entries are not emitted to the EvictionChannel but are handed over to Config.OnFinalize
+func (c *TLRU[K, V]) UnmarshalBinary(data []byte) error {
+	var b binaryCache[K, V]
+	_, unmarshal := c.valueCodec()
+	if unmarshal == nil {
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err != nil {
+			return fmt.Errorf("tlru.UnmarshalBinary: %w", err)
+		}
+	} else {
+		var encoded binaryEncodedCache[K]
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&encoded); err != nil {
+			return fmt.Errorf("tlru.UnmarshalBinary: %w", err)
+		}
+		state, err := decodeState(encoded.State, unmarshal)
+		if err != nil {
+			return fmt.Errorf("tlru.UnmarshalBinary: %w", err)
+		}
+		b = binaryCache[K, V]{Config: encoded.Config, State: state}
+	}
+
+	c.Lock()
+	config := c.config
+	config.MaxSize = b.Config.MaxSize
+	config.TTL = b.Config.TTL
+	config.EvictionPolicy = b.Config.EvictionPolicy
+	config.GarbageCollectionInterval = b.Config.GarbageCollectionInterval
+	config.EntryLocking = b.Config.EntryLocking
+	config.StaleRefreshTimeout = b.Config.StaleRefreshTimeout
+	config.MaxLifetime = b.Config.MaxLifetime
+	config.LoadTimeout = b.Config.LoadTimeout
+	config.NegativeTTL = b.Config.NegativeTTL
+	config.LoadErrorPolicy = b.Config.LoadErrorPolicy
+	config.LoadErrorBackoff = b.Config.LoadErrorBackoff
+	config.LoadErrorMaxBackoff = b.Config.LoadErrorMaxBackoff
+	config.PromoteAfter = b.Config.PromoteAfter
+	config.PromoteAge = b.Config.PromoteAge
+	config.PromoteInterval = b.Config.PromoteInterval
+	config.ExpirationTimers = b.Config.ExpirationTimers
+	config.SoftMaxSize = b.Config.SoftMaxSize
+	config.OversizedState = b.Config.OversizedState
+	config.MaxConcurrentLoads = b.Config.MaxConcurrentLoads
+	config.LoadLimitPolicy = b.Config.LoadLimitPolicy
+	config.ResetCountersOnRestore = b.Config.ResetCountersOnRestore
+	config.TrackChurn = b.Config.TrackChurn
+	config.GhostAdmission = b.Config.GhostAdmission
+	config.ProviderPollInterval = b.Config.ProviderPollInterval
+	config.EvictionSamples = b.Config.EvictionSamples
+	config.AccessBufferSize = b.Config.AccessBufferSize
+	config.AccessFlushInterval = b.Config.AccessFlushInterval
+	config.CoalesceWindow = b.Config.CoalesceWindow
+	config.ExpiryWarning = b.Config.ExpiryWarning
+	config.LeaseTimeout = b.Config.LeaseTimeout
+	config.MisuseDetection = b.Config.MisuseDetection
+	config.Name = b.Config.Name
+	config.OnEvictWorkers = b.Config.OnEvictWorkers
+	config.GetLatencyBudget = b.Config.GetLatencyBudget
+	config.EvictionDelivery = b.Config.EvictionDelivery
+	config.ExpirationMode = b.Config.ExpirationMode
+	config.MaxCost = b.Config.MaxCost
+	c.stopGarbageCollection()
+	c.negativeEntries = nil
+	c.clear()
+	c.init(config)
+	c.unlock()
+
+	return c.SetState(b.State)
+}
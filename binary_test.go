@@ -0,0 +1,84 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheMarshalBinary(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        3,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			MaxLifetime:    time.Hour,
+		}
+		cache := New(config)
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+
+		data, err := cache.MarshalBinary()
+		assert.NoError(err)
+
+		restored := &TLRU[string, int]{}
+		assert.NoError(restored.UnmarshalBinary(data))
+
+		restoredConfig := restored.Config()
+		assert.Equal(config.MaxSize, restoredConfig.MaxSize)
+		assert.Equal(config.TTL, restoredConfig.TTL)
+		assert.Equal(config.EvictionPolicy, restoredConfig.EvictionPolicy)
+		assert.Equal(config.MaxLifetime, restoredConfig.MaxLifetime)
+		assert.Equal(defaultGarbageCollectionInterval, restoredConfig.GarbageCollectionInterval)
+		expectedState, restoredState := cache.GetState(), restored.GetState()
+		assert.Equal(len(expectedState.Entries), len(restoredState.Entries))
+		for i := range expectedState.Entries {
+			assert.Equal(expectedState.Entries[i].Key, restoredState.Entries[i].Key)
+			assert.Equal(expectedState.Entries[i].Value, restoredState.Entries[i].Value)
+			assert.Equal(expectedState.Entries[i].Counter, restoredState.Entries[i].Counter)
+		}
+
+		restored.Set("d", 4)
+		assert.Equal(3, restored.Len())
+	}
+}
+
+func TestLRUCacheMarshalBinaryEmbedded(t *testing.T) {
+	assert := assert.New(t)
+	type snapshot struct {
+		Name  string
+		Cache *TLRU[string, int]
+	}
+
+	cache := New(Config[string, int]{MaxSize: 2, TTL: time.Minute, EvictionPolicy: LRA})
+	cache.Set("a", 1)
+
+	var buf bytes.Buffer
+	assert.NoError(gob.NewEncoder(&buf).Encode(snapshot{Name: "app", Cache: cache}))
+
+	var decoded snapshot
+	assert.NoError(gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal("app", decoded.Name)
+	entry := decoded.Cache.Get("a")
+	if assert.NotNil(entry) {
+		assert.Equal(1, entry.Value)
+	}
+	assert.Equal(LRA, decoded.Cache.Config().EvictionPolicy)
+}
+
+func TestLRUCacheUnmarshalBinaryInvalid(t *testing.T) {
+	assert := assert.New(t)
+	cache := New(Config[string, int]{MaxSize: 2, TTL: time.Minute})
+	cache.Set("a", 1)
+
+	assert.Error(cache.UnmarshalBinary([]byte("garbage")))
+	assert.Equal(1, cache.Len())
+}
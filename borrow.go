@@ -0,0 +1,72 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import "time"
+
+// borrow counts the outstanding checkouts of a key and holds the values of the key
+// which left the cache while being borrowed until they are handed over to Config.OnFinalize
+type borrow[V any] struct {
+	count   int
+	retired []V
+}
+
+// Checkout returns the value of the entry that corresponds to the provided key and
+// marks the entry as borrowed until a matching call to Checkin
+// A borrowed entry is neither dropped nor expired and values of the key which are
+// deleted, cleared or replaced meanwhile are handed over to Config.OnFinalize only
+// after the last Checkin. Checkouts are counted per key
+// It returns false if an entry for the specified key doesn't exist or is expired
+// Checkout marks the entry as used like Get does
+func (c *TLRU[K, V]) Checkout(key K) (V, bool) {
+	defer c.unlock()
+	c.Lock()
+
+	var value V
+	linkedNode, exists := c.cache[key]
+	now := time.Now()
+	if !exists || linkedNode.isExpired(now) {
+		return value, false
+	}
+	if c.config.EvictionPolicy == LRA {
+		c.access(linkedNode, now)
+	}
+
+	if c.borrows == nil {
+		c.borrows = make(map[K]*borrow[V])
+	}
+	if c.borrows[key] == nil {
+		c.borrows[key] = &borrow[V]{}
+	}
+	c.borrows[key].count++
+
+	return linkedNode.readValue(), true
+}
+
+// Checkin returns an entry that has been borrowed via Checkout
+// On the last Checkin of a key the retired values of the key are finalized and
+// the evictions which have been deferred while the entry was borrowed take place
+func (c *TLRU[K, V]) Checkin(key K) {
+	defer c.unlock()
+	c.Lock()
+
+	borrow := c.borrows[key]
+	if borrow == nil {
+		return
+	}
+	borrow.count--
+	if borrow.count > 0 {
+		return
+	}
+	delete(c.borrows, key)
+
+	for _, value := range borrow.retired {
+		c.finalized = append(c.finalized, finalizedValue[K, V]{key: key, value: value})
+	}
+	if linkedNode, exists := c.cache[key]; exists && linkedNode.isExpired(time.Now()) {
+		c.evictEntry(linkedNode, EvictionReasonExpired)
+	}
+	c.shrink(c.config.MaxSize)
+}
@@ -0,0 +1,80 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheCheckoutPreventsEviction(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var finalized []string
+		config := Config[string, int]{
+			MaxSize:        1,
+			TTL:            20 * time.Millisecond,
+			EvictionPolicy: policy,
+			OnFinalize: func(key string, value int) {
+				finalized = append(finalized, key)
+			},
+		}
+		cache := New(config)
+
+		_, ok := cache.Checkout(entry1.Key)
+		assert.False(ok)
+
+		cache.Set(entry1.Key, entry1.Value)
+		value, ok := cache.Checkout(entry1.Key)
+		assert.True(ok)
+		assert.Equal(entry1.Value, value)
+		cache.Checkout(entry1.Key)
+
+		cache.Set(entry2.Key, entry2.Value)
+		assert.Equal(2, cache.Len())
+		assert.NotNil(cache.Peek(entry1.Key))
+
+		time.Sleep(30 * time.Millisecond)
+		assert.Nil(cache.Get(entry1.Key))
+		assert.Nil(cache.Get(entry2.Key))
+		assert.Equal(1, cache.Len())
+		assert.Equal([]string{entry2.Key}, finalized)
+
+		cache.Checkin(entry1.Key)
+		assert.Equal(1, cache.Len())
+		cache.Checkin(entry1.Key)
+		assert.Equal(0, cache.Len())
+		assert.Equal([]string{entry2.Key, entry1.Key}, finalized)
+
+		cache.Checkin(entry1.Key)
+	}
+}
+
+func TestLRUCacheCheckoutDefersFinalization(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var finalized []int
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			OnFinalize: func(key string, value int) {
+				finalized = append(finalized, value)
+			},
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, 1)
+		cache.Checkout(entry1.Key)
+		cache.Swap(entry1.Key, 2)
+		cache.Delete(entry1.Key)
+		assert.Nil(cache.Get(entry1.Key))
+		assert.Empty(finalized)
+
+		cache.Checkin(entry1.Key)
+		assert.Equal([]int{1, 2}, finalized)
+	}
+}
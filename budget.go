@@ -0,0 +1,88 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	minBudgetBackoff = 10 * time.Microsecond
+	maxBudgetBackoff = time.Millisecond
+)
+
+// GetConsistent behaves like Get but observes every operation which completed before it
+// Get already observes the values of all completed writes. GetConsistent additionally
+// waits for the lock of the cache regardless of Config.GetLatencyBudget so that a preceding
+// write is never missed, and applies the accesses buffered due to Config.AccessBufferSize
+// so that the Counter and LastUsedAt of the returned entry account for the preceding Gets
+func (c *TLRU[K, V]) GetConsistent(key K) *CacheEntry[K, V] {
+	if c.misused("GetConsistent", false) {
+		return nil
+	}
+	c.Lock()
+	c.applyAccesses()
+	cacheEntry := c.getLocked(key, time.Now())
+	c.unlock()
+
+	cacheEntry = c.validate(cacheEntry)
+	c.countGet(cacheEntry)
+
+	return cacheEntry
+}
+
+// budgetDeadline returns the time until which Get may wait for the lock of the cache
+// or the zero time if Config.GetLatencyBudget is not set
+func (c *TLRU[K, V]) budgetDeadline() time.Time {
+	if c.config.GetLatencyBudget <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(c.config.GetLatencyBudget)
+}
+
+// rlockBefore acquires the read lock of the cache unless the provided deadline
+// passes first. A zero deadline waits as long as it takes
+func (c *TLRU[K, V]) rlockBefore(deadline time.Time) bool {
+	if deadline.IsZero() {
+		c.RLock()
+		return true
+	}
+
+	return c.retryUntil(deadline, c.TryRLock)
+}
+
+// lockBefore acquires the lock of the cache unless the provided deadline
+// passes first. A zero deadline waits as long as it takes
+func (c *TLRU[K, V]) lockBefore(deadline time.Time) bool {
+	if deadline.IsZero() {
+		c.Lock()
+		return true
+	}
+
+	return c.retryUntil(deadline, c.TryLock)
+}
+
+// retryUntil retries tryLock with an exponential backoff until it succeeds or the deadline passes
+func (c *TLRU[K, V]) retryUntil(deadline time.Time, tryLock func() bool) bool {
+	for backoff := minBudgetBackoff; ; backoff *= 2 {
+		if tryLock() {
+			return true
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			atomic.AddUint64(&c.operations.budgetExceeded, 1)
+			return false
+		}
+		if backoff > maxBudgetBackoff {
+			backoff = maxBudgetBackoff
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		time.Sleep(backoff)
+	}
+}
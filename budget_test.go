@@ -0,0 +1,73 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheGetLatencyBudget(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:          10,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			GetLatencyBudget: 5 * time.Millisecond,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		counter := cache.Peek(entry1.Key).Counter
+
+		cache.Lock()
+		startedAt := time.Now()
+		assert.Nil(cache.Get(entry1.Key))
+		assert.True(time.Since(startedAt) < 100*time.Millisecond)
+		cache.Unlock()
+		assert.Equal(uint64(1), cache.Stats().BudgetExceeded)
+
+		cache.RLock()
+		cacheEntry := cache.Get(entry1.Key)
+		cache.RUnlock()
+		assert.Equal(entry1.Value, cacheEntry.Value)
+		assert.Equal(counter, cache.Peek(entry1.Key).Counter)
+
+		assert.Equal(entry1.Value, cache.Get(entry1.Key).Value)
+		if policy == LRA {
+			assert.Equal(uint64(2), cache.Stats().BudgetExceeded)
+			assert.Equal(counter+1, cache.Peek(entry1.Key).Counter)
+		}
+	}
+}
+
+func TestLRUCacheGetConsistent(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:             10,
+		TTL:                 time.Minute,
+		EvictionPolicy:      LRA,
+		GetLatencyBudget:    time.Millisecond,
+		AccessBufferSize:    10,
+		AccessFlushInterval: time.Minute,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	counter := cache.Get(entry1.Key).Counter
+
+	cacheEntry := cache.GetConsistent(entry1.Key)
+	assert.Equal(entry1.Value, cacheEntry.Value)
+	assert.Equal(counter+2, cacheEntry.Counter)
+
+	cache.Lock()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cache.Unlock()
+	}()
+	assert.Nil(cache.Get(entry1.Key))
+	assert.Equal(entry1.Value, cache.GetConsistent(entry1.Key).Value)
+	assert.Nil(cache.GetConsistent(entry2.Key))
+}
@@ -0,0 +1,74 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	calibrationDuration = 100 * time.Millisecond
+	calibrationMaxSize  = 10000
+)
+
+// Calibration is the outcome of the Calibrate micro-benchmark
+type Calibration struct {
+	GetOpsPerSecond float64 `json:"get_ops_per_second"`
+	GetAllocsPerOp  float64 `json:"get_allocs_per_op"`
+	GetBytesPerOp   float64 `json:"get_bytes_per_op"`
+	SetOpsPerSecond float64 `json:"set_ops_per_second"`
+	SetAllocsPerOp  float64 `json:"set_allocs_per_op"`
+	SetBytesPerOp   float64 `json:"set_bytes_per_op"`
+}
+
+// Calibrate runs a short single-threaded micro-benchmark of Get and Set on the
+// current hardware and returns the expected throughput and allocations per op
+// Get is measured against a full cache of the LRA EvictionPolicy and Set with
+// unique keys so that every Set drops the least recently used entry
+// It takes about 200 milliseconds
+func Calibrate() Calibration {
+	cache := New(Config[int, int]{
+		MaxSize: calibrationMaxSize,
+		TTL:     time.Minute,
+	})
+	defer cache.Clear()
+
+	var calibration Calibration
+	key := 0
+	calibration.SetOpsPerSecond, calibration.SetAllocsPerOp, calibration.SetBytesPerOp = measure(func() {
+		cache.Set(key, key)
+		key++
+	})
+	calibration.GetOpsPerSecond, calibration.GetAllocsPerOp, calibration.GetBytesPerOp = measure(func() {
+		cache.Get(key % calibrationMaxSize)
+		key++
+	})
+
+	return calibration
+}
+
+// measure runs op for calibrationDuration and returns its ops per second,
+// allocations per op and allocated bytes per op
+func measure(op func()) (float64, float64, float64) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	ops := 0
+	start := time.Now()
+	for time.Since(start) < calibrationDuration {
+		for i := 0; i < 1000; i++ {
+			op()
+		}
+		ops += 1000
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return float64(ops) / elapsed.Seconds(),
+		float64(after.Mallocs-before.Mallocs) / float64(ops),
+		float64(after.TotalAlloc-before.TotalAlloc) / float64(ops)
+}
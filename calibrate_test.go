@@ -0,0 +1,20 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrate(t *testing.T) {
+	assert := assert.New(t)
+	calibration := Calibrate()
+
+	assert.Greater(calibration.GetOpsPerSecond, float64(0))
+	assert.Greater(calibration.SetOpsPerSecond, float64(0))
+	assert.Greater(calibration.SetAllocsPerOp, float64(0))
+	assert.GreaterOrEqual(calibration.GetAllocsPerOp, float64(0))
+}
@@ -0,0 +1,73 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+// minChurnHistory is the min number of evicted keys that are remembered to detect re-admissions
+const minChurnHistory = 1024
+
+type ghost[K comparable] struct {
+	key      K
+	sequence uint64
+}
+
+// ghostState is what is remembered about an evicted key
+type ghostState struct {
+	sequence uint64
+	// counter is the Counter of the entry if it has been dropped due to MaxSize
+	counter int64
+	dropped bool
+}
+
+// churnTracker remembers the most recently dropped or expired keys in a ring buffer
+// so that their re-insertion can be counted. It must only be used while holding the
+// lock of the cache
+type churnTracker[K comparable] struct {
+	insertions   uint64
+	readmissions uint64
+	sequence     uint64
+	ghosts       []ghost[K]
+	states       map[K]ghostState
+}
+
+func newChurnTracker[K comparable](maxSize int) *churnTracker[K] {
+	history := maxSize
+	if history < minChurnHistory {
+		history = minChurnHistory
+	}
+
+	return &churnTracker[K]{
+		ghosts: make([]ghost[K], 0, history),
+		states: make(map[K]ghostState, history),
+	}
+}
+
+// inserted counts the insertion of the provided key and returns the Counter the key had
+// when it was dropped due to MaxSize if it is a re-admission of such a key
+func (t *churnTracker[K]) inserted(key K) (int64, bool) {
+	t.insertions++
+	state, exists := t.states[key]
+	if !exists {
+		return 0, false
+	}
+	t.readmissions++
+	delete(t.states, key)
+
+	return state.counter, state.dropped
+}
+
+func (t *churnTracker[K]) evicted(key K, counter int64, reason evictionReason) {
+	t.sequence++
+	g := ghost[K]{key: key, sequence: t.sequence}
+	if len(t.ghosts) < cap(t.ghosts) {
+		t.ghosts = append(t.ghosts, g)
+	} else {
+		i := int((t.sequence - 1) % uint64(cap(t.ghosts)))
+		if oldest := t.ghosts[i]; t.states[oldest.key].sequence == oldest.sequence {
+			delete(t.states, oldest.key)
+		}
+		t.ghosts[i] = g
+	}
+	t.states[key] = ghostState{sequence: t.sequence, counter: counter, dropped: reason == EvictionReasonDropped}
+}
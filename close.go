@@ -0,0 +1,62 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// CloseAndExport stops the garbage collection of the cache, the polling of Config.Provider,
+// the checks of Config.ExpiryWarning and the periodic snapshots, evicts the expired entries,
+// hands the pending expired entries over to Config.OnExpiredBatch and returns the final State
+// of the cache so that it can be persisted and rehydrated on the next start
+// The final State is also written to Config.Snapshotter(if present). If that fails the
+// State is returned along with the error
+// Once it has been called, writes are rejected with ErrClosed (or have no effect for
+// methods which don't return an error) and no entry is evicted anymore while reads
+// keep on being served
+func (c *TLRU[K, V]) CloseAndExport() (State[K, V], error) {
+	c.Lock()
+	if c.closed {
+		c.unlock()
+		return State[K, V]{}, fmt.Errorf("tlru.CloseAndExport: %w", ErrClosed)
+	}
+
+	if c.config.ExpirationTimers {
+		for _, linkedNode := range c.cache {
+			linkedNode.timer.Stop()
+		}
+	}
+	c.evictExpiredEntries()
+	c.stopGarbageCollection()
+	c.closed = true
+	atomic.StoreInt32(&c.closedFlag, 1)
+	c.scheduleProviderPoll()
+	c.scheduleExpiryWarning()
+	c.scheduleSnapshot()
+
+	state := c.state()
+	expiredEntries := c.expiredEntries
+	c.expiredEntries = nil
+	target := c.config.Snapshotter.Target
+	c.unlock()
+
+	if len(expiredEntries) > 0 {
+		c.protect("OnExpiredBatch", func() {
+			c.config.OnExpiredBatch(expiredEntries)
+		})
+	}
+	if target != nil {
+		c.snapshotMutex.Lock()
+		err := writeSnapshot(target, state)
+		c.snapshotMutex.Unlock()
+		if err != nil {
+			return state, fmt.Errorf("tlru.CloseAndExport: %w", err)
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,54 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheCloseAndExport(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var expiredBatch []EvictedEntry[string, int]
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			OnExpiredBatch: func(entries []EvictedEntry[string, int]) {
+				expiredBatch = entries
+			},
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, time.Now().Add(-time.Hour))
+
+		state, err := cache.CloseAndExport()
+		assert.NoError(err)
+		assert.Equal(2, len(state.Entries))
+		assert.Equal(entry2.Key, state.Entries[0].Key)
+		assert.Equal(entry1.Key, state.Entries[1].Key)
+		assert.Equal(1, len(expiredBatch))
+		assert.Equal(entry3.Key, expiredBatch[0].Key)
+
+		assert.True(errors.Is(cache.Set(entry4.Key, entry4.Value), ErrClosed))
+		assert.True(errors.Is(cache.SetState(state), ErrClosed))
+		assert.False(cache.SetIfPresent(entry1.Key, 10))
+		cache.Delete(entry1.Key)
+		cache.Clear()
+		assert.Equal(2, cache.Len())
+		assert.Equal(entry1.Value, cache.Get(entry1.Key).Value)
+
+		_, err = cache.CloseAndExport()
+		assert.True(errors.Is(err, ErrClosed))
+
+		restored := New(config)
+		assert.NoError(restored.SetState(state))
+		assert.Equal(2, restored.Len())
+	}
+}
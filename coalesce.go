@@ -0,0 +1,62 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// coalesce replaces the value of the node if the provided write falls within
+// Config.CoalesceWindow since its last uncoalesced write and schedules the emission of
+// the coalesced writes. It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) coalesce(linkedNode *doublyLinkedNode[K, V], entry Entry[K, V]) bool {
+	now := time.Now()
+	if c.config.CoalesceWindow <= 0 || entry.Timestamp != nil || linkedNode.isExpired(now) ||
+		now.Sub(linkedNode.writtenAt) >= c.config.CoalesceWindow {
+		return false
+	}
+
+	c.finalize(linkedNode)
+	linkedNode.writeValue(entry.Value, c.nextVersion())
+	c.rewrite(linkedNode)
+	linkedNode.metadata = entry.Metadata
+	linkedNode.ttl, linkedNode.customTTL = c.entryTTL(entry.TTL)
+	linkedNode.coalesced++
+	c.coalescedWrites++
+	if linkedNode.coalesced == 1 {
+		atomic.AddInt32(&c.coalesceTimers, 1)
+		time.AfterFunc(c.config.CoalesceWindow-now.Sub(linkedNode.writtenAt), func() {
+			c.flushCoalesced(linkedNode)
+		})
+	}
+
+	return true
+}
+
+// flushCoalesced marks the node as written and emits an Operation with its current value
+// if writes have been coalesced into it
+func (c *TLRU[K, V]) flushCoalesced(linkedNode *doublyLinkedNode[K, V]) {
+	defer c.unlock()
+	c.Lock()
+	atomic.AddInt32(&c.coalesceTimers, -1)
+
+	if c.closed || c.cache[linkedNode.key] != linkedNode || linkedNode.coalesced == 0 {
+		return
+	}
+	operation := Operation[K, V]{
+		Type:      OperationSet,
+		Event:     EventUpdated,
+		Key:       linkedNode.key,
+		Value:     linkedNode.readValue(),
+		Coalesced: linkedNode.coalesced,
+	}
+	now := time.Now()
+	linkedNode.coalesced = 0
+	linkedNode.writtenAt = now
+	c.touch(linkedNode, now)
+	operation.LastUsedAt = linkedNode.lastUsedAt
+	c.emitOperation(operation)
+}
@@ -0,0 +1,52 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheCoalesceWindow(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		operationChannel := make(chan Operation[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:          3,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			OperationChannel: &operationChannel,
+			CoalesceWindow:   20 * time.Millisecond,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, 1)
+		cache.Set(entry2.Key, entry2.Value)
+		assert.Equal(EventInserted, (<-operationChannel).Event)
+		<-operationChannel
+
+		cache.SetIfPresent(entry1.Key, 2)
+		cache.Swap(entry1.Key, 3)
+		cache.SetIfPresent(entry1.Key, 4)
+		assert.Equal(4, cache.Peek(entry1.Key).Value)
+		assert.Equal(0, len(operationChannel))
+		lruKey, _ := cache.LRUKey()
+		assert.Equal(entry1.Key, lruKey)
+		assert.Equal(uint64(3), cache.Stats().CoalescedWrites)
+
+		operation := <-operationChannel
+		assert.Equal(EventUpdated, operation.Event)
+		assert.Equal(4, operation.Value)
+		assert.Equal(3, operation.Coalesced)
+		lruKey, _ = cache.LRUKey()
+		assert.Equal(entry2.Key, lruKey)
+
+		time.Sleep(config.CoalesceWindow)
+		cache.SetIfPresent(entry1.Key, 5)
+		operation = <-operationChannel
+		assert.Equal(5, operation.Value)
+		assert.Equal(0, operation.Coalesced)
+	}
+}
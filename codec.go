@@ -0,0 +1,137 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// encodedState is a State whose values have been encoded by Config.ValueMarshaler
+type encodedState[K comparable] struct {
+	Entries        []encodedStateEntry[K] `json:"entries"`
+	EvictionPolicy evictionPolicy         `json:"eviction_policy"`
+	ExtractedAt    time.Time              `json:"extracted_at"`
+}
+
+type encodedStateEntry[K comparable] struct {
+	Key        K                 `json:"key"`
+	Value      []byte            `json:"value"`
+	Counter    int64             `json:"counter"`
+	LastUsedAt time.Time         `json:"last_used_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	TTL        time.Duration     `json:"ttl,omitempty"`
+}
+
+// encodeState encodes the values of the provided State via the provided ValueMarshaler
+func encodeState[K comparable, V any](state State[K, V], marshal func(value V) ([]byte, error)) (encodedState[K], error) {
+	encoded := encodedState[K]{
+		Entries:        make([]encodedStateEntry[K], 0, len(state.Entries)),
+		EvictionPolicy: state.EvictionPolicy,
+		ExtractedAt:    state.ExtractedAt,
+	}
+	for _, stateEntry := range state.Entries {
+		value, err := marshal(stateEntry.Value)
+		if err != nil {
+			return encoded, fmt.Errorf("Value of key '%+v' can't be encoded: %w", stateEntry.Key, err)
+		}
+		encoded.Entries = append(encoded.Entries, encodedStateEntry[K]{
+			Key:        stateEntry.Key,
+			Value:      value,
+			Counter:    stateEntry.Counter,
+			LastUsedAt: stateEntry.LastUsedAt,
+			CreatedAt:  stateEntry.CreatedAt,
+			Metadata:   stateEntry.Metadata,
+			TTL:        stateEntry.TTL,
+		})
+	}
+
+	return encoded, nil
+}
+
+// decodeState decodes the values of the provided encodedState via the provided ValueUnmarshaler
+func decodeState[K comparable, V any](encoded encodedState[K], unmarshal func(data []byte) (V, error)) (State[K, V], error) {
+	state := State[K, V]{
+		Entries:        make([]StateEntry[K, V], 0, len(encoded.Entries)),
+		EvictionPolicy: encoded.EvictionPolicy,
+		ExtractedAt:    encoded.ExtractedAt,
+	}
+	for _, encodedEntry := range encoded.Entries {
+		value, err := unmarshal(encodedEntry.Value)
+		if err != nil {
+			return state, fmt.Errorf("Value of key '%+v' can't be decoded: %w", encodedEntry.Key, err)
+		}
+		state.Entries = append(state.Entries, StateEntry[K, V]{
+			Key:        encodedEntry.Key,
+			Value:      value,
+			Counter:    encodedEntry.Counter,
+			LastUsedAt: encodedEntry.LastUsedAt,
+			CreatedAt:  encodedEntry.CreatedAt,
+			Metadata:   encodedEntry.Metadata,
+			TTL:        encodedEntry.TTL,
+		})
+	}
+
+	return state, nil
+}
+
+// valueCodec returns Config.ValueMarshaler and Config.ValueUnmarshaler
+func (c *TLRU[K, V]) valueCodec() (func(value V) ([]byte, error), func(data []byte) (V, error)) {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.config.ValueMarshaler, c.config.ValueUnmarshaler
+}
+
+// MarshalJSON implements json.Marshaler
+// It encodes the State of the cache. Values are encoded via Config.ValueMarshaler if it is set
+// and are then held as base64 strings
+func (c *TLRU[K, V]) MarshalJSON() ([]byte, error) {
+	marshal, _ := c.valueCodec()
+	state := c.GetState()
+	if marshal == nil {
+		return json.Marshal(state)
+	}
+
+	encoded, err := encodeState(state, marshal)
+	if err != nil {
+		return nil, fmt.Errorf("tlru.MarshalJSON: %w", err)
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+// It replaces the entries of the cache with the decoded State via the SetState method so
+// the cache must have been created via New with the Config it has been encoded with
+func (c *TLRU[K, V]) UnmarshalJSON(data []byte) error {
+	c.RLock()
+	initialized := c.cache != nil
+	c.RUnlock()
+	if !initialized {
+		return fmt.Errorf("tlru.UnmarshalJSON: Cache has not been created via New")
+	}
+
+	_, unmarshal := c.valueCodec()
+	var state State[K, V]
+	if unmarshal == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("tlru.UnmarshalJSON: %w", err)
+		}
+	} else {
+		var encoded encodedState[K]
+		if err := json.Unmarshal(data, &encoded); err != nil {
+			return fmt.Errorf("tlru.UnmarshalJSON: %w", err)
+		}
+		var err error
+		if state, err = decodeState(encoded, unmarshal); err != nil {
+			return fmt.Errorf("tlru.UnmarshalJSON: %w", err)
+		}
+	}
+
+	return c.SetState(state)
+}
@@ -0,0 +1,86 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type subscription struct {
+	topic    string
+	messages chan string
+}
+
+func subscriptionConfig(policy evictionPolicy) Config[string, subscription] {
+	return Config[string, subscription]{
+		MaxSize:        3,
+		TTL:            time.Minute,
+		EvictionPolicy: policy,
+		ValueMarshaler: func(value subscription) ([]byte, error) {
+			if value.topic == "" {
+				return nil, errors.New("missing topic")
+			}
+			return []byte(value.topic), nil
+		},
+		ValueUnmarshaler: func(data []byte) (subscription, error) {
+			return subscription{topic: string(data), messages: make(chan string)}, nil
+		},
+	}
+}
+
+func TestLRUCacheValueMarshaler(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		cache := New(subscriptionConfig(policy))
+		cache.Set(entry1.Key, subscription{topic: "news", messages: make(chan string)})
+		cache.Set(entry2.Key, subscription{topic: "sports", messages: make(chan string)})
+
+		data, err := json.Marshal(cache)
+		assert.NoError(err)
+		restored := New(subscriptionConfig(policy))
+		assert.NoError(json.Unmarshal(data, restored))
+		assert.Equal(2, restored.Len())
+		assert.Equal("news", restored.Peek(entry1.Key).Value.topic)
+		assert.NotNil(restored.Peek(entry1.Key).Value.messages)
+
+		data, err = cache.MarshalBinary()
+		assert.NoError(err)
+		restored = &TLRU[string, subscription]{}
+		restored.config.ValueUnmarshaler = subscriptionConfig(policy).ValueUnmarshaler
+		assert.NoError(restored.UnmarshalBinary(data))
+		assert.Equal(2, restored.Len())
+		assert.Equal("sports", restored.Peek(entry2.Key).Value.topic)
+		assert.Equal(cache.GetState().Entries[0].Counter, restored.GetState().Entries[0].Counter)
+
+		cache.Set(entry3.Key, subscription{})
+		_, err = cache.MarshalBinary()
+		assert.Error(err)
+		_, err = json.Marshal(cache)
+		assert.Error(err)
+	}
+}
+
+func TestLRUCacheMarshalJSON(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{MaxSize: 3, TTL: time.Minute, EvictionPolicy: policy}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+
+		data, err := json.Marshal(cache)
+		assert.NoError(err)
+		restored := New(config)
+		assert.NoError(json.Unmarshal(data, restored))
+		assert.Equal(entry1.Value, restored.Peek(entry1.Key).Value)
+		assert.Equal(entry2.Value, restored.Peek(entry2.Key).Value)
+
+		assert.Error(json.Unmarshal(data, &TLRU[string, int]{}))
+	}
+}
@@ -0,0 +1,354 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type loadResult[V any] struct {
+	value V
+	err   error
+}
+
+// negativeEntry remembers the error of a failed load until it expires
+type negativeEntry struct {
+	err       error
+	expiresAt time.Time
+	// failures is the number of consecutive failures in the LoadErrorBackoff policy
+	failures int
+	// forgetAt is the time the entry is removed from the cache. In the LoadErrorBackoff
+	// policy it is later than expiresAt so that consecutive failures can be counted
+	forgetAt time.Time
+}
+
+// BackoffState is the state of a key whose loads have failed
+type BackoffState struct {
+	// Number of consecutive failures. It is always 1 unless the LoadErrorBackoff policy is used
+	Failures int
+	// The error of the last failed load
+	LastError error
+	// The time after which the key can be loaded again
+	RetryAt time.Time
+}
+
+// GetOrCompute is identical to the GetOrComputeWithContext function but it uses
+// a background context and a loader which doesn't accept a context
+func (c *TLRU[K, V]) GetOrCompute(key K, loader func(key K) (V, error)) (V, error) {
+	return c.GetOrComputeWithContext(context.Background(), key, func(ctx context.Context, key K) (V, error) {
+		return loader(key)
+	})
+}
+
+// GetOrComputeWithContext returns the value of the entry that corresponds to the provided key
+// On a miss the loader is invoked and its result is cached. Loader errors are
+// returned to the caller and are handled according to Config.LoadErrorPolicy
+// The loader receives a context which is done when the provided context is done or
+// Config.LoadTimeout is exceeded. GetOrComputeWithContext then returns immediately with
+// an error that wraps the error of the context and the result of the loader is discarded.
+// If Config.NegativeTTL is set a load that timed out is remembered for that duration and
+// subsequent calls for the same key fail fast with the same error
+// If Config.StaleRefreshTimeout is set and the entry is expired but not yet evicted,
+// GetOrComputeWithContext waits up to that duration for the loader to refresh the entry.
+// If the loader doesn't finish in time or its context is done the stale value is returned.
+// The refreshed value is cached once the loader finishes
+func (c *TLRU[K, V]) GetOrComputeWithContext(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	var value V
+	if err := c.checkMisuse("GetOrCompute", false); err != nil {
+		return value, err
+	}
+	startedAt := time.Now()
+	stale, isStale := c.staleValue(key)
+	if err := c.negativeError(key); err != nil {
+		if isStale {
+			return stale, nil
+		}
+		return value, err
+	}
+
+	if isStale {
+		return c.refresh(ctx, key, stale, loader)
+	}
+
+	if cacheEntry := c.Get(key); cacheEntry != nil {
+		c.hitLatencies.record(time.Since(startedAt))
+		return cacheEntry.Value, nil
+	}
+
+	loadCtx, results := c.load(ctx, key, loader)
+	select {
+	case result := <-results:
+		if result.err == nil {
+			c.missLatencies.record(time.Since(startedAt))
+		}
+		return result.value, result.err
+	case <-loadCtx.Done():
+		return value, c.loadCanceled(ctx, loadCtx, key)
+	}
+}
+
+// GetOrComputeMany returns the values of the entries that correspond to the provided keys
+// The keys which are missing from the cache are passed to the loader at once, which makes it
+// suitable for backends that support batch fetches, and the loaded values are cached. Keys
+// which the loader doesn't return are missing from the returned map. If the loader fails the
+// values of the cached keys are returned along with the error of the loader
+// The loader is bounded by Config.MaxConcurrentLoads like the loaders of GetOrCompute
+func (c *TLRU[K, V]) GetOrComputeMany(keys []K, loader func(keys []K) (map[K]V, error)) (map[K]V, error) {
+	values := make(map[K]V, len(keys))
+	seen := make(map[K]struct{}, len(keys))
+	missing := make([]K, 0)
+	for _, key := range keys {
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		if cacheEntry := c.Get(key); cacheEntry != nil {
+			values[key] = cacheEntry.Value
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	c.RLock()
+	epoch, loadSlots := c.epoch, c.loadSlots
+	c.RUnlock()
+	if err := c.acquireLoadSlot(context.Background(), loadSlots); err != nil {
+		return values, fmt.Errorf("tlru.GetOrComputeMany: %w", err)
+	}
+	if loadSlots != nil {
+		defer func() { <-loadSlots }()
+	}
+
+	loaded, err := c.callManyLoader(missing, loader)
+	if err != nil {
+		return values, fmt.Errorf("tlru.GetOrComputeMany: %w", err)
+	}
+
+	defer c.unlock()
+	c.Lock()
+	for _, key := range missing {
+		value, exists := loaded[key]
+		if !exists {
+			continue
+		}
+		values[key] = value
+		if c.epoch == epoch {
+			c.cacheLoaded(key, value)
+		}
+	}
+
+	return values, nil
+}
+
+// staleValue returns the value of an expired entry that is still in the cache
+// if Config.StaleRefreshTimeout is set
+func (c *TLRU[K, V]) staleValue(key K) (V, bool) {
+	var value V
+	if c.config.StaleRefreshTimeout <= 0 {
+		return value, false
+	}
+
+	defer c.RUnlock()
+	c.RLock()
+
+	linkedNode, exists := c.cache[key]
+	if !exists || !linkedNode.isExpired(time.Now()) {
+		return value, false
+	}
+
+	return linkedNode.readValue(), true
+}
+
+func (c *TLRU[K, V]) refresh(ctx context.Context, key K, stale V, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	loadCtx, results := c.load(ctx, key, loader)
+
+	timer := time.NewTimer(c.config.StaleRefreshTimeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-results:
+		return result.value, result.err
+	case <-loadCtx.Done():
+		c.loadCanceled(ctx, loadCtx, key)
+		return stale, nil
+	case <-timer.C:
+		return stale, nil
+	}
+}
+
+// load invokes the loader in a new goroutine with a context which is bounded by
+// Config.LoadTimeout. The loaded value is cached unless the context is done
+// before the loader returns
+func (c *TLRU[K, V]) load(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (context.Context, <-chan loadResult[V]) {
+	loadCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.config.LoadTimeout > 0 {
+		loadCtx, cancel = context.WithTimeout(ctx, c.config.LoadTimeout)
+	}
+
+	c.RLock()
+	epoch, loadSlots := c.epoch, c.loadSlots
+	c.RUnlock()
+
+	results := make(chan loadResult[V], 1)
+	atomic.AddInt32(&c.runningLoaders, 1)
+	go func() {
+		defer atomic.AddInt32(&c.runningLoaders, -1)
+		defer cancel()
+		if err := c.acquireLoadSlot(loadCtx, loadSlots); err != nil {
+			results <- loadResult[V]{err: err}
+			return
+		}
+		if loadSlots != nil {
+			defer func() { <-loadSlots }()
+		}
+
+		value, err := c.callLoader(loadCtx, key, loader)
+		if loadCtx.Err() == nil {
+			if err == nil {
+				c.storeLoaded(key, value, epoch)
+			} else {
+				c.rememberFailure(key, err, false)
+			}
+		}
+		results <- loadResult[V]{value: value, err: err}
+	}()
+
+	return loadCtx, results
+}
+
+// acquireLoadSlot blocks until the number of running loads is below Config.MaxConcurrentLoads
+// or fails according to Config.LoadLimitPolicy
+func (c *TLRU[K, V]) acquireLoadSlot(ctx context.Context, loadSlots chan struct{}) error {
+	if loadSlots == nil {
+		return nil
+	}
+	select {
+	case loadSlots <- struct{}{}:
+		return nil
+	default:
+	}
+	if c.config.LoadLimitPolicy == LoadLimitFailFast {
+		return fmt.Errorf("tlru.GetOrCompute: %d loads are running. %w", cap(loadSlots), ErrTooManyLoads)
+	}
+
+	select {
+	case loadSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loadCanceled returns the error of a load whose context is done and remembers it
+// if it was caused by Config.LoadTimeout
+func (c *TLRU[K, V]) loadCanceled(ctx context.Context, loadCtx context.Context, key K) error {
+	err := fmt.Errorf("tlru.GetOrCompute: Load of key '%+v' has been canceled: %w", key, loadCtx.Err())
+	if ctx.Err() == nil {
+		c.rememberFailure(key, err, true)
+	}
+
+	return err
+}
+
+// rememberFailure remembers the error of a failed load according to Config.LoadErrorPolicy
+// Timeouts are also remembered for Config.NegativeTTL in the LoadErrorNotCached policy
+func (c *TLRU[K, V]) rememberFailure(key K, err error, timeout bool) {
+	defer c.unlock()
+	c.Lock()
+
+	now := time.Now()
+	failure := negativeEntry{err: err, failures: 1}
+	switch {
+	case c.config.LoadErrorPolicy == LoadErrorBackoff:
+		if previousFailure, exists := c.negativeEntries[key]; exists {
+			failure.failures = previousFailure.failures + 1
+		}
+		backoff := c.config.LoadErrorMaxBackoff
+		if failure.failures < 32 && c.config.LoadErrorBackoff<<(failure.failures-1) < backoff {
+			backoff = c.config.LoadErrorBackoff << (failure.failures - 1)
+		}
+		failure.expiresAt = now.Add(backoff)
+		failure.forgetAt = failure.expiresAt.Add(c.config.LoadErrorMaxBackoff)
+	case c.config.NegativeTTL > 0 && (timeout || c.config.LoadErrorPolicy == LoadErrorCached):
+		failure.expiresAt = now.Add(c.config.NegativeTTL)
+		failure.forgetAt = failure.expiresAt
+	default:
+		return
+	}
+
+	if c.negativeEntries == nil {
+		c.negativeEntries = make(map[K]negativeEntry)
+	}
+	c.negativeEntries[key] = failure
+}
+
+// LoadBackoff returns the BackoffState of a key whose failed load is remembered
+// It returns false if no failure is remembered for the provided key
+func (c *TLRU[K, V]) LoadBackoff(key K) (BackoffState, bool) {
+	defer c.RUnlock()
+	c.RLock()
+
+	negativeEntry, exists := c.negativeEntries[key]
+	if !exists {
+		return BackoffState{}, false
+	}
+
+	return BackoffState{
+		Failures:  negativeEntry.failures,
+		LastError: negativeEntry.err,
+		RetryAt:   negativeEntry.expiresAt,
+	}, true
+}
+
+// negativeError returns the remembered error of a failed load of the provided key
+func (c *TLRU[K, V]) negativeError(key K) error {
+	defer c.RUnlock()
+	c.RLock()
+
+	negativeEntry, exists := c.negativeEntries[key]
+	if !exists || time.Now().After(negativeEntry.expiresAt) {
+		return nil
+	}
+
+	return negativeEntry.err
+}
+
+// evictExpiredNegativeEntries must be called while holding the lock of the cache
+func (c *TLRU[K, V]) evictExpiredNegativeEntries() {
+	now := time.Now()
+	for key, negativeEntry := range c.negativeEntries {
+		if now.After(negativeEntry.forgetAt) {
+			delete(c.negativeEntries, key)
+		}
+	}
+}
+
+// storeLoaded caches a loaded value by replacing the entry of the provided key if it is expired
+// An entry that has been inserted concurrently in the LRA EvictionPolicy is kept
+// The value is discarded if the entries of the cache have been replaced since the load started
+func (c *TLRU[K, V]) storeLoaded(key K, value V, epoch uint64) {
+	defer c.unlock()
+	c.Lock()
+
+	if c.epoch != epoch {
+		return
+	}
+	c.cacheLoaded(key, value)
+}
+
+// cacheLoaded must be called while holding the lock of the cache
+func (c *TLRU[K, V]) cacheLoaded(key K, value V) {
+	if linkedNode, exists := c.cache[key]; exists && linkedNode.isExpired(time.Now()) {
+		c.evictEntry(linkedNode, EvictionReasonExpired)
+	}
+	delete(c.negativeEntries, key)
+	c.insert(Entry[K, V]{Key: key, Value: value})
+}
@@ -0,0 +1,395 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheGetOrCompute(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		calls := 0
+		loader := func(key string) (int, error) {
+			calls++
+			return len(key), nil
+		}
+
+		value, err := cache.GetOrCompute(entry1.Key, loader)
+		assert.NoError(err)
+		assert.Equal(len(entry1.Key), value)
+		value, err = cache.GetOrCompute(entry1.Key, loader)
+		assert.NoError(err)
+		assert.Equal(len(entry1.Key), value)
+		assert.Equal(1, calls)
+
+		_, err = cache.GetOrCompute(entry2.Key, func(key string) (int, error) {
+			return 0, errors.New("unavailable")
+		})
+		assert.Error(err)
+		assert.Nil(cache.Get(entry2.Key))
+
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, time.Now().Add(-time.Hour))
+		value, err = cache.GetOrCompute(entry3.Key, loader)
+		assert.NoError(err)
+		assert.Equal(len(entry3.Key), value)
+	}
+}
+
+func TestLRUCacheGetOrComputeWithStaleRefreshTimeout(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:             10,
+			TTL:                 time.Minute,
+			EvictionPolicy:      policy,
+			StaleRefreshTimeout: 10 * time.Millisecond,
+		}
+		cache := New(config)
+		expiredTimestamp := time.Now().Add(-time.Hour)
+
+		cache.SetWithTimestamp(entry1.Key, entry1.Value, expiredTimestamp)
+		value, err := cache.GetOrCompute(entry1.Key, func(key string) (int, error) {
+			return 10, nil
+		})
+		assert.NoError(err)
+		assert.Equal(10, value)
+
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, expiredTimestamp)
+		refreshed := make(chan struct{})
+		value, err = cache.GetOrCompute(entry2.Key, func(key string) (int, error) {
+			defer close(refreshed)
+			time.Sleep(50 * time.Millisecond)
+			return 20, nil
+		})
+		assert.NoError(err)
+		assert.Equal(entry2.Value, value)
+
+		<-refreshed
+		assert.Eventually(func() bool {
+			cacheEntry := cache.Get(entry2.Key)
+			return cacheEntry != nil && cacheEntry.Value == 20
+		}, time.Second, time.Millisecond)
+	}
+}
+
+func TestLRUCacheGetOrComputeWithLoadTimeout(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			LoadTimeout:    10 * time.Millisecond,
+			NegativeTTL:    time.Minute,
+		}
+		cache := New(config)
+
+		var calls int32
+		slowLoader := func(ctx context.Context, key string) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+
+		_, err := cache.GetOrComputeWithContext(context.Background(), entry1.Key, slowLoader)
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+		_, err = cache.GetOrComputeWithContext(context.Background(), entry1.Key, slowLoader)
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(int32(1), atomic.LoadInt32(&calls))
+		assert.Nil(cache.Get(entry1.Key))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = cache.GetOrComputeWithContext(ctx, entry2.Key, slowLoader)
+		assert.True(errors.Is(err, context.Canceled))
+		value, err := cache.GetOrCompute(entry2.Key, func(key string) (int, error) {
+			return entry2.Value, nil
+		})
+		assert.NoError(err)
+		assert.Equal(entry2.Value, value)
+
+		cache.Clear()
+		value, err = cache.GetOrCompute(entry1.Key, func(key string) (int, error) {
+			return entry1.Value, nil
+		})
+		assert.NoError(err)
+		assert.Equal(entry1.Value, value)
+	}
+}
+
+func TestLRUCacheGetOrComputeDiscardsLoadsOfPreviousEpoch(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			value, err := cache.GetOrCompute(entry1.Key, func(key string) (int, error) {
+				close(started)
+				<-release
+				return entry1.Value, nil
+			})
+			assert.NoError(err)
+			assert.Equal(entry1.Value, value)
+		}()
+
+		<-started
+		cache.Clear()
+		close(release)
+		<-done
+
+		assert.Nil(cache.Get(entry1.Key))
+		assert.Equal(0, cache.Len())
+	}
+}
+
+func TestLRUCacheGetOrComputeMaxConcurrentLoads(t *testing.T) {
+	assert := assert.New(t)
+	for _, loadLimitPolicy := range []loadLimitPolicy{LoadLimitWait, LoadLimitFailFast} {
+		config := Config[string, int]{
+			MaxSize:            10,
+			TTL:                time.Minute,
+			MaxConcurrentLoads: 1,
+			LoadLimitPolicy:    loadLimitPolicy,
+		}
+		cache := New(config)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cache.GetOrCompute(entry1.Key, func(key string) (int, error) {
+				close(started)
+				<-release
+				return entry1.Value, nil
+			})
+		}()
+		<-started
+
+		loader := func(key string) (int, error) {
+			return entry2.Value, nil
+		}
+		if loadLimitPolicy == LoadLimitFailFast {
+			_, err := cache.GetOrCompute(entry2.Key, loader)
+			assert.True(errors.Is(err, ErrTooManyLoads))
+			close(release)
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			_, err := cache.GetOrComputeWithContext(ctx, entry2.Key, func(ctx context.Context, key string) (int, error) {
+				return loader(key)
+			})
+			cancel()
+			assert.True(errors.Is(err, context.DeadlineExceeded))
+			time.AfterFunc(10*time.Millisecond, func() { close(release) })
+		}
+
+		value, err := cache.GetOrCompute(entry3.Key, func(key string) (int, error) {
+			return entry3.Value, nil
+		})
+		<-done
+		if loadLimitPolicy == LoadLimitWait {
+			assert.NoError(err)
+			assert.Equal(entry3.Value, value)
+		}
+		assert.Nil(cache.Peek(entry2.Key))
+		assert.Equal(entry1.Value, cache.Peek(entry1.Key).Value)
+	}
+}
+
+func TestLRUCacheGetOrComputeLoadErrorPolicies(t *testing.T) {
+	assert := assert.New(t)
+	errUnavailable := errors.New("unavailable")
+	for _, policy := range policies {
+		var calls int32
+		failingLoader := func(key string) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			return 0, errUnavailable
+		}
+
+		cache := New(Config[string, int]{MaxSize: 10, TTL: time.Minute, EvictionPolicy: policy})
+		cache.GetOrCompute(entry1.Key, failingLoader)
+		cache.GetOrCompute(entry1.Key, failingLoader)
+		assert.Equal(int32(2), atomic.LoadInt32(&calls))
+		_, exists := cache.LoadBackoff(entry1.Key)
+		assert.False(exists)
+
+		atomic.StoreInt32(&calls, 0)
+		cache = New(Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			LoadErrorPolicy: LoadErrorCached,
+			NegativeTTL:     time.Minute,
+		})
+		cache.GetOrCompute(entry1.Key, failingLoader)
+		_, err := cache.GetOrCompute(entry1.Key, failingLoader)
+		assert.Equal(errUnavailable, err)
+		assert.Equal(int32(1), atomic.LoadInt32(&calls))
+
+		atomic.StoreInt32(&calls, 0)
+		cache = New(Config[string, int]{
+			MaxSize:          10,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			LoadErrorPolicy:  LoadErrorBackoff,
+			LoadErrorBackoff: 10 * time.Millisecond,
+		})
+		cache.GetOrCompute(entry1.Key, failingLoader)
+		cache.GetOrCompute(entry1.Key, failingLoader)
+		backoffState, exists := cache.LoadBackoff(entry1.Key)
+		assert.True(exists)
+		assert.Equal(1, backoffState.Failures)
+		assert.Equal(errUnavailable, backoffState.LastError)
+		assert.Equal(int32(1), atomic.LoadInt32(&calls))
+
+		time.Sleep(time.Until(backoffState.RetryAt) + time.Millisecond)
+		cache.GetOrCompute(entry1.Key, failingLoader)
+		backoffState, _ = cache.LoadBackoff(entry1.Key)
+		assert.Equal(2, backoffState.Failures)
+		assert.Equal(int32(2), atomic.LoadInt32(&calls))
+
+		time.Sleep(time.Until(backoffState.RetryAt) + time.Millisecond)
+		value, err := cache.GetOrCompute(entry1.Key, func(key string) (int, error) {
+			return entry1.Value, nil
+		})
+		assert.NoError(err)
+		assert.Equal(entry1.Value, value)
+		_, exists = cache.LoadBackoff(entry1.Key)
+		assert.False(exists)
+	}
+}
+
+func TestLRUCacheGetOrComputeMany(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+
+		var requested [][]string
+		loader := func(keys []string) (map[string]int, error) {
+			requested = append(requested, keys)
+			values := map[string]int{}
+			for _, key := range keys {
+				if key != entry4.Key {
+					values[key] = len(key)
+				}
+			}
+			return values, nil
+		}
+
+		values, err := cache.GetOrComputeMany([]string{entry1.Key, entry2.Key, entry3.Key, entry2.Key, entry4.Key}, loader)
+		assert.NoError(err)
+		assert.Equal(map[string]int{entry1.Key: entry1.Value, entry2.Key: len(entry2.Key), entry3.Key: len(entry3.Key)}, values)
+		assert.Equal([][]string{{entry2.Key, entry3.Key, entry4.Key}}, requested)
+		assert.Equal(len(entry2.Key), cache.Peek(entry2.Key).Value)
+		assert.Nil(cache.Peek(entry4.Key))
+
+		values, err = cache.GetOrComputeMany([]string{entry1.Key, entry2.Key}, loader)
+		assert.NoError(err)
+		assert.Equal(2, len(values))
+		assert.Equal(1, len(requested))
+
+		values, err = cache.GetOrComputeMany([]string{entry1.Key, entry4.Key}, func(keys []string) (map[string]int, error) {
+			return nil, errors.New("unavailable")
+		})
+		assert.Error(err)
+		assert.Equal(map[string]int{entry1.Key: entry1.Value}, values)
+
+		_, err = cache.GetOrComputeMany([]string{entry4.Key}, func(keys []string) (map[string]int, error) {
+			panic("boom")
+		})
+		var panicErr *PanicError
+		assert.True(errors.As(err, &panicErr))
+	}
+}
+
+func TestLRUCacheGetWithContext(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var calls int32
+		release := make(chan struct{})
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			Loader: func(ctx context.Context, key string) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				select {
+				case <-release:
+					return len(key), nil
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				}
+			},
+		}
+		cache := New(config)
+
+		values := make(chan int, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				value, err := cache.GetWithContext(context.Background(), entry1.Key)
+				assert.NoError(err)
+				values <- value
+			}()
+		}
+		assert.Eventually(func() bool {
+			cache.RLock()
+			defer cache.RUnlock()
+			return cache.loaderCalls[entry1.Key] != nil && cache.loaderCalls[entry1.Key].waiters == 3
+		}, time.Second, time.Millisecond)
+		close(release)
+		for i := 0; i < 3; i++ {
+			assert.Equal(len(entry1.Key), <-values)
+		}
+		assert.Equal(int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(len(entry1.Key), cache.Peek(entry1.Key).Value)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		value, err := cache.GetWithContext(ctx, entry1.Key)
+		assert.NoError(err)
+		assert.Equal(len(entry1.Key), value)
+
+		cache.config.Loader = func(ctx context.Context, key string) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err = cache.GetWithContext(ctx, entry2.Key)
+		cancel()
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+		assert.Nil(cache.Peek(entry2.Key))
+
+		_, err = New(Config[string, int]{MaxSize: 10, TTL: time.Minute}).GetWithContext(context.Background(), entry1.Key)
+		assert.True(errors.Is(err, ErrNotFound))
+	}
+}
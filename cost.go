@@ -0,0 +1,76 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+// Cost returns the sum of the costs of all entries as computed by Config.Weigher
+// It always returns 0 if Config.MaxCost is not set
+func (c *TLRU[K, V]) Cost() int64 {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.totalCost
+}
+
+// weighedEntry is the cost of the entry being inserted
+type weighedEntry[K comparable] struct {
+	key  K
+	cost int64
+}
+
+// weigh returns the cost of an entry which is 1 if Config.Weigher is not set
+// A panic of the Weigher is returned as a PanicError
+// It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) weigh(key K, value V) (int64, error) {
+	if c.config.Weigher == nil {
+		return 1, nil
+	}
+	var cost int64
+	err := c.protectUnderLock("Weigher", func() {
+		cost = c.config.Weigher(key, value)
+	})
+
+	return cost, err
+}
+
+// addCost must be called while holding the lock of the cache whenever a node is added to it
+// Nodes whose Weigher panics cost 1
+func (c *TLRU[K, V]) addCost(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.MaxCost <= 0 {
+		return
+	}
+	if c.weighed != nil && c.weighed.key == linkedNode.key {
+		linkedNode.cost = c.weighed.cost
+	} else if cost, err := c.weigh(linkedNode.key, linkedNode.readValue()); err == nil {
+		linkedNode.cost = cost
+	} else {
+		linkedNode.cost = 1
+	}
+	c.totalCost += linkedNode.cost
+}
+
+// removeCost must be called while holding the lock of the cache whenever a node is removed from it
+func (c *TLRU[K, V]) removeCost(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.MaxCost <= 0 {
+		return
+	}
+	c.totalCost -= linkedNode.cost
+	linkedNode.cost = 0
+}
+
+// shrinkCost drops the least recently used entries which aren't borrowed until the
+// total cost doesn't exceed Config.MaxCost
+func (c *TLRU[K, V]) shrinkCost() {
+	if c.config.MaxCost <= 0 {
+		return
+	}
+	previousNode := c.sentinel.previous
+	for c.totalCost > c.config.MaxCost && previousNode != c.sentinel {
+		droppedNode := previousNode
+		previousNode = previousNode.previous
+		if c.borrows[droppedNode.key] == nil {
+			c.evictEntry(droppedNode, EvictionReasonDropped)
+		}
+	}
+}
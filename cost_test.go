@@ -0,0 +1,160 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheMaxCost(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		for _, entryLocking := range []bool{false, true} {
+			evictionChannel := make(chan EvictedEntry[string, []byte], 10)
+			config := Config[string, []byte]{
+				TTL:             time.Minute,
+				EvictionPolicy:  policy,
+				EvictionChannel: &evictionChannel,
+				EntryLocking:    entryLocking,
+				MaxCost:         10,
+				Weigher: func(key string, value []byte) int64 {
+					return int64(len(value))
+				},
+			}
+			cache := New(config)
+
+			cache.Set(entry1.Key, make([]byte, 4))
+			cache.Set(entry2.Key, make([]byte, 4))
+			assert.Equal(int64(8), cache.Cost())
+			cache.Get(entry1.Key)
+			cache.Set(entry3.Key, make([]byte, 4))
+			assert.Equal(int64(8), cache.Cost())
+			if policy == LRA {
+				assert.Equal(entry2.Key, (<-evictionChannel).Key)
+			} else {
+				assert.Equal(entry1.Key, (<-evictionChannel).Key)
+			}
+
+			err := cache.Set(entry4.Key, make([]byte, 11))
+			assert.True(errors.Is(err, ErrCostExceeded))
+			assert.Equal(2, cache.Len())
+
+			cache.Update(entry3.Key, func(value *[]byte) {
+				*value = make([]byte, 9)
+			})
+			assert.Equal(int64(9), cache.Cost())
+			assert.Equal(1, cache.Len())
+			assert.True(cache.Has(entry3.Key))
+
+			cache.Delete(entry3.Key)
+			assert.Equal(int64(0), cache.Cost())
+		}
+	}
+}
+
+func TestLRUCacheMaxCostWithoutWeigher(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			MaxCost:        2,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		assert.Equal(2, cache.Len())
+		assert.Equal(int64(2), cache.Cost())
+		assert.False(cache.Has(entry1.Key))
+		assert.Equal(int64(0), New(Config[string, int]{MaxSize: 2, TTL: time.Minute}).Cost())
+	}
+}
+
+func TestLRUCacheRejectedInsertDoesNotEvict(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		weighs := 0
+		var panics []*PanicError
+		config := Config[string, []byte]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			MaxCost:        10,
+			Weigher: func(key string, value []byte) int64 {
+				weighs++
+				if value == nil {
+					panic("nil value")
+				}
+				return int64(len(value))
+			},
+			OnPanic: func(err *PanicError) {
+				panics = append(panics, err)
+			},
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, make([]byte, 1))
+		cache.Set(entry2.Key, make([]byte, 1))
+		assert.Equal(2, weighs)
+
+		err := cache.Set(entry3.Key, make([]byte, 11))
+		assert.True(errors.Is(err, ErrCostExceeded))
+		assert.ElementsMatch([]string{entry1.Key, entry2.Key}, cache.Keys())
+
+		var panicErr *PanicError
+		err = cache.Set(entry3.Key, nil)
+		assert.True(errors.As(err, &panicErr))
+		assert.Equal("Weigher", panicErr.Callback)
+		assert.Equal([]*PanicError{panicErr}, panics)
+		assert.ElementsMatch([]string{entry1.Key, entry2.Key}, cache.Keys())
+
+		if policy == LRA {
+			err = cache.Set(entry1.Key, make([]byte, 1))
+			assert.True(errors.Is(err, ErrReplacementNotAllowed))
+			assert.ElementsMatch([]string{entry1.Key, entry2.Key}, cache.Keys())
+		}
+		assert.Equal(4, weighs)
+	}
+}
+
+func TestLRUCacheMaxCostOnReplacement(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, []byte]{
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			MaxCost:        10,
+			Weigher: func(key string, value []byte) int64 {
+				return int64(len(value))
+			},
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, make([]byte, 5))
+		cache.Set(entry2.Key, make([]byte, 5))
+
+		// Oversized values are rejected and keep the existing entries
+		assert.False(cache.SetIfPresent(entry1.Key, make([]byte, 50)))
+		_, _, err := cache.Swap(entry2.Key, make([]byte, 50))
+		assert.True(errors.Is(err, ErrCostExceeded))
+		err = cache.SetIfVersion(entry1.Key, make([]byte, 50), cache.Peek(entry1.Key).Version)
+		assert.True(errors.Is(err, ErrCostExceeded))
+		assert.Equal(int64(10), cache.Cost())
+		assert.Equal(2, cache.Len())
+
+		// Larger values make room for themselves
+		assert.True(cache.SetIfPresent(entry1.Key, make([]byte, 8)))
+		assert.Equal(int64(8), cache.Cost())
+		assert.False(cache.Has(entry2.Key))
+		previous, loaded, err := cache.Swap(entry1.Key, make([]byte, 10))
+		assert.NoError(err)
+		assert.True(loaded)
+		assert.Equal(8, len(previous))
+		assert.Equal(int64(10), cache.Cost())
+	}
+}
@@ -0,0 +1,88 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type evictionDeliveryPolicy int
+
+func (p evictionDeliveryPolicy) String() string {
+	return [...]string{0: "Block", 1: "DropNotification", 2: "BufferUnbounded"}[p]
+}
+
+// evictionOverflow queues the EvictedEntries which couldn't be sent to the
+// EvictionChannel right away in the EvictionDeliveryBufferUnbounded policy
+// A single goroutine forwards them in order while the queue is not empty
+type evictionOverflow[K comparable, V any] struct {
+	mutex      sync.Mutex
+	entries    []EvictedEntry[K, V]
+	forwarding bool
+}
+
+// deliverEviction emits the EvictedEntry to the EvictionChannel according to
+// Config.EvictionDelivery. It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) deliverEviction(evictedEntry EvictedEntry[K, V]) {
+	evictionChannel := *c.config.EvictionChannel
+	switch c.config.EvictionDelivery {
+	case EvictionDeliveryDropNotification:
+		select {
+		case evictionChannel <- evictedEntry:
+		default:
+			atomic.AddUint64(&c.operations.droppedNotifications, 1)
+		}
+	case EvictionDeliveryBufferUnbounded:
+		c.overflow.push(evictionChannel, evictedEntry)
+	default:
+		evictionChannel <- evictedEntry
+	}
+}
+
+// push sends the EvictedEntry to the channel if it has room and nothing is queued
+// before it, otherwise it queues the EvictedEntry and starts the forwarding goroutine
+func (o *evictionOverflow[K, V]) push(evictionChannel chan EvictedEntry[K, V], evictedEntry EvictedEntry[K, V]) {
+	defer o.mutex.Unlock()
+	o.mutex.Lock()
+	if !o.forwarding {
+		select {
+		case evictionChannel <- evictedEntry:
+			return
+		default:
+		}
+	}
+	o.entries = append(o.entries, evictedEntry)
+	if !o.forwarding {
+		o.forwarding = true
+		go o.forward(evictionChannel)
+	}
+}
+
+// forward sends the queued EvictedEntries to the channel until the queue is empty
+func (o *evictionOverflow[K, V]) forward(evictionChannel chan EvictedEntry[K, V]) {
+	for {
+		o.mutex.Lock()
+		if len(o.entries) == 0 {
+			o.entries = nil
+			o.forwarding = false
+			o.mutex.Unlock()
+			return
+		}
+		evictedEntry := o.entries[0]
+		o.entries = o.entries[1:]
+		o.mutex.Unlock()
+
+		evictionChannel <- evictedEntry
+	}
+}
+
+// pending returns the number of queued EvictedEntries
+func (o *evictionOverflow[K, V]) pending() int {
+	defer o.mutex.Unlock()
+	o.mutex.Lock()
+
+	return len(o.entries)
+}
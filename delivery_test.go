@@ -0,0 +1,66 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheEvictionDeliveryDropNotification(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 1)
+		config := Config[string, int]{
+			MaxSize:          1,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			EvictionChannel:  &evictionChannel,
+			EvictionDelivery: EvictionDeliveryDropNotification,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Delete(entry3.Key)
+
+		assert.Equal(entry1.Key, (<-evictionChannel).Key)
+		assert.Equal(0, len(evictionChannel))
+		assert.Equal(uint64(2), cache.Stats().DroppedEvictionNotifications)
+	}
+}
+
+func TestLRUCacheEvictionDeliveryBufferUnbounded(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int])
+		config := Config[string, int]{
+			MaxSize:          1,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			EvictionChannel:  &evictionChannel,
+			EvictionDelivery: EvictionDeliveryBufferUnbounded,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Delete(entry3.Key)
+		assert.Equal(0, cache.Len())
+
+		var keys []string
+		for i := 0; i < 3; i++ {
+			keys = append(keys, (<-evictionChannel).Key)
+		}
+		assert.Equal([]string{entry1.Key, entry2.Key, entry3.Key}, keys)
+		assert.Eventually(func() bool {
+			return cache.Stats().PendingEvictionNotifications == 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(uint64(0), cache.Stats().DroppedEvictionNotifications)
+	}
+}
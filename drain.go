@@ -0,0 +1,45 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+)
+
+// DrainEvictions consumes the EvictionChannel of the provided cache in a new goroutine
+// and invokes fn for every EvictedEntry
+// Draining stops when the context is done or the EvictionChannel is closed. The returned
+// channel is closed once draining has stopped. A panic in fn is recovered and reported
+// to Config.OnPanic so that a faulty callback doesn't stop the consumption of subsequent evictions
+// If the cache has no EvictionChannel the returned channel is already closed
+// Since the cache blocks while emitting to an unbuffered EvictionChannel in the
+// EvictionDeliveryBlock policy, the context should only be done once the cache is no longer used
+func DrainEvictions[K comparable, V any](ctx context.Context, cache *TLRU[K, V], fn func(EvictedEntry[K, V])) <-chan struct{} {
+	done := make(chan struct{})
+	if cache.config.EvictionChannel == nil {
+		close(done)
+		return done
+	}
+
+	evictionChannel := *cache.config.EvictionChannel
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case evictedEntry, ok := <-evictionChannel:
+				if !ok {
+					return
+				}
+				cache.protect("DrainEvictions", func() {
+					fn(evictedEntry)
+				})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return done
+}
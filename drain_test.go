@@ -0,0 +1,57 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainEvictions(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int])
+		config := Config[string, int]{
+			MaxSize:         1,
+			TTL:             time.Minute,
+			EvictionChannel: &evictionChannel,
+			EvictionPolicy:  policy,
+		}
+		cache := New(config)
+
+		var evictedKeys []string
+		done := DrainEvictions(context.Background(), cache, func(evictedEntry EvictedEntry[string, int]) {
+			evictedKeys = append(evictedKeys, evictedEntry.Key)
+			if evictedEntry.Key == entry1.Key {
+				panic("faulty callback")
+			}
+		})
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		close(evictionChannel)
+		<-done
+
+		assert.Equal([]string{entry1.Key, entry2.Key}, evictedKeys)
+	}
+}
+
+func TestDrainEvictionsStops(t *testing.T) {
+	assert := assert.New(t)
+	cache := New(Config[string, int]{MaxSize: 1, TTL: time.Minute})
+	_, ok := <-DrainEvictions(context.Background(), cache, func(EvictedEntry[string, int]) {})
+	assert.False(ok)
+
+	evictionChannel := make(chan EvictedEntry[string, int])
+	cache = New(Config[string, int]{MaxSize: 1, TTL: time.Minute, EvictionChannel: &evictionChannel})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := DrainEvictions(ctx, cache, func(EvictedEntry[string, int]) {})
+	cancel()
+	_, ok = <-done
+	assert.False(ok)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -27,13 +28,12 @@ func ExampleLRA() {
 		GarbageCollectionInterval: ttl,
 	}
 	cache := tlru.New(config)
+	// Stops the garbage collection so that it doesn't outlive the example
+	defer cache.CloseAndExport()
 
-	go func() {
-		for {
-			evictedEntry := <-evictionChannel
-			fmt.Printf("Entry with key: '%s' has been evicted with reason: %s\n", evictedEntry.Key, evictedEntry.Reason.String())
-		}
-	}()
+	tlru.DrainEvictions(context.Background(), cache, func(evictedEntry tlru.EvictedEntry[string, int]) {
+		fmt.Printf("Entry with key: '%s' has been evicted with reason: %s\n", evictedEntry.Key, evictedEntry.Reason.String())
+	})
 
 	cache.Set(entry1.Key, entry1.Value)
 	time.Sleep(2 * ttl)
@@ -84,13 +84,12 @@ func ExampleLRI() {
 		GarbageCollectionInterval: ttl,
 	}
 	cache := tlru.New(config)
+	// Stops the garbage collection so that it doesn't outlive the example
+	defer cache.CloseAndExport()
 
-	go func() {
-		for {
-			evictedEntry := <-evictionChannel
-			fmt.Printf("Entry with key: '%s' has been evicted with reason: %s\n", evictedEntry.Key, evictedEntry.Reason.String())
-		}
-	}()
+	tlru.DrainEvictions(context.Background(), cache, func(evictedEntry tlru.EvictedEntry[string, int]) {
+		fmt.Printf("Entry with key: '%s' has been evicted with reason: %s\n", evictedEntry.Key, evictedEntry.Reason.String())
+	})
 
 	cache.Set(entry1.Key, entry1.Value)
 	time.Sleep(2 * ttl)
@@ -0,0 +1,99 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+// expiryHeap is a min-heap of the cached nodes ordered by their expiration so that
+// the garbage collection only visits the nodes which are due
+// It implements heap.Interface
+type expiryHeap[K comparable, V any] []*doublyLinkedNode[K, V]
+
+func (h expiryHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap[K, V]) Less(i, j int) bool {
+	return h[i].expiresAt.Before(h[j].expiresAt)
+}
+
+func (h expiryHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].expiryIndex = i
+	h[j].expiryIndex = j
+}
+
+func (h *expiryHeap[K, V]) Push(x any) {
+	linkedNode := x.(*doublyLinkedNode[K, V])
+	linkedNode.expiryIndex = len(*h)
+	*h = append(*h, linkedNode)
+}
+
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	last := len(old) - 1
+	linkedNode := old[last]
+	old[last] = nil
+	linkedNode.expiryIndex = -1
+	*h = old[:last]
+
+	return linkedNode
+}
+
+// addExpiry must be called while holding the lock of the cache
+func (c *TLRU[K, V]) addExpiry(linkedNode *doublyLinkedNode[K, V]) {
+	heap.Push(&c.expiries, linkedNode)
+}
+
+// removeExpiry must be called while holding the lock of the cache
+func (c *TLRU[K, V]) removeExpiry(linkedNode *doublyLinkedNode[K, V]) {
+	if c.isExpiryOf(linkedNode) {
+		heap.Remove(&c.expiries, linkedNode.expiryIndex)
+	}
+}
+
+// fixExpiry must be called while holding the lock of the cache whenever
+// the expiration of a cached node changes
+func (c *TLRU[K, V]) fixExpiry(linkedNode *doublyLinkedNode[K, V]) {
+	if c.isExpiryOf(linkedNode) {
+		heap.Fix(&c.expiries, linkedNode.expiryIndex)
+	}
+}
+
+// isExpiryOf reports whether the provided node is held by the expiries. Nodes which have
+// been discarded by Clear or SetState keep their stale index
+func (c *TLRU[K, V]) isExpiryOf(linkedNode *doublyLinkedNode[K, V]) bool {
+	i := linkedNode.expiryIndex
+	return i >= 0 && i < len(c.expiries) && c.expiries[i] == linkedNode
+}
+
+// evictExpiredEntries evicts the expired entries from the least to the most recently used one
+// It only visits the entries which are due and stops at the first one which isn't expired
+func (c *TLRU[K, V]) evictExpiredEntries() {
+	c.applyAccesses()
+	now := time.Now()
+	var due []*doublyLinkedNode[K, V]
+	for len(c.expiries) > 0 && c.expiries[0].isExpired(now) {
+		due = append(due, heap.Pop(&c.expiries).(*doublyLinkedNode[K, V]))
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].lastUsedAt.Equal(due[j].lastUsedAt) {
+			return due[i].lastUsedAt.Before(due[j].lastUsedAt)
+		}
+		return due[i].sequence < due[j].sequence
+	})
+
+	for _, linkedNode := range due {
+		c.evictEntry(linkedNode, EvictionReasonExpired)
+		// Borrowed entries and the entries of a closed cache aren't evicted
+		if c.cache[linkedNode.key] == linkedNode {
+			heap.Push(&c.expiries, linkedNode)
+		}
+	}
+}
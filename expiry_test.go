@@ -0,0 +1,69 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheExpiryHeap(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        50,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		for i := 0; i < 60; i++ {
+			cache.SetWithTTL(strconv.Itoa(i), i, time.Duration(60-i)*time.Second)
+		}
+		for i := 10; i < 60; i += 3 {
+			cache.Get(strconv.Itoa(i))
+		}
+		for i := 10; i < 60; i += 7 {
+			cache.Delete(strconv.Itoa(i))
+		}
+		assertExpiryHeap(assert, cache)
+
+		cache.SetTTL(time.Second)
+		assertExpiryHeap(assert, cache)
+		for _, linkedNode := range cache.expiries {
+			if linkedNode.customTTL {
+				linkedNode.expiresAt = time.Now().Add(-time.Millisecond)
+				cache.fixExpiry(linkedNode)
+				break
+			}
+		}
+		assertExpiryHeap(assert, cache)
+
+		length := cache.Len()
+		cache.Lock()
+		cache.evictExpiredEntries()
+		cache.unlock()
+		assert.Equal(length-1, cache.Len())
+		assertExpiryHeap(assert, cache)
+
+		cache.Clear()
+		assert.Equal(0, len(cache.expiries))
+	}
+}
+
+func assertExpiryHeap(assert *assert.Assertions, cache *TLRU[string, int]) {
+	cache.RLock()
+	defer cache.RUnlock()
+
+	assert.Equal(len(cache.cache), len(cache.expiries))
+	for i, linkedNode := range cache.expiries {
+		assert.Equal(i, linkedNode.expiryIndex)
+		assert.Equal(linkedNode, cache.cache[linkedNode.key])
+		if i > 0 {
+			assert.False(linkedNode.expiresAt.Before(cache.expiries[(i-1)/2].expiresAt))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import "time"
+
+// ReadOnlyCache is an immutable point-in-time view of a cache returned by the Freeze method
+// It holds a copy of the index of the cache which shares the values with it, so it serves
+// lookups without any locking and is safe for concurrent use. The zero value is an empty view
+type ReadOnlyCache[K comparable, V any] struct {
+	entries  map[K]CacheEntry[K, V]
+	frozenAt time.Time
+}
+
+// Freeze returns a ReadOnlyCache with the entries of the cache which aren't expired
+// Neither Freeze nor the lookups of the returned view mark entries as used and
+// entries that expire after Freeze remain in the view
+func (c *TLRU[K, V]) Freeze() ReadOnlyCache[K, V] {
+	defer c.RUnlock()
+	c.RLock()
+
+	now := time.Now()
+	readOnlyCache := ReadOnlyCache[K, V]{
+		entries:  make(map[K]CacheEntry[K, V], len(c.cache)),
+		frozenAt: now.UTC(),
+	}
+	for key, linkedNode := range c.cache {
+		if !linkedNode.isExpired(now) {
+			readOnlyCache.entries[key] = linkedNode.ToCacheEntry()
+		}
+	}
+
+	return readOnlyCache
+}
+
+// Get retrieves an entry from the view by key
+// If an entry for the specified key doesn't exist then it returns nil
+func (r ReadOnlyCache[K, V]) Get(key K) *CacheEntry[K, V] {
+	cacheEntry, exists := r.entries[key]
+	if !exists {
+		return nil
+	}
+
+	return &cacheEntry
+}
+
+// Has returns true if an entry for the specified key exists in the view
+func (r ReadOnlyCache[K, V]) Has(key K) bool {
+	_, exists := r.entries[key]
+	return exists
+}
+
+// Len returns the number of entries of the view
+func (r ReadOnlyCache[K, V]) Len() int {
+	return len(r.entries)
+}
+
+// Keys returns an unordered slice of all keys of the view
+func (r ReadOnlyCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(r.entries))
+	for key := range r.entries {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Entries returns an unordered slice of all entries of the view
+func (r ReadOnlyCache[K, V]) Entries() []CacheEntry[K, V] {
+	entries := make([]CacheEntry[K, V], 0, len(r.entries))
+	for _, cacheEntry := range r.entries {
+		entries = append(entries, cacheEntry)
+	}
+
+	return entries
+}
+
+// FrozenAt returns the time the view was created at
+func (r ReadOnlyCache[K, V]) FrozenAt() time.Time {
+	return r.frozenAt
+}
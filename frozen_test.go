@@ -0,0 +1,57 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheFreeze(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, time.Now().Add(-time.Hour))
+
+		frozen := cache.Freeze()
+		cache.Delete(entry1.Key)
+		cache.Set(entry4.Key, entry4.Value)
+
+		assert.Equal(2, frozen.Len())
+		assert.Equal(entry1.Value, frozen.Get(entry1.Key).Value)
+		assert.True(frozen.Has(entry2.Key))
+		assert.False(frozen.Has(entry3.Key))
+		assert.Nil(frozen.Get(entry4.Key))
+		assert.ElementsMatch([]string{entry1.Key, entry2.Key}, frozen.Keys())
+		assert.Equal(2, len(frozen.Entries()))
+		assert.False(frozen.FrozenAt().IsZero())
+		assert.Equal(cache.Peek(entry2.Key).Counter, frozen.Get(entry2.Key).Counter)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					frozen.Get(entry2.Key)
+				}
+			}()
+		}
+		wg.Wait()
+
+		var empty ReadOnlyCache[string, int]
+		assert.Nil(empty.Get(entry1.Key))
+		assert.Equal(0, empty.Len())
+	}
+}
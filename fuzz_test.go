@@ -0,0 +1,225 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fuzzMaxSize = 8
+
+// fuzzOperation decodes 3 bytes of fuzz input to an operation
+type fuzzOperation struct {
+	kind  byte
+	key   byte
+	value int
+}
+
+func decodeFuzzOperations(data []byte) []fuzzOperation {
+	operations := make([]fuzzOperation, 0, len(data)/3)
+	for i := 0; i+2 < len(data); i += 3 {
+		operations = append(operations, fuzzOperation{kind: data[i] % 4, key: data[i+1] % 16, value: int(data[i+2])})
+	}
+
+	return operations
+}
+
+func fuzzPolicy(data []byte) evictionPolicy {
+	if len(data)%2 == 0 {
+		return LRA
+	}
+
+	return LRI
+}
+
+// lruModel is a reference implementation of the eviction policies without expiration
+type lruModel struct {
+	policy  evictionPolicy
+	maxSize int
+	// keys are ordered from the most to the least recently used one
+	keys   []byte
+	values map[byte]int
+}
+
+func (m *lruModel) moveToFront(key byte) {
+	m.remove(key)
+	m.keys = append([]byte{key}, m.keys...)
+}
+
+func (m *lruModel) remove(key byte) {
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *lruModel) apply(operation fuzzOperation) {
+	_, exists := m.values[operation.key]
+	switch operation.kind {
+	case 0:
+		if exists && m.policy == LRA {
+			return
+		}
+		if !exists && len(m.keys) == m.maxSize {
+			delete(m.values, m.keys[len(m.keys)-1])
+			m.keys = m.keys[:len(m.keys)-1]
+		}
+		m.values[operation.key] = operation.value
+		m.moveToFront(operation.key)
+	case 1:
+		if exists && m.policy == LRA {
+			m.moveToFront(operation.key)
+		}
+	case 2:
+		delete(m.values, operation.key)
+		m.remove(operation.key)
+	case 3:
+		m.keys = nil
+		m.values = make(map[byte]int)
+	}
+}
+
+func applyFuzzOperation(cache *TLRU[byte, int], operation fuzzOperation) {
+	switch operation.kind {
+	case 0:
+		cache.Set(operation.key, operation.value)
+	case 1:
+		cache.Get(operation.key)
+	case 2:
+		cache.Delete(operation.key)
+	case 3:
+		cache.Clear()
+	}
+}
+
+// checkListInvariants verifies that the doubly linked list and the cache map hold the same nodes
+func checkListInvariants[K comparable, V any](t *testing.T, cache *TLRU[K, V]) {
+	t.Helper()
+	defer cache.RUnlock()
+	cache.RLock()
+
+	count := 0
+	previousNode := cache.sentinel
+	for node := cache.sentinel.next; node != cache.sentinel; node = node.next {
+		if node.previous != previousNode {
+			t.Fatalf("node %v is not linked to its previous node", node.key)
+		}
+		if cache.cache[node.key] != node {
+			t.Fatalf("node %v is not in the cache map", node.key)
+		}
+		previousNode = node
+		count++
+		if count > len(cache.cache) {
+			t.Fatalf("list has more nodes than the cache map")
+		}
+	}
+	if cache.sentinel.previous != previousNode {
+		t.Fatalf("sentinel is not linked to the last node")
+	}
+	if count != len(cache.cache) {
+		t.Fatalf("list has %d nodes while the cache map has %d", count, len(cache.cache))
+	}
+	if cache.config.MaxSize != 0 && count > cache.config.MaxSize {
+		t.Fatalf("cache has %d entries which exceed MaxSize %d", count, cache.config.MaxSize)
+	}
+}
+
+func FuzzOperations(f *testing.F) {
+	f.Add([]byte{0, 1, 1, 0, 2, 2, 1, 1, 0, 2, 2, 0})
+	f.Add([]byte{0, 1, 1, 0, 2, 2, 0, 3, 3, 0, 4, 4, 0, 5, 5, 0, 6, 6, 0, 7, 7, 0, 8, 8, 0, 9, 9, 1, 2, 0, 3})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		policy := fuzzPolicy(data)
+		operations := decodeFuzzOperations(data)
+		cache := New(Config[byte, int]{MaxSize: fuzzMaxSize, TTL: time.Hour, EvictionPolicy: policy})
+		model := &lruModel{policy: policy, maxSize: fuzzMaxSize, values: make(map[byte]int)}
+
+		for _, operation := range operations {
+			applyFuzzOperation(cache, operation)
+			model.apply(operation)
+			checkListInvariants(t, cache)
+		}
+
+		state := cache.GetState()
+		if len(state.Entries) != len(model.keys) {
+			t.Fatalf("cache has %d entries while the model has %d", len(state.Entries), len(model.keys))
+		}
+		for i, entry := range state.Entries {
+			if entry.Key != model.keys[i] || entry.Value != model.values[entry.Key] {
+				t.Fatalf("entry %d is %v=%v while the model has %v=%v", i, entry.Key, entry.Value, model.keys[i], model.values[model.keys[i]])
+			}
+		}
+
+		concurrentCache := New(Config[byte, int]{MaxSize: fuzzMaxSize, TTL: time.Hour, EvictionPolicy: policy})
+		var wg sync.WaitGroup
+		for worker := 0; worker < 4; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				for i := worker; i < len(operations); i += 4 {
+					applyFuzzOperation(concurrentCache, operations[i])
+				}
+			}(worker)
+		}
+		wg.Wait()
+		checkListInvariants(t, concurrentCache)
+	})
+}
+
+func FuzzStateRoundTrip(f *testing.F) {
+	f.Add([]byte{0, 1, 1, 0, 2, 2, 1, 1, 0})
+	f.Add([]byte{0, 1, 1, 0, 2, 2, 0, 3, 3, 2, 2, 0, 0, 4, 4})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		policy := fuzzPolicy(data)
+		config := Config[byte, int]{MaxSize: fuzzMaxSize, TTL: time.Hour, EvictionPolicy: policy}
+		cache := New(config)
+		for _, operation := range decodeFuzzOperations(data) {
+			applyFuzzOperation(cache, operation)
+		}
+		state := cache.GetState()
+
+		encodedState, err := json.Marshal(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decodedState State[byte, int]
+		if err := json.Unmarshal(encodedState, &decodedState); err != nil {
+			t.Fatal(err)
+		}
+		restoredCache := New(config)
+		if err := restoredCache.SetState(decodedState); err != nil {
+			t.Fatal(err)
+		}
+		checkListInvariants(t, restoredCache)
+		checkSameState(t, state, restoredCache.GetState())
+
+		encodedCache, err := cache.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		unmarshaledCache := &TLRU[byte, int]{}
+		if err := unmarshaledCache.UnmarshalBinary(encodedCache); err != nil {
+			t.Fatal(err)
+		}
+		checkListInvariants(t, unmarshaledCache)
+		checkSameState(t, state, unmarshaledCache.GetState())
+	})
+}
+
+// checkSameState verifies that both States hold equal entries in the same order
+func checkSameState[K comparable, V any](t *testing.T, expected State[K, V], actual State[K, V]) {
+	t.Helper()
+	if diff := DiffStates(expected, actual); !diff.IsEmpty() {
+		t.Fatalf("round trip changed the state: %+v", diff)
+	}
+	for i := range expected.Entries {
+		if expected.Entries[i].Key != actual.Entries[i].Key {
+			t.Fatalf("round trip changed the order of the entries")
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package batch implements the batching, flushing and retrying shared by the eviction sinks
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultMaxRetries    = 3
+	defaultRetryBackoff  = 100 * time.Millisecond
+)
+
+// ErrClosed is returned by the Add method after a call to Close
+var ErrClosed = errors.New("batch: Batcher closed")
+
+// Config of Batcher
+type Config[T any] struct {
+	// Number of items after which a batch is written. If not set it defaults to 100
+	BatchSize int
+	// Max time an item waits in a batch before the batch is written.
+	// If not set it defaults to 1 second
+	FlushInterval time.Duration
+	// Number of retries of a failed write. If not set it defaults to 3.
+	// A negative value disables retries
+	MaxRetries int
+	// Backoff before the first retry which doubles on every subsequent retry.
+	// If not set it defaults to 100 milliseconds
+	RetryBackoff time.Duration
+	// Writes a batch. The slice is not reused after Write returns
+	Write func(ctx context.Context, batch []T) error
+	// Optional callback which is invoked with the error of the last attempt and the
+	// batch that couldn't be written after all retries
+	OnError func(err error, batch []T)
+}
+
+// Batcher collects items and writes them in batches once a batch is full or
+// Config.FlushInterval has elapsed since its first item
+type Batcher[T any] struct {
+	config     Config[T]
+	mutex      sync.Mutex
+	pending    []T
+	flushTimer *time.Timer
+	// generation invalidates the flush timer of a batch which has already been written
+	generation uint64
+	closed     bool
+	// writeMutex serializes the writes so that batches are written in the order of their items
+	writeMutex sync.Mutex
+}
+
+// New returns a new Batcher
+func New[T any](config Config[T]) *Batcher[T] {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaultRetryBackoff
+	}
+
+	return &Batcher[T]{config: config}
+}
+
+// Add appends the provided item to the pending batch. If the batch is full it is written
+// before Add returns and its retries stop once the provided context is done
+func (b *Batcher[T]) Add(ctx context.Context, item T) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return ErrClosed
+	}
+	b.pending = append(b.pending, item)
+	if len(b.pending) == 1 {
+		generation := b.generation
+		b.flushTimer = time.AfterFunc(b.config.FlushInterval, func() {
+			b.flushExpired(generation)
+		})
+	}
+	full := len(b.pending) >= b.config.BatchSize
+	b.mutex.Unlock()
+
+	if full {
+		return b.Flush(ctx)
+	}
+
+	return nil
+}
+
+// Flush writes all pending items in batches of at most Config.BatchSize items
+// It returns the error of the last batch which couldn't be written
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	defer b.writeMutex.Unlock()
+	b.writeMutex.Lock()
+
+	var err error
+	pending := b.takePending()
+	for start := 0; start < len(pending); start += b.config.BatchSize {
+		end := start + b.config.BatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		if batchErr := b.write(ctx, pending[start:end:end]); batchErr != nil {
+			err = batchErr
+		}
+	}
+
+	return err
+}
+
+// Close writes all pending items and rejects subsequent ones
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.mutex.Lock()
+	b.closed = true
+	b.mutex.Unlock()
+
+	return b.Flush(ctx)
+}
+
+// takePending removes the pending items and stops their flush timer
+func (b *Batcher[T]) takePending() []T {
+	defer b.mutex.Unlock()
+	b.mutex.Lock()
+
+	pending := b.pending
+	b.pending = nil
+	b.generation++
+	if b.flushTimer != nil {
+		b.flushTimer.Stop()
+		b.flushTimer = nil
+	}
+
+	return pending
+}
+
+// flushExpired writes the pending items once Config.FlushInterval has elapsed since the first
+// of them unless they have already been written
+func (b *Batcher[T]) flushExpired(generation uint64) {
+	b.mutex.Lock()
+	expired := b.generation == generation
+	b.mutex.Unlock()
+
+	if expired {
+		b.Flush(context.Background())
+	}
+}
+
+// write writes the provided batch and retries with an exponential backoff until
+// Config.MaxRetries is reached or the provided context is done
+func (b *Batcher[T]) write(ctx context.Context, batch []T) error {
+	backoff := b.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := b.config.Write(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		if attempt >= b.config.MaxRetries || ctx.Err() != nil {
+			if b.config.OnError != nil {
+				b.config.OnError(err, batch)
+			}
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		backoff *= 2
+	}
+}
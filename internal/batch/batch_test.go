@@ -0,0 +1,100 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	sync.Mutex
+	failures int
+	calls    int
+	batches  [][]int
+}
+
+func (r *recorder) write(ctx context.Context, batch []int) error {
+	defer r.Unlock()
+	r.Lock()
+	r.calls++
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("unavailable")
+	}
+	r.batches = append(r.batches, batch)
+
+	return nil
+}
+
+func (r *recorder) written() [][]int {
+	defer r.Unlock()
+	r.Lock()
+
+	return append([][]int(nil), r.batches...)
+}
+
+func TestBatcher(t *testing.T) {
+	assert := assert.New(t)
+	recorder := &recorder{failures: 1}
+	flushInterval := 20 * time.Millisecond
+	batcher := New(Config[int]{
+		BatchSize:     2,
+		FlushInterval: flushInterval,
+		RetryBackoff:  time.Millisecond,
+		Write:         recorder.write,
+	})
+
+	assert.NoError(batcher.Add(context.Background(), 1))
+	assert.NoError(batcher.Add(context.Background(), 2))
+	assert.Equal([][]int{{1, 2}}, recorder.written())
+	assert.Equal(2, recorder.calls)
+
+	// The flush timer of a written batch doesn't flush the next one early
+	assert.NoError(batcher.Add(context.Background(), 3))
+	assert.NoError(batcher.Add(context.Background(), 4))
+	assert.NoError(batcher.Add(context.Background(), 5))
+	time.Sleep(flushInterval / 2)
+	assert.Equal([][]int{{1, 2}, {3, 4}}, recorder.written())
+	assert.Eventually(func() bool { return len(recorder.written()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal([]int{5}, recorder.written()[2])
+
+	assert.NoError(batcher.Add(context.Background(), 6))
+	assert.NoError(batcher.Close(context.Background()))
+	assert.Equal([]int{6}, recorder.written()[3])
+	assert.True(errors.Is(batcher.Add(context.Background(), 7), ErrClosed))
+}
+
+func TestBatcherOnError(t *testing.T) {
+	assert := assert.New(t)
+	recorder := &recorder{failures: 10}
+	var failed []int
+	var failure error
+	batcher := New(Config[int]{
+		BatchSize:    1,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		Write:        recorder.write,
+		OnError: func(err error, batch []int) {
+			failure, failed = err, batch
+		},
+	})
+
+	assert.Error(batcher.Add(context.Background(), 1))
+	assert.Equal(3, recorder.calls)
+	assert.Equal([]int{1}, failed)
+	assert.Error(failure)
+
+	// Retries stop once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(batcher.Add(ctx, 2))
+	assert.Equal(4, recorder.calls)
+	assert.Equal([]int{2}, failed)
+}
@@ -0,0 +1,138 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tcpserver implements the connection bookkeeping shared by the protocol adapters
+package tcpserver
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// ErrServerClosed is returned by the Serve method after a call to Close
+var ErrServerClosed = errors.New("tcpserver: Server closed")
+
+// ErrLineTooLong is returned by ReadLine when a line exceeds the max length
+var ErrLineTooLong = errors.New("tcpserver: line too long")
+
+// Server accepts connections and hands each one to its handler in a separate goroutine
+type Server struct {
+	handler     func(net.Conn)
+	onPanic     func(value interface{}, stack []byte)
+	mu          sync.Mutex
+	listener    net.Listener
+	connections map[net.Conn]struct{}
+	closed      bool
+	wg          sync.WaitGroup
+}
+
+// New returns a new Server which serves connections via the provided handler
+// The handler doesn't need to close the connection. The optional onPanic callback
+// is invoked with the value and the stack trace of a panic of the handler
+func New(handler func(net.Conn), onPanic func(value interface{}, stack []byte)) *Server {
+	return &Server{
+		handler:     handler,
+		onPanic:     onPanic,
+		connections: make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts incoming connections on the provided listener
+// It blocks until the listener fails or the server is closed
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.connections[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.handleConnection(conn)
+	}
+}
+
+// Close stops the listener, closes all active connections and waits for their handlers to return
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.connections {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	return err
+}
+
+// handleConnection serves a connection via the handler. A panic of the handler is
+// reported to onPanic and only closes its connection so that it can't take the server down
+func (s *Server) handleConnection(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		if recovered := recover(); recovered != nil && s.onPanic != nil {
+			s.onPanic(recovered, debug.Stack())
+		}
+		s.mu.Lock()
+		delete(s.connections, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	s.handler(conn)
+}
+
+// ReadLine reads a line and trims its line ending. It fails with ErrLineTooLong as soon as
+// the line exceeds maxLength bytes so that a client can't make the server buffer it unbounded
+func ReadLine(r *bufio.Reader, maxLength int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxLength {
+			return "", ErrLineTooLong
+		}
+		line = append(line, chunk...)
+		switch {
+		case err == nil:
+			return strings.TrimRight(string(line), "\r\n"), nil
+		case err == bufio.ErrBufferFull:
+		case err == io.EOF && len(line) > 0:
+			return "", io.ErrUnexpectedEOF
+		default:
+			return "", err
+		}
+	}
+}
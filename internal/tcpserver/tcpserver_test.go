@@ -0,0 +1,54 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tcpserver
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerHandlerPanic(t *testing.T) {
+	assert := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+
+	recovered := make(chan interface{}, 1)
+	server := New(func(conn net.Conn) {
+		panic("boom")
+	}, func(value interface{}, stack []byte) {
+		assert.NotEmpty(stack)
+		recovered <- value
+	})
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+	assert.Equal("boom", <-recovered)
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(io.EOF, err)
+}
+
+func TestReadLine(t *testing.T) {
+	assert := assert.New(t)
+	reader := bufio.NewReaderSize(strings.NewReader("GET key\r\n"+strings.Repeat("a", 100)+"\r\npartial"), 16)
+
+	line, err := ReadLine(reader, 64)
+	assert.NoError(err)
+	assert.Equal("GET key", line)
+	_, err = ReadLine(reader, 64)
+	assert.Equal(ErrLineTooLong, err)
+
+	reader = bufio.NewReaderSize(strings.NewReader("partial"), 16)
+	_, err = ReadLine(reader, 64)
+	assert.Equal(io.ErrUnexpectedEOF, err)
+	_, err = ReadLine(reader, 64)
+	assert.Equal(io.EOF, err)
+}
@@ -0,0 +1,90 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"sort"
+	"time"
+)
+
+// Cursor is the position of an iteration started by IterateFrom
+// The zero value starts an iteration from the beginning. A Cursor is only
+// meaningful for the cache which returned it
+type Cursor struct {
+	// The sequence of the last visited entry. Entries are visited in the order of
+	// their creation and every created entry gets a greater sequence
+	Sequence uint64 `json:"sequence"`
+}
+
+type cursorPosition[K comparable] struct {
+	key      K
+	sequence uint64
+}
+
+// IterateFrom invokes fn with the entries of the cache which follow the provided Cursor in
+// the order of their creation and returns the Cursor after the last visited entry along with
+// true once all entries have been visited. If fn returns false the iteration stops and
+// IterateFrom returns false. The returned Cursor can be persisted and passed to IterateFrom
+// later in order to resume the iteration, e.g. after a restart of a long migration
+// The lock of the cache is not held while fn is executed so fn can modify the cache and
+// concurrent mutations are tolerated. Every key which exists for the entire iteration is
+// visited at least once, entries which are inserted during the iteration are visited as well
+// and entries which are removed before they are reached are skipped. Expired entries are skipped
+// and neither IterateFrom nor fn mark entries as used
+func (c *TLRU[K, V]) IterateFrom(cursor Cursor, fn func(key K, value V) bool) (Cursor, bool) {
+	for {
+		positions := c.positionsAfter(cursor)
+		if len(positions) == 0 {
+			return cursor, true
+		}
+		for _, position := range positions {
+			value, exists := c.valueAt(position)
+			cursor.Sequence = position.sequence
+			if exists && !fn(position.key, value) {
+				return cursor, false
+			}
+		}
+	}
+}
+
+// positionsAfter returns the positions of the entries that follow the provided Cursor
+// ordered by their sequence
+func (c *TLRU[K, V]) positionsAfter(cursor Cursor) []cursorPosition[K] {
+	c.RLock()
+	positions := make([]cursorPosition[K], 0)
+	for key, linkedNode := range c.cache {
+		if linkedNode.sequence > cursor.Sequence {
+			positions = append(positions, cursorPosition[K]{key: key, sequence: linkedNode.sequence})
+		}
+	}
+	c.RUnlock()
+
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].sequence < positions[j].sequence
+	})
+
+	return positions
+}
+
+// valueAt returns the value of the entry at the provided position unless it has been
+// removed, replaced by a newer entry or is expired
+func (c *TLRU[K, V]) valueAt(position cursorPosition[K]) (V, bool) {
+	defer c.RUnlock()
+	c.RLock()
+
+	var value V
+	linkedNode, exists := c.cache[position.key]
+	if !exists || linkedNode.sequence != position.sequence || linkedNode.isExpired(time.Now()) {
+		return value, false
+	}
+
+	return linkedNode.readValue(), true
+}
+
+// nextSequence must be called while holding the lock of the cache
+func (c *TLRU[K, V]) nextSequence() uint64 {
+	c.sequence++
+	return c.sequence
+}
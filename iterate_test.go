@@ -0,0 +1,60 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheIterateFrom(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        100,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		for i := 0; i < 10; i++ {
+			cache.Set(fmt.Sprint(i), i)
+		}
+
+		visited := map[string]int{}
+		cursor, completed := cache.IterateFrom(Cursor{}, func(key string, value int) bool {
+			visited[key]++
+			return len(visited) < 4
+		})
+		assert.False(completed)
+		assert.Equal(4, len(visited))
+
+		cache.Delete("9")
+		cache.Get("0")
+		cache.Set("10", 10)
+		assert.NoError(cache.SetState(cache.GetState()))
+		cursor, completed = cache.IterateFrom(cursor, func(key string, value int) bool {
+			visited[key]++
+			if key == "5" {
+				cache.Set("11", 11)
+			}
+			return true
+		})
+		assert.True(completed)
+		for i := 0; i < 9; i++ {
+			assert.True(visited[fmt.Sprint(i)] >= 1)
+		}
+		assert.Equal(0, visited["9"])
+		assert.Equal(1, visited["10"])
+		assert.Equal(1, visited["11"])
+
+		_, completed = cache.IterateFrom(cursor, func(key string, value int) bool {
+			assert.Fail("all entries have been visited")
+			return true
+		})
+		assert.True(completed)
+	}
+}
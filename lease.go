@@ -0,0 +1,102 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultLeaseTimeout = 10 * time.Second
+
+// Lease grants its holder the exclusive right to populate a missing key via SetWithLease
+type Lease struct {
+	// The token of the lease. It is 0 if no lease has been granted
+	Token uint64 `json:"token"`
+	// The time the lease expires at
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GetWithLease retrieves an entry from the cache by key like Get. On a miss the first caller
+// is granted a Lease and is expected to populate the key via SetWithLease or give the Lease up
+// via ReleaseLease, while the rest of the callers get an error that wraps ErrLeaseHeld until the
+// Lease is used, released or expires after Config.LeaseTimeout. These callers also get the
+// stale entry if the missing entry has expired but hasn't been evicted yet, so they can either
+// serve stale data or retry later instead of stampeding the backend
+// Unlike GetOrCompute it never blocks
+func (c *TLRU[K, V]) GetWithLease(key K) (*CacheEntry[K, V], Lease, error) {
+	// Expired entries are looked up without Get so that they aren't evicted
+	c.RLock()
+	linkedNode, exists := c.cache[key]
+	fresh := exists && !linkedNode.isExpired(time.Now())
+	c.RUnlock()
+	if fresh {
+		if cacheEntry := c.Get(key); cacheEntry != nil {
+			return cacheEntry, Lease{}, nil
+		}
+	}
+
+	defer c.unlock()
+	c.Lock()
+
+	now := time.Now()
+	linkedNode, exists = c.cache[key]
+	if exists && !linkedNode.isExpired(now) {
+		cacheEntry := linkedNode.ToCacheEntry()
+		return &cacheEntry, Lease{}, nil
+	}
+	if c.closed {
+		return nil, Lease{}, fmt.Errorf("tlru.GetWithLease: %w", ErrClosed)
+	}
+
+	if lease, held := c.leases[key]; held && now.Before(lease.ExpiresAt) {
+		err := fmt.Errorf("tlru.GetWithLease: Lease of key '%+v' expires at %s. %w", key, lease.ExpiresAt, ErrLeaseHeld)
+		if exists {
+			cacheEntry := linkedNode.ToCacheEntry()
+			return &cacheEntry, Lease{}, err
+		}
+		return nil, Lease{}, err
+	}
+
+	c.leaseToken++
+	lease := Lease{Token: c.leaseToken, ExpiresAt: now.Add(c.config.LeaseTimeout)}
+	if c.leases == nil {
+		c.leases = make(map[K]Lease)
+	}
+	c.leases[key] = lease
+
+	return nil, lease, nil
+}
+
+// SetWithLease inserts the entry of a key whose Lease has been granted by GetWithLease and
+// releases the Lease. An expired entry of the key is replaced in both EvictionPolicies
+// If the Lease has expired or has been invalidated by a write or delete of the key in the
+// meantime an error that wraps ErrLeaseInvalid is returned and the value is discarded
+func (c *TLRU[K, V]) SetWithLease(key K, value V, lease Lease) error {
+	defer c.unlock()
+	c.Lock()
+	if c.closed {
+		return fmt.Errorf("tlru.SetWithLease: %w", ErrClosed)
+	}
+
+	if current, held := c.leases[key]; !held || current.Token != lease.Token || !time.Now().Before(current.ExpiresAt) {
+		return fmt.Errorf("tlru.SetWithLease: Lease %d of key '%+v' is not held. %w", lease.Token, key, ErrLeaseInvalid)
+	}
+	c.cacheLoaded(key, value)
+
+	return nil
+}
+
+// ReleaseLease gives up a Lease granted by GetWithLease so that the next caller of
+// GetWithLease is granted a new one, e.g. when the value couldn't be loaded
+// It has no effect if the Lease is no longer held
+func (c *TLRU[K, V]) ReleaseLease(key K, lease Lease) {
+	defer c.unlock()
+	c.Lock()
+
+	if current, held := c.leases[key]; held && current.Token == lease.Token {
+		delete(c.leases, key)
+	}
+}
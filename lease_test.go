@@ -0,0 +1,64 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheGetWithLease(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			LeaseTimeout:   20 * time.Millisecond,
+		}
+		cache := New(config)
+
+		cacheEntry, lease, err := cache.GetWithLease(entry1.Key)
+		assert.NoError(err)
+		assert.Nil(cacheEntry)
+		assert.NotEqual(uint64(0), lease.Token)
+
+		_, otherLease, err := cache.GetWithLease(entry1.Key)
+		assert.True(errors.Is(err, ErrLeaseHeld))
+		assert.Equal(uint64(0), otherLease.Token)
+		assert.True(errors.Is(cache.SetWithLease(entry1.Key, entry1.Value, otherLease), ErrLeaseInvalid))
+
+		assert.NoError(cache.SetWithLease(entry1.Key, entry1.Value, lease))
+		cacheEntry, _, err = cache.GetWithLease(entry1.Key)
+		assert.NoError(err)
+		assert.Equal(entry1.Value, cacheEntry.Value)
+		assert.True(errors.Is(cache.SetWithLease(entry1.Key, entry1.Value, lease), ErrLeaseInvalid))
+
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, time.Now().Add(-time.Hour))
+		_, lease, err = cache.GetWithLease(entry2.Key)
+		assert.NoError(err)
+		cacheEntry, _, err = cache.GetWithLease(entry2.Key)
+		assert.True(errors.Is(err, ErrLeaseHeld))
+		assert.Equal(entry2.Value, cacheEntry.Value)
+		assert.NoError(cache.SetWithLease(entry2.Key, 20, lease))
+		assert.Equal(20, cache.Get(entry2.Key).Value)
+
+		_, lease, _ = cache.GetWithLease(entry3.Key)
+		cache.Delete(entry3.Key)
+		assert.True(errors.Is(cache.SetWithLease(entry3.Key, entry3.Value, lease), ErrLeaseInvalid))
+
+		_, lease, _ = cache.GetWithLease(entry4.Key)
+		cache.ReleaseLease(entry4.Key, lease)
+		_, lease, err = cache.GetWithLease(entry4.Key)
+		assert.NoError(err)
+		time.Sleep(config.LeaseTimeout)
+		assert.True(errors.Is(cache.SetWithLease(entry4.Key, entry4.Value, lease), ErrLeaseInvalid))
+		_, lease, err = cache.GetWithLease(entry4.Key)
+		assert.NoError(err)
+		assert.NotEqual(uint64(0), lease.Token)
+	}
+}
@@ -0,0 +1,138 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// loaderCall is an invocation of Config.Loader which is shared by the callers
+// of GetWithContext that missed the same key
+type loaderCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// waiters is the number of callers which wait for the result
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// GetWithContext returns the value of the entry that corresponds to the provided key
+// On a miss Config.Loader is invoked and its result is cached. Concurrent misses of the same
+// key wait for the same invocation of the loader. If the provided context is done before the
+// loader returns GetWithContext returns an error that wraps the error of the context and the
+// loader is canceled once all the callers waiting for it have returned
+// If Config.Loader is not set a miss returns an error that wraps ErrNotFound
+func (c *TLRU[K, V]) GetWithContext(ctx context.Context, key K) (V, error) {
+	var value V
+	if err := c.checkMisuse("GetWithContext", false); err != nil {
+		return value, err
+	}
+	if cacheEntry := c.Get(key); cacheEntry != nil {
+		return cacheEntry.Value, nil
+	}
+	if c.config.Loader == nil {
+		return value, fmt.Errorf("tlru.GetWithContext: Key '%+v' doesn't exist. %w", key, ErrNotFound)
+	}
+	if err := c.negativeError(key); err != nil {
+		return value, err
+	}
+
+	call := c.joinLoaderCall(key)
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		c.leaveLoaderCall(key, call)
+		return value, fmt.Errorf("tlru.GetWithContext: Load of key '%+v' has been canceled: %w", key, ctx.Err())
+	}
+}
+
+// joinLoaderCall returns the running invocation of Config.Loader for the provided key
+// or starts a new one
+func (c *TLRU[K, V]) joinLoaderCall(key K) *loaderCall[V] {
+	defer c.unlock()
+	c.Lock()
+
+	call, exists := c.loaderCalls[key]
+	if !exists {
+		loadCtx, cancel := context.WithCancel(context.Background())
+		if c.config.LoadTimeout > 0 {
+			loadCtx, cancel = context.WithTimeout(context.Background(), c.config.LoadTimeout)
+		}
+		call = &loaderCall[V]{done: make(chan struct{}), cancel: cancel}
+		if c.loaderCalls == nil {
+			c.loaderCalls = make(map[K]*loaderCall[V])
+		}
+		c.loaderCalls[key] = call
+		atomic.AddInt32(&c.runningLoaders, 1)
+		go c.runLoaderCall(loadCtx, key, call, c.epoch, c.loadSlots)
+	}
+	call.waiters++
+
+	return call
+}
+
+// leaveLoaderCall cancels the invocation of Config.Loader once no caller waits for it anymore
+func (c *TLRU[K, V]) leaveLoaderCall(key K, call *loaderCall[V]) {
+	defer c.unlock()
+	c.Lock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	call.cancel()
+	if c.loaderCalls[key] == call {
+		delete(c.loaderCalls, key)
+	}
+}
+
+// runLoaderCall invokes Config.Loader and caches its result unless the load has been
+// canceled or the entries of the cache have been replaced since the load started
+func (c *TLRU[K, V]) runLoaderCall(loadCtx context.Context, key K, call *loaderCall[V], epoch uint64, loadSlots chan struct{}) {
+	defer atomic.AddInt32(&c.runningLoaders, -1)
+	defer close(call.done)
+	defer call.cancel()
+
+	if call.err = c.acquireLoadSlot(loadCtx, loadSlots); call.err != nil {
+		c.finishLoaderCall(key, call)
+		return
+	}
+	if loadSlots != nil {
+		defer func() { <-loadSlots }()
+	}
+
+	call.value, call.err = c.callLoader(loadCtx, key, c.config.Loader)
+	if call.err == nil && loadCtx.Err() != nil {
+		call.err = loadCtx.Err()
+	}
+	if call.err != nil {
+		call.err = fmt.Errorf("tlru.GetWithContext: %w", call.err)
+		switch loadCtx.Err() {
+		case nil:
+			c.rememberFailure(key, call.err, false)
+		case context.DeadlineExceeded:
+			c.rememberFailure(key, call.err, true)
+		}
+		c.finishLoaderCall(key, call)
+		return
+	}
+
+	c.finishLoaderCall(key, call)
+	c.storeLoaded(key, call.value, epoch)
+}
+
+// finishLoaderCall removes the provided invocation of Config.Loader from the running ones
+func (c *TLRU[K, V]) finishLoaderCall(key K, call *loaderCall[V]) {
+	defer c.unlock()
+	c.Lock()
+
+	if c.loaderCalls[key] == call {
+		delete(c.loaderCalls, key)
+	}
+}
@@ -0,0 +1,48 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"time"
+)
+
+// KeysMatching returns the keys of all non-expired entries of a cache with string keys
+// that match the provided glob pattern, similar to the Redis KEYS command
+// The pattern syntax is the one of path.Match and the order of keys is not guaranteed
+func KeysMatching[V any](c *TLRU[string, V], pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("tlru.KeysMatching: Invalid pattern '%s': %w", pattern, err)
+	}
+
+	return c.keysMatching(func(key string) bool {
+		matched, _ := path.Match(pattern, key)
+		return matched
+	}), nil
+}
+
+// KeysMatchingRegexp returns the keys of all non-expired entries of a cache with string keys
+// that match the provided regular expression
+// The order of keys is not guaranteed
+func KeysMatchingRegexp[V any](c *TLRU[string, V], re *regexp.Regexp) []string {
+	return c.keysMatching(re.MatchString)
+}
+
+func (c *TLRU[K, V]) keysMatching(match func(K) bool) []K {
+	defer c.RUnlock()
+	c.RLock()
+
+	keys := make([]K, 0)
+	now := time.Now()
+	for key, linkedNode := range c.cache {
+		if !linkedNode.isExpired(now) && match(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
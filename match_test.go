@@ -0,0 +1,44 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysMatching(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		cache.Set("user:1:name", 1)
+		cache.Set("user:2:name", 2)
+		cache.Set("user:2:email", 3)
+		cache.Set("session:1", 4)
+		cache.SetWithTimestamp("user:3:name", 5, time.Now().Add(-time.Hour))
+
+		keys, err := KeysMatching(cache, "user:*:name")
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"user:1:name", "user:2:name"}, keys)
+
+		keys, err = KeysMatching(cache, "session:?")
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"session:1"}, keys)
+
+		_, err = KeysMatching(cache, "user:[")
+		assert.Error(err)
+
+		keys = KeysMatchingRegexp(cache, regexp.MustCompile(`^user:\d+:(name|email)$`))
+		assert.ElementsMatch([]string{"user:1:name", "user:2:name", "user:2:email"}, keys)
+	}
+}
@@ -0,0 +1,142 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	// MisuseDetectionOff doesn't check for misuse of the cache
+	MisuseDetectionOff misuseDetection = iota
+	// MisuseDetectionError skips a misused call and returns a MisuseError or,
+	// for methods which don't return an error, reports it to Config.OnMisuse
+	MisuseDetectionError
+	// MisuseDetectionPanic panics with a MisuseError on misuse
+	MisuseDetectionPanic
+)
+
+const (
+	// MisuseUseAfterClose occurs when a cache is read after CloseAndExport has been called
+	MisuseUseAfterClose misuseKind = iota
+	// MisuseWriteOnReadOnly occurs when a cache is written after CloseAndExport
+	// has been called, which makes it read-only
+	MisuseWriteOnReadOnly
+	// MisuseReentrantCall occurs when a callback which is invoked while holding the lock
+	// of the cache, i.e. the fn of View and Update or Config.Tenant, calls into the cache
+	MisuseReentrantCall
+)
+
+type misuseDetection int
+
+func (d misuseDetection) String() string {
+	return [...]string{0: "Off", 1: "Error", 2: "Panic"}[d]
+}
+
+type misuseKind int
+
+func (k misuseKind) String() string {
+	return [...]string{0: "UseAfterClose", 1: "WriteOnReadOnly", 2: "ReentrantCall"}[k]
+}
+
+// MisuseError is returned or raised by the calls which Config.MisuseDetection flags
+type MisuseError struct {
+	Kind misuseKind
+	// The name of the misused method, e.g. "Set"
+	Method string
+}
+
+func (e *MisuseError) Error() string {
+	reason := [...]string{
+		0: "the cache has been closed via CloseAndExport",
+		1: "the cache is read-only since CloseAndExport has been called",
+		2: "called from a callback which holds the lock of the cache and would deadlock",
+	}[e.Kind]
+
+	return fmt.Sprintf("tlru.%s: %s misuse: %s", e.Method, e.Kind, reason)
+}
+
+// Unwrap returns ErrClosed for the misuses of a closed cache
+func (e *MisuseError) Unwrap() error {
+	if e.Kind == MisuseReentrantCall {
+		return nil
+	}
+
+	return ErrClosed
+}
+
+// checkMisuse returns a MisuseError if the call of the provided method is a misuse
+// or panics with it according to Config.MisuseDetection
+func (c *TLRU[K, V]) checkMisuse(method string, write bool) error {
+	if c.config.MisuseDetection == MisuseDetectionOff {
+		return nil
+	}
+
+	var err *MisuseError
+	_, reentrant := c.callbackGoroutines.Load(goroutineID())
+	switch {
+	case reentrant:
+		err = &MisuseError{Kind: MisuseReentrantCall, Method: method}
+	case atomic.LoadInt32(&c.closedFlag) == 1 && write:
+		err = &MisuseError{Kind: MisuseWriteOnReadOnly, Method: method}
+	case atomic.LoadInt32(&c.closedFlag) == 1:
+		err = &MisuseError{Kind: MisuseUseAfterClose, Method: method}
+	default:
+		return nil
+	}
+
+	if c.config.MisuseDetection == MisuseDetectionPanic {
+		panic(err)
+	}
+	return err
+}
+
+// misused is identical to checkMisuse but it reports the MisuseError to Config.OnMisuse
+// for methods which don't return an error
+func (c *TLRU[K, V]) misused(method string, write bool) bool {
+	err := c.checkMisuse(method, write)
+	if err == nil {
+		return false
+	}
+	if c.config.OnMisuse != nil {
+		c.protect("OnMisuse", func() {
+			c.config.OnMisuse(err.(*MisuseError))
+		})
+	}
+
+	return true
+}
+
+// underLock invokes a callback which is invoked while holding the lock of the cache
+// and marks its goroutine so that calls of the callback into the cache are detected
+func (c *TLRU[K, V]) underLock(fn func()) {
+	if c.config.MisuseDetection == MisuseDetectionOff {
+		fn()
+		return
+	}
+
+	id := goroutineID()
+	c.callbackGoroutines.Store(id, struct{}{})
+	defer c.callbackGoroutines.Delete(id)
+	fn()
+}
+
+// goroutineID returns the id of the calling goroutine which is parsed
+// from the header of its stack trace, e.g. "goroutine 18 [running]:"
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+
+	return id
+}
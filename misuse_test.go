@@ -0,0 +1,103 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheMisuseDetectionError(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var misuses []string
+		config := Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			MisuseDetection: MisuseDetectionError,
+			OnMisuse: func(err *MisuseError) {
+				misuses = append(misuses, fmt.Sprintf("%s:%s", err.Method, err.Kind))
+			},
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+
+		var reentrant *CacheEntry[string, int]
+		assert.True(cache.Update(entry1.Key, func(value *int) {
+			reentrant = cache.Get(entry1.Key)
+			*value = entry2.Value
+		}))
+		assert.Nil(reentrant)
+		assert.Equal(entry2.Value, cache.Get(entry1.Key).Value)
+
+		var reentrantErr error
+		cache.View(entry1.Key, func(value int) {
+			reentrantErr = cache.Set(entry2.Key, value)
+		})
+		var misuseErr *MisuseError
+		assert.True(errors.As(reentrantErr, &misuseErr))
+		assert.Equal(MisuseReentrantCall, misuseErr.Kind)
+		assert.Equal("Set", misuseErr.Method)
+
+		_, err := cache.CloseAndExport()
+		assert.NoError(err)
+		err = cache.Set(entry3.Key, entry3.Value)
+		assert.True(errors.As(err, &misuseErr))
+		assert.Equal(MisuseWriteOnReadOnly, misuseErr.Kind)
+		assert.True(errors.Is(err, ErrClosed))
+		assert.Nil(cache.Get(entry1.Key))
+		_, err = cache.GetOrCompute(entry1.Key, func(key string) (int, error) {
+			return entry1.Value, nil
+		})
+		assert.True(errors.As(err, &misuseErr))
+		assert.Equal(MisuseUseAfterClose, misuseErr.Kind)
+		// Only the misuses which can't be returned are reported
+		assert.Equal([]string{"Get:ReentrantCall", "Get:UseAfterClose"}, misuses)
+	}
+}
+
+func TestLRUCacheMisuseDetectionPanic(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:         10,
+		TTL:             time.Minute,
+		MisuseDetection: MisuseDetectionPanic,
+		Tenant: func(key string) string {
+			return key
+		},
+	}
+	cache := New(config)
+	assert.NoError(cache.Set(entry1.Key, entry1.Value))
+
+	cache.config.Tenant = func(key string) string {
+		cache.Len()
+		return key
+	}
+	assert.Equal(&MisuseError{Kind: MisuseReentrantCall, Method: "Len"}, recoverMisuse(func() {
+		cache.Set(entry2.Key, entry2.Value)
+	}))
+
+	cache = New(Config[string, int]{MaxSize: 10, TTL: time.Minute, MisuseDetection: MisuseDetectionPanic})
+	cache.CloseAndExport()
+	assert.Equal(&MisuseError{Kind: MisuseWriteOnReadOnly, Method: "Delete"}, recoverMisuse(func() {
+		cache.Delete(entry1.Key)
+	}))
+	assert.Equal(&MisuseError{Kind: MisuseUseAfterClose, Method: "Peek"}, recoverMisuse(func() {
+		cache.Peek(entry1.Key)
+	}))
+}
+
+func recoverMisuse(fn func()) (misuseErr *MisuseError) {
+	defer func() {
+		misuseErr, _ = recover().(*MisuseError)
+	}()
+	fn()
+
+	return nil
+}
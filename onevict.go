@@ -0,0 +1,28 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+// notifyEvictions invokes Config.OnEvict for the provided entries, asynchronously
+// by at most Config.OnEvictWorkers goroutines if evictionSlots is set
+func (c *TLRU[K, V]) notifyEvictions(evicted []EvictedEntry[K, V], evictionSlots chan struct{}) {
+	onEvict := c.config.OnEvict
+	for _, evictedEntry := range evicted {
+		evictedEntry := evictedEntry
+		if evictionSlots == nil {
+			c.protect("OnEvict", func() {
+				onEvict(evictedEntry)
+			})
+			continue
+		}
+
+		evictionSlots <- struct{}{}
+		go func() {
+			defer func() { <-evictionSlots }()
+			c.protect("OnEvict", func() {
+				onEvict(evictedEntry)
+			})
+		}()
+	}
+}
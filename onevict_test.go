@@ -0,0 +1,88 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheOnEvict(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var evicted []EvictedEntry[string, int]
+		var lengths []int
+		var cache *TLRU[string, int]
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			OnEvict: func(evictedEntry EvictedEntry[string, int]) {
+				evicted = append(evicted, evictedEntry)
+				lengths = append(lengths, cache.Len())
+			},
+		}
+		cache = New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Delete(entry3.Key)
+		cache.SetWithTimestamp(entry4.Key, entry4.Value, time.Now().Add(-time.Hour))
+		cache.Get(entry4.Key)
+
+		assert.Equal(3, len(evicted))
+		assert.Equal(entry1.Key, evicted[0].Key)
+		assert.Equal(EvictionReasonDropped, evicted[0].Reason)
+		assert.Equal(entry3.Key, evicted[1].Key)
+		assert.Equal(EvictionReasonDeleted, evicted[1].Reason)
+		assert.Equal(entry4.Key, evicted[2].Key)
+		assert.Equal(EvictionReasonExpired, evicted[2].Reason)
+		assert.Equal([]int{2, 1, 1}, lengths)
+	}
+}
+
+func TestLRUCacheOnEvictWorkers(t *testing.T) {
+	assert := assert.New(t)
+	var mutex sync.Mutex
+	evicted := map[string]evictionReason{}
+	var panics int
+	release := make(chan struct{})
+	config := Config[string, int]{
+		MaxSize:        1,
+		TTL:            time.Minute,
+		OnEvictWorkers: 2,
+		OnEvict: func(evictedEntry EvictedEntry[string, int]) {
+			<-release
+			if evictedEntry.Key == entry3.Key {
+				panic("boom")
+			}
+			defer mutex.Unlock()
+			mutex.Lock()
+			evicted[evictedEntry.Key] = evictedEntry.Reason
+		},
+		OnPanic: func(err *PanicError) {
+			defer mutex.Unlock()
+			mutex.Lock()
+			panics++
+		},
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+	cache.Set(entry3.Key, entry3.Value)
+	close(release)
+	cache.Delete(entry3.Key)
+
+	assert.Eventually(func() bool {
+		defer mutex.Unlock()
+		mutex.Lock()
+		return len(evicted) == 2 && panics == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(EvictionReasonDropped, evicted[entry1.Key])
+	assert.Equal(EvictionReasonDropped, evicted[entry2.Key])
+}
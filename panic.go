@@ -0,0 +1,114 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError holds a panic which has been recovered from a user-supplied callback
+type PanicError struct {
+	// The name of the callback, e.g. "OnFinalize"
+	Callback string
+	// The value passed to panic
+	Value interface{}
+	// The stack trace of the goroutine at the time of the panic
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tlru: %s panicked: %v", e.Callback, e.Value)
+}
+
+type finalizedValue[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// unlock releases the lock of the cache and then invokes Config.OnFinalize for the
+// values which have been finalized and Config.OnEvict for the entries which have
+// been evicted while holding it
+func (c *TLRU[K, V]) unlock() {
+	finalized, evicted, evictionSlots, panicked := c.finalized, c.evicted, c.evictionSlots, c.panicked
+	c.finalized, c.evicted, c.panicked = nil, nil, nil
+	c.Unlock()
+
+	for _, panicErr := range panicked {
+		c.reportPanic(panicErr)
+	}
+
+	for _, f := range finalized {
+		c.protect("OnFinalize", func() {
+			c.config.OnFinalize(f.key, f.value)
+		})
+	}
+	if len(evicted) > 0 {
+		c.notifyEvictions(evicted, evictionSlots)
+	}
+}
+
+// protectUnderLock invokes a callback while holding the lock of the cache like underLock
+// and converts its panic to a PanicError which is returned and reported to Config.OnPanic
+// once the lock is released, so that a panicking callback never leaves the lock held
+func (c *TLRU[K, V]) protectUnderLock(callback string, fn func()) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := &PanicError{Callback: callback, Value: recovered, Stack: debug.Stack()}
+			c.panicked = append(c.panicked, panicErr)
+			err = panicErr
+		}
+	}()
+	c.underLock(fn)
+
+	return nil
+}
+
+// protect invokes fn and reports a panic of fn to Config.OnPanic instead of propagating it
+func (c *TLRU[K, V]) protect(callback string, fn func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			c.reportPanic(&PanicError{Callback: callback, Value: recovered, Stack: debug.Stack()})
+		}
+	}()
+	fn()
+}
+
+// callLoader invokes the loader and converts a panic of the loader to a PanicError
+func (c *TLRU[K, V]) callLoader(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (value V, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := &PanicError{Callback: "loader", Value: recovered, Stack: debug.Stack()}
+			c.reportPanic(panicErr)
+			err = panicErr
+		}
+	}()
+
+	return loader(ctx, key)
+}
+
+// callManyLoader invokes the loader of GetOrComputeMany and converts a panic of the loader to a PanicError
+func (c *TLRU[K, V]) callManyLoader(keys []K, loader func(keys []K) (map[K]V, error)) (values map[K]V, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := &PanicError{Callback: "loader", Value: recovered, Stack: debug.Stack()}
+			c.reportPanic(panicErr)
+			err = panicErr
+		}
+	}()
+
+	return loader(keys)
+}
+
+func (c *TLRU[K, V]) reportPanic(err *PanicError) {
+	if c.config.OnPanic == nil {
+		return
+	}
+	defer func() {
+		recover()
+	}()
+	c.config.OnPanic(err)
+}
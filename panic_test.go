@@ -0,0 +1,77 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheCallbackPanicsAreRecovered(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var mutex sync.Mutex
+		var panics []string
+		config := Config[string, int]{
+			MaxSize:                   10,
+			TTL:                       10 * time.Millisecond,
+			EvictionPolicy:            policy,
+			GarbageCollectionInterval: 20 * time.Millisecond,
+			OnFinalize: func(key string, value int) {
+				panic("faulty finalizer")
+			},
+			OnExpiredBatch: func(entries []EvictedEntry[string, int]) {
+				panic("faulty batch handler")
+			},
+			OnPanic: func(err *PanicError) {
+				defer mutex.Unlock()
+				mutex.Lock()
+				panics = append(panics, err.Callback)
+			},
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Delete(entry1.Key)
+		cache.Set(entry2.Key, entry2.Value)
+
+		assert.Eventually(func() bool {
+			defer mutex.Unlock()
+			mutex.Lock()
+			return len(panics) == 3
+		}, time.Second, time.Millisecond)
+		assert.Equal([]string{"OnFinalize", "OnFinalize", "OnExpiredBatch"}, panics)
+
+		_, err := cache.GetOrCompute(entry3.Key, func(key string) (int, error) {
+			panic("faulty loader")
+		})
+		var panicErr *PanicError
+		assert.True(errors.As(err, &panicErr))
+		assert.Equal("loader", panicErr.Callback)
+		assert.Equal("faulty loader", panicErr.Value)
+		assert.NotEmpty(panicErr.Stack)
+	}
+}
+
+func TestLRUCacheOnFinalizeIsInvokedOutsideOfTheLock(t *testing.T) {
+	assert := assert.New(t)
+	var cache *TLRU[string, int]
+	lengths := make([]int, 0)
+	config := Config[string, int]{
+		MaxSize: 1,
+		TTL:     time.Minute,
+		OnFinalize: func(key string, value int) {
+			lengths = append(lengths, cache.Len())
+		},
+	}
+	cache = New(config)
+
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+	assert.Equal([]int{1}, lengths)
+}
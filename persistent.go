@@ -0,0 +1,160 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checksumSize is the size of the CRC-32 checksum which trails the snapshot files of NewPersistent
+const checksumSize = 4
+
+// NewPersistent returns a new instance of TLRU whose State is persisted to the file at the
+// provided path via Config.Snapshotter, every Snapshotter.Interval(if set) and on CloseAndExport.
+// Snapshotter.Target is replaced while Snapshotter.Interval and Snapshotter.OnError are kept
+// If the file exists the cache is rehydrated from it, discarding the entries which have
+// expired in the meantime. Snapshots are checksummed and written to a temporary file which
+// replaces the previous snapshot only once it is complete
+// If the file can't be loaded, e.g. because it is corrupted, the returned cache is empty
+// and usable and the error is returned along with it
+func NewPersistent[K comparable, V any](config Config[K, V], path string) (*TLRU[K, V], error) {
+	config.Snapshotter.Target = func() (io.WriteCloser, error) {
+		file, err := createSnapshotFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	cache := New(config)
+
+	state, err := readSnapshotFile[K, V](path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, fmt.Errorf("tlru.NewPersistent: %w", err)
+	}
+	if err := cache.SetState(cache.unexpired(state)); err != nil {
+		return cache, fmt.Errorf("tlru.NewPersistent: %w", err)
+	}
+
+	return cache, nil
+}
+
+// unexpired returns the provided State without the entries which would be expired once restored
+func (c *TLRU[K, V]) unexpired(state State[K, V]) State[K, V] {
+	defer c.RUnlock()
+	c.RLock()
+
+	now := time.Now()
+	entries := state.Entries[:0]
+	for _, stateEntry := range state.Entries {
+		_, createdAt := c.restoredCounterAndCreatedAt(stateEntry)
+		ttl, _ := c.entryTTL(stateEntry.TTL)
+		if c.limitLifetime(c.deadline(stateEntry.LastUsedAt, ttl), createdAt, ttl).After(now) {
+			entries = append(entries, stateEntry)
+		}
+	}
+	state.Entries = entries
+
+	return state
+}
+
+// readSnapshotFile verifies the checksum of the snapshot file at the provided path
+// and then decodes its State
+func readSnapshotFile[K comparable, V any](path string) (State[K, V], error) {
+	var state State[K, V]
+	file, err := os.Open(path)
+	if err != nil {
+		return state, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return state, err
+	}
+	size := info.Size() - checksumSize
+	if size < 0 {
+		return state, fmt.Errorf("Snapshot '%s' is truncated. %w", path, ErrCorruptedSnapshot)
+	}
+	checksum := crc32.NewIEEE()
+	if _, err := io.Copy(checksum, io.LimitReader(file, size)); err != nil {
+		return state, err
+	}
+	var expected [checksumSize]byte
+	if _, err := io.ReadFull(file, expected[:]); err != nil {
+		return state, err
+	}
+	if binary.BigEndian.Uint32(expected[:]) != checksum.Sum32() {
+		return state, fmt.Errorf("Checksum of snapshot '%s' doesn't match. %w", path, ErrCorruptedSnapshot)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return state, err
+	}
+	if state, err = ReadStateFrom[K, V](io.LimitReader(file, size)); err != nil {
+		return state, fmt.Errorf("Snapshot '%s' can't be decoded: %v. %w", path, err, ErrCorruptedSnapshot)
+	}
+
+	return state, nil
+}
+
+// snapshotFile is a temporary file which is checksummed and renamed to
+// the snapshot file once the snapshot is complete
+type snapshotFile struct {
+	file     *os.File
+	path     string
+	checksum hash.Hash32
+}
+
+func createSnapshotFile(path string) (*snapshotFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshotFile{file: file, path: path, checksum: crc32.NewIEEE()}, nil
+}
+
+func (f *snapshotFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	f.checksum.Write(p[:n])
+	return n, err
+}
+
+// Close appends the checksum and replaces the snapshot file with the temporary one
+func (f *snapshotFile) Close() error {
+	var checksum [checksumSize]byte
+	binary.BigEndian.PutUint32(checksum[:], f.checksum.Sum32())
+	if _, err := f.file.Write(checksum[:]); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		os.Remove(f.file.Name())
+		return err
+	}
+
+	return os.Rename(f.file.Name(), f.path)
+}
+
+// Abort discards the temporary file and keeps the previous snapshot file
+func (f *snapshotFile) Abort() error {
+	f.file.Close()
+	return os.Remove(f.file.Name())
+}
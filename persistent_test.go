@@ -0,0 +1,74 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheNewPersistent(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		path := filepath.Join(t.TempDir(), "cache.snapshot")
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache, err := NewPersistent(config, path)
+		assert.NoError(err)
+		assert.Equal(0, cache.Len())
+
+		cache.SetMany([]Entry[string, int]{entry1, entry2})
+		cache.SetWithTTL(entry3.Key, entry3.Value, 50*time.Millisecond)
+		_, err = cache.CloseAndExport()
+		assert.NoError(err)
+		matches, _ := filepath.Glob(path + ".*.tmp")
+		assert.Equal(0, len(matches))
+
+		time.Sleep(100 * time.Millisecond)
+		restoredCache, err := NewPersistent(config, path)
+		assert.NoError(err)
+		assert.Equal(2, restoredCache.Len())
+		assert.Equal(entry1.Value, restoredCache.Get(entry1.Key).Value)
+		assert.Nil(restoredCache.Peek(entry3.Key))
+
+		restoredCache.Set(entry4.Key, entry4.Value)
+		assert.NoError(restoredCache.Snapshot())
+		restoredCache, err = NewPersistent(config, path)
+		assert.NoError(err)
+		assert.Equal(3, restoredCache.Len())
+	}
+}
+
+func TestLRUCacheNewPersistentCorrupted(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	config := Config[string, int]{MaxSize: 10, TTL: time.Minute}
+	cache, _ := NewPersistent(config, path)
+	cache.SetMany([]Entry[string, int]{entry1, entry2})
+	assert.NoError(cache.Snapshot())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	data[len(data)/2] ^= 0xff
+	assert.NoError(os.WriteFile(path, data, 0o600))
+
+	cache, err = NewPersistent(config, path)
+	assert.True(errors.Is(err, ErrCorruptedSnapshot))
+	assert.NotNil(cache)
+	assert.Equal(0, cache.Len())
+	cache.Set(entry1.Key, entry1.Value)
+	assert.Equal(entry1.Value, cache.Get(entry1.Key).Value)
+
+	assert.NoError(os.WriteFile(path, data[:2], 0o600))
+	_, err = NewPersistent(config, path)
+	assert.True(errors.Is(err, ErrCorruptedSnapshot))
+}
@@ -0,0 +1,160 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// nilTypeName is the name under which nil values are encoded
+const nilTypeName = ""
+
+type typeCodec struct {
+	t      reflect.Type
+	name   string
+	encode func(any) ([]byte, error)
+	decode func([]byte) (any, error)
+}
+
+// TypeRegistry encodes and decodes values of heterogeneous types, e.g. the values of
+// a TLRU[K, any], by prefixing their encoding with the name of their registered type
+// Its Marshal and Unmarshal methods can be used as Config.ValueMarshaler and
+// Config.ValueUnmarshaler so that MarshalBinary and MarshalJSON restore the original types
+type TypeRegistry struct {
+	mutex  sync.RWMutex
+	byType map[reflect.Type]typeCodec
+	byName map[string]typeCodec
+}
+
+// NewTypeRegistry returns an empty TypeRegistry
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		byType: make(map[reflect.Type]typeCodec),
+		byName: make(map[string]typeCodec),
+	}
+}
+
+// RegisterType registers the encoding of type T under the provided name which must be
+// unique within the registry. If encode or decode is nil encoding/json is used instead
+// A registration replaces a previous one of the same type or name
+func RegisterType[T any](registry *TypeRegistry, name string, encode func(T) ([]byte, error), decode func([]byte) (T, error)) {
+	if encode == nil {
+		encode = func(value T) ([]byte, error) {
+			return json.Marshal(value)
+		}
+	}
+	if decode == nil {
+		decode = func(data []byte) (T, error) {
+			var value T
+			err := json.Unmarshal(data, &value)
+			return value, err
+		}
+	}
+
+	defer registry.mutex.Unlock()
+	registry.mutex.Lock()
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if previous, exists := registry.byName[name]; exists {
+		delete(registry.byType, previous.t)
+	}
+	if previous, exists := registry.byType[t]; exists {
+		delete(registry.byName, previous.name)
+	}
+
+	c := typeCodec{
+		t:    t,
+		name: name,
+		encode: func(value any) ([]byte, error) {
+			return encode(value.(T))
+		},
+		decode: func(data []byte) (any, error) {
+			return decode(data)
+		},
+	}
+	registry.byType[t] = c
+	registry.byName[name] = c
+}
+
+// Marshal returns the encoding of the provided value which is prefixed with the name
+// of its type. It returns an error that wraps ErrUnregisteredType if the dynamic type
+// of the value hasn't been registered. Nil values don't need to be registered
+func (r *TypeRegistry) Marshal(value any) ([]byte, error) {
+	if value == nil {
+		return appendTypeName(nil, nilTypeName), nil
+	}
+
+	name, data, err := r.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(appendTypeName(make([]byte, 0, len(name)+len(data)+binary.MaxVarintLen64), name), data...), nil
+}
+
+// Encode returns the name under which the dynamic type of the provided value has been
+// registered along with the encoding of the value. It returns an error that wraps
+// ErrUnregisteredType if the type hasn't been registered
+// It allows other encodings, such as the ones of tlrupb, to embed the values of the registry
+func (r *TypeRegistry) Encode(value any) (string, []byte, error) {
+	t := reflect.TypeOf(value)
+	r.mutex.RLock()
+	c, exists := r.byType[t]
+	r.mutex.RUnlock()
+	if !exists {
+		return "", nil, fmt.Errorf("tlru.TypeRegistry: %s. %w", t, ErrUnregisteredType)
+	}
+
+	data, err := c.encode(value)
+	if err != nil {
+		return "", nil, fmt.Errorf("tlru.TypeRegistry: Cannot encode %s: %w", c.name, err)
+	}
+
+	return c.name, data, nil
+}
+
+// Unmarshal decodes a value which has been encoded by Marshal into a value of its registered type
+// It returns an error that wraps ErrUnregisteredType if the type of the value isn't registered
+func (r *TypeRegistry) Unmarshal(data []byte) (any, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < length {
+		return nil, fmt.Errorf("tlru.TypeRegistry: Invalid encoding")
+	}
+	name, data := string(data[n:n+int(length)]), data[n+int(length):]
+	if name == nilTypeName {
+		return nil, nil
+	}
+
+	return r.Decode(name, data)
+}
+
+// Decode decodes data which has been returned by Encode into a value of the type registered
+// under the provided name. It returns an error that wraps ErrUnregisteredType if no type is
+// registered under the name
+func (r *TypeRegistry) Decode(name string, data []byte) (any, error) {
+	r.mutex.RLock()
+	c, exists := r.byName[name]
+	r.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("tlru.TypeRegistry: %s. %w", name, ErrUnregisteredType)
+	}
+
+	value, err := c.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("tlru.TypeRegistry: Cannot decode %s: %w", name, err)
+	}
+
+	return value, nil
+}
+
+func appendTypeName(b []byte, name string) []byte {
+	var length [binary.MaxVarintLen64]byte
+	b = append(b, length[:binary.PutUvarint(length[:], uint64(len(name)))]...)
+	return append(b, name...)
+}
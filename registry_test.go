@@ -0,0 +1,74 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type point struct {
+	X int
+	Y int
+}
+
+func TestTypeRegistry(t *testing.T) {
+	assert := assert.New(t)
+	registry := NewTypeRegistry()
+	RegisterType[point](registry, "point", nil, nil)
+	RegisterType(registry, "int", func(value int) ([]byte, error) {
+		return []byte(strconv.Itoa(value)), nil
+	}, func(data []byte) (int, error) {
+		return strconv.Atoi(string(data))
+	})
+	RegisterType[string](registry, "string", nil, nil)
+
+	for _, policy := range policies {
+		config := Config[string, any]{
+			MaxSize:          10,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			ValueMarshaler:   registry.Marshal,
+			ValueUnmarshaler: registry.Unmarshal,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, point{X: 1, Y: 2})
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, "value")
+		cache.Set(entry4.Key, nil)
+
+		data, err := json.Marshal(cache)
+		assert.NoError(err)
+		restored := New(config)
+		assert.NoError(json.Unmarshal(data, restored))
+		assert.Equal(point{X: 1, Y: 2}, restored.Peek(entry1.Key).Value)
+		assert.Equal(entry2.Value, restored.Peek(entry2.Key).Value)
+		assert.Equal("value", restored.Peek(entry3.Key).Value)
+		assert.Nil(restored.Peek(entry4.Key).Value)
+
+		data, err = cache.MarshalBinary()
+		assert.NoError(err)
+		restored = &TLRU[string, any]{}
+		restored.config.ValueUnmarshaler = registry.Unmarshal
+		assert.NoError(restored.UnmarshalBinary(data))
+		assert.Equal(point{X: 1, Y: 2}, restored.Peek(entry1.Key).Value)
+
+		cache.Set("float", 1.5)
+		_, err = cache.MarshalBinary()
+		assert.True(errors.Is(err, ErrUnregisteredType))
+	}
+
+	data, err := registry.Marshal(point{X: 3})
+	assert.NoError(err)
+	RegisterType[point](registry, "point-v2", nil, nil)
+	_, err = registry.Unmarshal(data)
+	assert.True(errors.Is(err, ErrUnregisteredType))
+	_, err = registry.Unmarshal([]byte{10})
+	assert.Error(err)
+}
@@ -0,0 +1,61 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rekey moves the entry of oldKey to newKey atomically preserving its value, Counter,
+// timestamps, TTL, metadata and position in the list, e.g. when a temporary id is
+// replaced by a permanent one
+// No EvictedEntry is emitted while an OperationDelete of oldKey followed by an
+// OperationSet of newKey are emitted to the OperationChannel(if present)
+// It fails with ErrNotFound if an entry for oldKey doesn't exist or is expired, with
+// ErrKeyExists if an entry for newKey exists and with ErrBorrowed if oldKey is borrowed
+func (c *TLRU[K, V]) Rekey(oldKey, newKey K) error {
+	if err := c.checkMisuse("Rekey", true); err != nil {
+		return err
+	}
+	defer c.unlock()
+	c.Lock()
+
+	if c.closed {
+		return fmt.Errorf("tlru.Rekey: %w", ErrClosed)
+	}
+	linkedNode, exists := c.cache[oldKey]
+	if !exists || linkedNode.isExpired(time.Now()) {
+		return fmt.Errorf("tlru.Rekey: Key '%+v' doesn't exist. %w", oldKey, ErrNotFound)
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	if _, exists := c.cache[newKey]; exists {
+		return fmt.Errorf("tlru.Rekey: Key '%+v' already exists. %w", newKey, ErrKeyExists)
+	}
+	if c.borrows[oldKey] != nil {
+		return fmt.Errorf("tlru.Rekey: Key '%+v' is borrowed. %w", oldKey, ErrBorrowed)
+	}
+
+	c.unindex(linkedNode)
+	delete(c.cache, oldKey)
+	delete(c.leases, oldKey)
+	delete(c.leases, newKey)
+	linkedNode.key = newKey
+	c.cache[newKey] = linkedNode
+	c.index(linkedNode)
+
+	c.emitOperation(Operation[K, V]{Type: OperationDelete, Key: oldKey})
+	c.emitOperation(Operation[K, V]{
+		Type:       OperationSet,
+		Event:      EventInserted,
+		Key:        newKey,
+		Value:      linkedNode.readValue(),
+		LastUsedAt: linkedNode.lastUsedAt,
+	})
+
+	return nil
+}
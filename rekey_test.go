@@ -0,0 +1,64 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheRekey(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		operationChannel := make(chan Operation[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:          3,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			OperationChannel: &operationChannel,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Get(entry2.Key)
+		for len(operationChannel) > 0 {
+			<-operationChannel
+		}
+		before := cache.Peek(entry2.Key)
+		keys := cache.GetState().Entries
+
+		assert.NoError(cache.Rekey(entry2.Key, entry4.Key))
+		assert.False(cache.Has(entry2.Key))
+		after := cache.Peek(entry4.Key)
+		assert.Equal(entry4.Key, after.Key)
+		assert.Equal(before.Value, after.Value)
+		assert.Equal(before.Counter, after.Counter)
+		assert.Equal(before.LastUsedAt, after.LastUsedAt)
+		assert.Equal(before.CreatedAt, after.CreatedAt)
+		for i, stateEntry := range cache.GetState().Entries {
+			if keys[i].Key == entry2.Key {
+				assert.Equal(entry4.Key, stateEntry.Key)
+				continue
+			}
+			assert.Equal(keys[i].Key, stateEntry.Key)
+		}
+		assert.Equal(OperationDelete, (<-operationChannel).Type)
+		operation := <-operationChannel
+		assert.Equal(OperationSet, operation.Type)
+		assert.Equal(entry4.Key, operation.Key)
+
+		assert.True(errors.Is(cache.Rekey(entry2.Key, "key5"), ErrNotFound))
+		assert.True(errors.Is(cache.Rekey(entry1.Key, entry3.Key), ErrKeyExists))
+		cache.Checkout(entry1.Key)
+		assert.True(errors.Is(cache.Rekey(entry1.Key, "key5"), ErrBorrowed))
+		cache.Checkin(entry1.Key)
+		assert.NoError(cache.Rekey(entry1.Key, entry1.Key))
+		assert.Equal(3, cache.Len())
+	}
+}
@@ -0,0 +1,135 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultProviderPollInterval = 30 * time.Second
+
+// Tunables holds the settings of the cache which can be changed at runtime
+// Zero values leave the corresponding setting unchanged
+type Tunables struct {
+	MaxSize                   int
+	TTL                       time.Duration
+	GarbageCollectionInterval time.Duration
+}
+
+// Provider provides the Tunables of the cache, e.g. from a config service or flags
+type Provider interface {
+	Tunables() (Tunables, error)
+}
+
+// Resize changes the max size of the cache. If the cache holds more entries than
+// the new size then the least recently used entries will be dropped and an
+// EvictedEntry will be emitted to the EvictionChannel(if present) with EvictionReasonDropped
+// A size of 0 makes the cache unbounded
+func (c *TLRU[K, V]) Resize(maxSize int) {
+	defer c.unlock()
+	c.Lock()
+
+	c.config.MaxSize = maxSize
+	c.shrink(maxSize)
+}
+
+// SetTTL changes the time to live of the cache
+// The expiration of existing entries without an own TTL is recomputed from their LastUsedAt
+func (c *TLRU[K, V]) SetTTL(ttl time.Duration) {
+	defer c.unlock()
+	c.Lock()
+
+	c.config.TTL = ttl
+	for node := c.sentinel.next; node != c.sentinel; node = node.next {
+		if node.customTTL {
+			continue
+		}
+		node.ttl = ttl
+		node.expiresAt = c.limitLifetime(c.deadline(node.lastUsedAt, ttl), node.createdAt, ttl)
+		c.scheduleExpiration(node)
+	}
+}
+
+// SetGarbageCollectionInterval changes the interval of the garbage collection
+// The cycle which is already scheduled keeps its interval and the following ones use the new one
+func (c *TLRU[K, V]) SetGarbageCollectionInterval(interval time.Duration) {
+	defer c.unlock()
+	c.Lock()
+
+	c.config.GarbageCollectionInterval = interval
+	c.garbageCollectionInterval = interval
+}
+
+// ReloadConfig fetches the Tunables from Config.Provider and applies the ones that changed
+// It is invoked every Config.ProviderPollInterval and can be invoked to reload immediately
+// If the Provider fails the current settings are kept and the error is returned
+func (c *TLRU[K, V]) ReloadConfig() error {
+	c.RLock()
+	provider, current := c.config.Provider, c.tunables()
+	c.RUnlock()
+	if provider == nil {
+		return nil
+	}
+
+	var tunables Tunables
+	var err error
+	c.protect("Provider", func() {
+		tunables, err = provider.Tunables()
+	})
+	if err != nil {
+		return fmt.Errorf("tlru.ReloadConfig: %w", err)
+	}
+
+	if tunables.MaxSize > 0 && tunables.MaxSize != current.MaxSize {
+		c.Resize(tunables.MaxSize)
+	}
+	if tunables.TTL > 0 && tunables.TTL != current.TTL {
+		c.SetTTL(tunables.TTL)
+	}
+	if tunables.GarbageCollectionInterval > 0 && tunables.GarbageCollectionInterval != current.GarbageCollectionInterval {
+		c.SetGarbageCollectionInterval(tunables.GarbageCollectionInterval)
+	}
+
+	return nil
+}
+
+// tunables must be called while holding the lock of the cache
+func (c *TLRU[K, V]) tunables() Tunables {
+	return Tunables{
+		MaxSize:                   c.config.MaxSize,
+		TTL:                       c.config.TTL,
+		GarbageCollectionInterval: c.config.GarbageCollectionInterval,
+	}
+}
+
+// scheduleProviderPoll must be called while holding the lock of the cache
+// Polls which have been scheduled before are not rescheduled anymore
+func (c *TLRU[K, V]) scheduleProviderPoll() {
+	if c.providerTimer != nil {
+		c.providerTimer.Stop()
+		c.providerTimer = nil
+	}
+	c.providerGeneration++
+	if c.config.Provider == nil || c.closed {
+		return
+	}
+
+	generation := c.providerGeneration
+	c.providerTimer = time.AfterFunc(c.config.ProviderPollInterval, func() {
+		c.pollProvider(generation)
+	})
+}
+
+// pollProvider reloads the config and schedules the next poll unless it has been rescheduled
+func (c *TLRU[K, V]) pollProvider(generation uint64) {
+	c.ReloadConfig()
+
+	defer c.Unlock()
+	c.Lock()
+	if generation == c.providerGeneration {
+		c.scheduleProviderPoll()
+	}
+}
@@ -0,0 +1,130 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProvider struct {
+	sync.Mutex
+	tunables Tunables
+	err      error
+	calls    int
+}
+
+func (p *mockProvider) Tunables() (Tunables, error) {
+	defer p.Unlock()
+	p.Lock()
+	p.calls++
+
+	return p.tunables, p.err
+}
+
+func (p *mockProvider) set(tunables Tunables, err error) {
+	defer p.Unlock()
+	p.Lock()
+	p.tunables, p.err = tunables, err
+}
+
+func TestLRUCacheResizeAndSetTTL(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:         3,
+			TTL:             time.Minute,
+			EvictionChannel: &evictionChannel,
+			EvictionPolicy:  policy,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+
+		cache.Resize(1)
+		assert.Equal(1, cache.Len())
+		assert.Equal(entry1.Key, (<-evictionChannel).Key)
+		assert.Equal(entry2.Key, (<-evictionChannel).Key)
+		cache.Set(entry4.Key, entry4.Value)
+		assert.Equal(1, cache.Len())
+		<-evictionChannel
+
+		cache.SetTTL(time.Nanosecond)
+		assert.Equal(time.Nanosecond, cache.TTL())
+		assert.Nil(cache.Get(entry4.Key))
+	}
+}
+
+func TestLRUCacheProvider(t *testing.T) {
+	assert := assert.New(t)
+	provider := &mockProvider{tunables: Tunables{MaxSize: 2}}
+	config := Config[string, int]{
+		MaxSize:              3,
+		TTL:                  time.Minute,
+		Provider:             provider,
+		ProviderPollInterval: 10 * time.Millisecond,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+	cache.Set(entry3.Key, entry3.Value)
+
+	assert.Eventually(func() bool { return cache.Len() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(time.Minute, cache.Config().TTL)
+
+	provider.set(Tunables{}, errors.New("unavailable"))
+	err := cache.ReloadConfig()
+	assert.Error(err)
+	assert.Equal(2, cache.Config().MaxSize)
+
+	provider.set(Tunables{TTL: time.Hour, GarbageCollectionInterval: time.Second}, nil)
+	assert.NoError(cache.ReloadConfig())
+	assert.Equal(time.Hour, cache.TTL())
+	assert.Equal(time.Second, cache.Config().GarbageCollectionInterval)
+
+	_, err = cache.CloseAndExport()
+	assert.NoError(err)
+	provider.Lock()
+	calls := provider.calls
+	provider.Unlock()
+	time.Sleep(30 * time.Millisecond)
+	provider.Lock()
+	assert.Equal(calls, provider.calls)
+	provider.Unlock()
+}
+
+func TestLRUCacheSetGarbageCollectionInterval(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:                   10,
+			TTL:                       time.Minute,
+			EvictionPolicy:            policy,
+			GarbageCollectionInterval: 5 * time.Millisecond,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		assert.Eventually(func() bool {
+			return cache.Stats().GarbageCollections >= 1
+		}, time.Second, time.Millisecond)
+
+		cache.SetGarbageCollectionInterval(10 * time.Millisecond)
+		cache.SetWithTTL(entry2.Key, entry2.Value, 30*time.Millisecond)
+		collections := cache.Stats().GarbageCollections
+		assert.Eventually(func() bool {
+			return cache.Stats().GarbageCollections >= collections+3
+		}, time.Second, time.Millisecond)
+		assert.Eventually(func() bool {
+			return cache.Stats().Expirations == 1
+		}, time.Second, time.Millisecond)
+		assert.Equal([]string{entry1.Key}, cache.Keys())
+		cache.CloseAndExport()
+	}
+}
@@ -0,0 +1,55 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+// addSample must be called while holding the lock of the cache
+func (c *TLRU[K, V]) addSample(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.EvictionSamples <= 0 {
+		return
+	}
+	linkedNode.slot = len(c.samples)
+	c.samples = append(c.samples, linkedNode)
+}
+
+// removeSample must be called while holding the lock of the cache
+// It moves the last sample to the slot of the removed node
+func (c *TLRU[K, V]) removeSample(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.EvictionSamples <= 0 {
+		return
+	}
+	last := len(c.samples) - 1
+	c.samples[linkedNode.slot] = c.samples[last]
+	c.samples[linkedNode.slot].slot = linkedNode.slot
+	c.samples[last] = nil
+	c.samples = c.samples[:last]
+}
+
+// shrinkSampled drops entries until the cache holds at most n entries. Every dropped entry
+// is the least recently used one which isn't borrowed among Config.EvictionSamples randomly
+// sampled entries. If all sampled entries are borrowed the least recently inserted entry
+// which isn't borrowed is dropped
+func (c *TLRU[K, V]) shrinkSampled(n int) {
+	for len(c.cache) > n && !c.closed {
+		var droppedNode *doublyLinkedNode[K, V]
+		for i := 0; i < c.config.EvictionSamples; i++ {
+			sampledNode := c.samples[c.random.Intn(len(c.samples))]
+			if c.borrows[sampledNode.key] != nil {
+				continue
+			}
+			if droppedNode == nil || sampledNode.lastUsedAt.Before(droppedNode.lastUsedAt) {
+				droppedNode = sampledNode
+			}
+		}
+		for previousNode := c.sentinel.previous; droppedNode == nil && previousNode != c.sentinel; previousNode = previousNode.previous {
+			if c.borrows[previousNode.key] == nil {
+				droppedNode = previousNode
+			}
+		}
+		if droppedNode == nil {
+			return
+		}
+		c.evictEntry(droppedNode, EvictionReasonDropped)
+	}
+}
@@ -0,0 +1,127 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheEvictionSamples(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:         100,
+		TTL:             time.Minute,
+		EvictionPolicy:  LRA,
+		EvictionSamples: 5,
+		RandSource:      rand.NewSource(1),
+	}
+	cache := New(config)
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprint(i), i)
+	}
+	time.Sleep(time.Millisecond)
+	for i := 0; i < 50; i++ {
+		cache.Get(fmt.Sprint(i))
+	}
+	lruKey, _ := cache.LRUKey()
+	assert.Equal("0", lruKey)
+
+	for i := 100; i < 150; i++ {
+		cache.Set(fmt.Sprint(i), i)
+	}
+	assert.Equal(100, cache.Len())
+
+	survivors := 0
+	for i := 0; i < 50; i++ {
+		if cache.Has(fmt.Sprint(i)) {
+			survivors++
+		}
+	}
+	assert.True(survivors > 35, "%d of the recently used entries survived", survivors)
+
+	cache.Delete("149")
+	assert.Equal(cache.Len(), len(cache.samples))
+	for slot, linkedNode := range cache.samples {
+		assert.Equal(slot, linkedNode.slot)
+	}
+	assert.NoError(cache.SetState(cache.GetState()))
+	assert.Equal(cache.Len(), len(cache.samples))
+	cache.Clear()
+	assert.Equal(0, len(cache.samples))
+}
+
+func TestLRUCacheEvictionSamplesGet(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:         10,
+		TTL:             time.Minute,
+		EvictionPolicy:  LRA,
+		EvictionSamples: 5,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+
+	// Accesses update the entry without moving it in the list
+	lastUsedAt := cache.Peek(entry1.Key).LastUsedAt
+	time.Sleep(time.Millisecond)
+	assert.Equal(int64(1), cache.Get(entry1.Key).Counter)
+	assert.True(cache.Peek(entry1.Key).LastUsedAt.After(lastUsedAt))
+	mruKey, _ := cache.MRUKey()
+	assert.Equal(entry2.Key, mruKey)
+
+	// Get still needs the write lock unless accesses are buffered
+	for _, accessBufferSize := range []int{0, 16} {
+		config.AccessBufferSize = accessBufferSize
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+
+		cache.RLock()
+		done := make(chan struct{})
+		go func() {
+			cache.Get(entry1.Key)
+			close(done)
+		}()
+		select {
+		case <-done:
+			assert.True(accessBufferSize > 0, "Get didn't wait for the write lock")
+		case <-time.After(20 * time.Millisecond):
+			assert.True(accessBufferSize == 0, "Get waited for the write lock")
+		}
+		cache.RUnlock()
+		<-done
+	}
+}
+
+func TestLRUCacheRandSourceIsDeterministic(t *testing.T) {
+	assert := assert.New(t)
+	survivors := func(seed int64) []string {
+		cache := New(Config[string, int]{
+			MaxSize:         20,
+			TTL:             time.Hour,
+			EvictionPolicy:  LRA,
+			EvictionSamples: 3,
+			RandSource:      rand.NewSource(seed),
+		})
+		// Distinct timestamps make the comparisons of the sampled entries independent of the clock
+		startedAt := time.Now().Add(-time.Minute)
+		for i := 0; i < 100; i++ {
+			cache.SetWithTimestamp(fmt.Sprint(i), i, startedAt.Add(time.Duration(i)*time.Millisecond))
+		}
+		keys := cache.Keys()
+		sort.Strings(keys)
+
+		return keys
+	}
+
+	assert.Equal(survivors(1), survivors(1))
+	assert.Equal(survivors(2), survivors(2))
+	assert.NotEqual(survivors(1), survivors(2))
+}
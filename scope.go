@@ -0,0 +1,72 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetScoped is identical to the Set function but the entry is deleted as soon as the
+// provided context is done, e.g. for data which is only valid during a request or session
+// The entries of a context are tracked together and a single goroutine per context waits
+// for it so that scoping many entries to the same context is cheap
+// The entry stays scoped while it is updated via Set and its variants but not once it
+// has been evicted and inserted again. A context which is never done, such as
+// context.Background, doesn't scope the entry at all
+// It returns the error of the context without setting the entry if it is already done
+func (c *TLRU[K, V]) SetScoped(ctx context.Context, key K, value V) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("tlru.SetScoped: %w", err)
+	}
+	if err := c.checkMisuse("SetScoped", true); err != nil {
+		return err
+	}
+	defer c.unlock()
+	c.Lock()
+
+	if err := c.insert(Entry[K, V]{Key: key, Value: value}); err != nil {
+		return fmt.Errorf("tlru.SetScoped: %w", err)
+	}
+	if done := ctx.Done(); done != nil {
+		c.addScope(done, c.cache[key])
+	}
+
+	return nil
+}
+
+// addScope registers the node to the scope of the done channel of a context and
+// starts waiting for it if it is the first node of the scope
+// It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) addScope(done <-chan struct{}, linkedNode *doublyLinkedNode[K, V]) {
+	if c.scopes == nil {
+		c.scopes = make(map[<-chan struct{}]map[K]uint64)
+	}
+	scope, exists := c.scopes[done]
+	if !exists {
+		scope = make(map[K]uint64)
+		c.scopes[done] = scope
+		go func() {
+			<-done
+			c.closeScope(done)
+		}()
+	}
+	scope[linkedNode.key] = linkedNode.sequence
+}
+
+// closeScope deletes the entries of the scope of the done channel of a context
+// which haven't been evicted in the meantime
+func (c *TLRU[K, V]) closeScope(done <-chan struct{}) {
+	defer c.unlock()
+	c.Lock()
+
+	scope := c.scopes[done]
+	delete(c.scopes, done)
+	for key, sequence := range scope {
+		if linkedNode, exists := c.cache[key]; exists && linkedNode.sequence == sequence {
+			c.delete(key)
+		}
+	}
+}
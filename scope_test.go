@@ -0,0 +1,53 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheSetScoped(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+		}
+		cache := New(config)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		assert.NoError(cache.SetScoped(ctx, entry1.Key, entry1.Value))
+		assert.NoError(cache.SetScoped(ctx, entry2.Key, entry2.Value))
+		assert.NoError(cache.SetScoped(context.Background(), entry3.Key, entry3.Value))
+		cache.Delete(entry2.Key)
+		<-evictionChannel
+		cache.Set(entry2.Key, entry2.Value)
+		assert.Equal(1, len(cache.scopes))
+
+		cancel()
+		evictedEntry := <-evictionChannel
+		assert.Equal(entry1.Key, evictedEntry.Key)
+		assert.Equal(EvictionReasonDeleted, evictedEntry.Reason)
+		assert.Eventually(func() bool {
+			cache.RLock()
+			defer cache.RUnlock()
+			return len(cache.scopes) == 0
+		}, time.Second, time.Millisecond)
+		assert.False(cache.Has(entry1.Key))
+		assert.True(cache.Has(entry2.Key))
+		assert.True(cache.Has(entry3.Key))
+
+		err := cache.SetScoped(ctx, entry4.Key, entry4.Value)
+		assert.True(errors.Is(err, context.Canceled))
+		assert.False(cache.Has(entry4.Key))
+	}
+}
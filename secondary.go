@@ -0,0 +1,72 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+// GetByIndex retrieves the entry whose value is mapped to the provided index by
+// Config.IndexFunc and behaves like Get for its key otherwise
+// If several entries are mapped to the same index the most recently written one is returned
+// It always returns nil if Config.IndexFunc is not set or if it panics
+func (c *TLRU[K, V]) GetByIndex(index any) *CacheEntry[K, V] {
+	if c.config.IndexFunc == nil {
+		return nil
+	}
+	c.RLock()
+	linkedNode, exists := c.secondary[index]
+	c.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	cacheEntry := c.Get(linkedNode.key)
+	if cacheEntry == nil {
+		return nil
+	}
+	matches := false
+	c.protect("IndexFunc", func() {
+		matches = c.config.IndexFunc(cacheEntry.Value) == index
+	})
+	if !matches {
+		return nil
+	}
+
+	return cacheEntry
+}
+
+// addIndex must be called while holding the lock of the cache whenever a node is added to it
+// If Config.IndexFunc panics or returns an index which isn't comparable the node isn't indexed
+// and the panic is reported to Config.OnPanic once the lock is released
+func (c *TLRU[K, V]) addIndex(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.IndexFunc == nil {
+		return
+	}
+	if c.secondary == nil {
+		c.secondary = make(map[any]*doublyLinkedNode[K, V])
+	}
+	var index any
+	err := c.protectUnderLock("IndexFunc", func() {
+		index = c.config.IndexFunc(linkedNode.readValue())
+		c.secondary[index] = linkedNode
+	})
+	if err == nil {
+		linkedNode.indexedBy = index
+	}
+}
+
+// removeIndex must be called while holding the lock of the cache whenever a node is removed from it
+func (c *TLRU[K, V]) removeIndex(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.IndexFunc == nil {
+		return
+	}
+	if c.secondary[linkedNode.indexedBy] == linkedNode {
+		delete(c.secondary, linkedNode.indexedBy)
+	}
+	linkedNode.indexedBy = nil
+}
+
+// reindex must be called while holding the lock of the cache whenever the value of a node changes
+func (c *TLRU[K, V]) reindex(linkedNode *doublyLinkedNode[K, V]) {
+	c.removeIndex(linkedNode)
+	c.addIndex(linkedNode)
+}
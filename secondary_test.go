@@ -0,0 +1,94 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID    string
+	Email string
+}
+
+func TestLRUCacheGetByIndex(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		for _, entryLocking := range []bool{false, true} {
+			config := Config[string, user]{
+				MaxSize:        2,
+				TTL:            time.Minute,
+				EvictionPolicy: policy,
+				EntryLocking:   entryLocking,
+				IndexFunc: func(value user) any {
+					return value.Email
+				},
+			}
+			cache := New(config)
+
+			cache.Set("1", user{ID: "1", Email: "one@example.com"})
+			cache.Set("2", user{ID: "2", Email: "two@example.com"})
+			assert.Equal("1", cache.GetByIndex("one@example.com").Key)
+			assert.Nil(cache.GetByIndex("three@example.com"))
+
+			cache.Update("2", func(value *user) {
+				value.Email = "three@example.com"
+			})
+			assert.Nil(cache.GetByIndex("two@example.com"))
+			assert.Equal("2", cache.GetByIndex("three@example.com").Key)
+
+			cache.Set("4", user{ID: "4", Email: "four@example.com"})
+			assert.Nil(cache.GetByIndex("one@example.com"))
+			cache.Delete("2")
+			assert.Nil(cache.GetByIndex("three@example.com"))
+			assert.Equal(1, len(cache.secondary))
+
+			if policy == LRI {
+				cache.Set("4", user{ID: "4", Email: "five@example.com"})
+				assert.Nil(cache.GetByIndex("four@example.com"))
+				assert.Equal("4", cache.GetByIndex("five@example.com").Key)
+			}
+
+			cache.Clear()
+			assert.Nil(cache.GetByIndex("five@example.com"))
+			assert.Nil(New(Config[string, user]{MaxSize: 2, TTL: time.Minute}).GetByIndex("one@example.com"))
+		}
+	}
+}
+
+func TestLRUCacheIndexFuncPanic(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var panics []string
+		config := Config[string, user]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			IndexFunc: func(value user) any {
+				if value.Email == "" {
+					panic("missing email")
+				}
+				return value.Email
+			},
+			OnPanic: func(err *PanicError) {
+				panics = append(panics, err.Callback)
+			},
+		}
+		cache := New(config)
+
+		assert.NoError(cache.Set("1", user{ID: "1"}))
+		assert.Equal([]string{"IndexFunc"}, panics)
+		assert.Equal("1", cache.Get("1").Key)
+		assert.Equal(0, len(cache.secondary))
+
+		// The lock of the cache has been released
+		cache.Set("2", user{ID: "2", Email: "two@example.com"})
+		assert.Equal("2", cache.GetByIndex("two@example.com").Key)
+		cache.Delete("1")
+		assert.Equal(1, cache.Len())
+	}
+}
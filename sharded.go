@@ -0,0 +1,317 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"math/rand"
+	"runtime"
+	"sort"
+	"time"
+)
+
+var _ Cache[string, any] = (*Sharded[string, any])(nil)
+
+// Sharded is a cache which partitions its keys across independently locked TLRU shards
+// so that writes of keys of different shards don't contend for the same lock
+// Every shard holds at most 1/numShards of Config.MaxSize entries and evicts them on its
+// own, so the least recently used entry is evicted per shard rather than across the cache
+type Sharded[K comparable, V any] struct {
+	shards []*TLRU[K, V]
+	seed   maphash.Seed
+}
+
+// NewSharded returns a new Sharded cache with the provided number of shards which are
+// created via New with the provided config. If numShards isn't positive it defaults to
+// GOMAXPROCS. The EvictionChannel and the OperationChannel are shared by all shards
+func NewSharded[K comparable, V any](config Config[K, V], numShards int) *Sharded[K, V] {
+	if numShards <= 0 {
+		numShards = runtime.GOMAXPROCS(0)
+	}
+
+	shardConfig := config
+	if config.MaxSize > 0 {
+		shardConfig.MaxSize = (config.MaxSize + numShards - 1) / numShards
+	}
+	if config.SoftMaxSize > 0 {
+		shardConfig.SoftMaxSize = (config.SoftMaxSize + numShards - 1) / numShards
+	}
+
+	sharded := &Sharded[K, V]{
+		shards: make([]*TLRU[K, V], numShards),
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range sharded.shards {
+		// A rand.Source isn't safe for concurrent use so every shard gets its own one
+		if config.RandSource != nil {
+			shardConfig.RandSource = rand.NewSource(config.RandSource.Int63())
+		}
+		sharded.shards[i] = New(shardConfig)
+	}
+
+	return sharded
+}
+
+// Shard returns the shard which holds the provided key
+func (s *Sharded[K, V]) Shard(key K) *TLRU[K, V] {
+	return s.shards[s.hash(key)%uint64(len(s.shards))]
+}
+
+// Shards returns all shards of the cache
+func (s *Sharded[K, V]) Shards() []*TLRU[K, V] {
+	return append([]*TLRU[K, V]{}, s.shards...)
+}
+
+// Get retrieves an entry from the shard of the provided key. See TLRU.Get
+func (s *Sharded[K, V]) Get(key K) *CacheEntry[K, V] {
+	return s.Shard(key).Get(key)
+}
+
+// Peek retrieves an entry from the shard of the provided key without using it. See TLRU.Peek
+func (s *Sharded[K, V]) Peek(key K) *CacheEntry[K, V] {
+	return s.Shard(key).Peek(key)
+}
+
+// Has returns true if the provided key exists in its shard. See TLRU.Has
+func (s *Sharded[K, V]) Has(key K) bool {
+	return s.Shard(key).Has(key)
+}
+
+// Set inserts/updates an entry in the shard of the provided key. See TLRU.Set
+func (s *Sharded[K, V]) Set(key K, value V) error {
+	return s.Shard(key).Set(key, value)
+}
+
+// SetWithTimestamp inserts/updates an entry with the provided timestamp in the shard
+// of the provided key. See TLRU.SetWithTimestamp
+func (s *Sharded[K, V]) SetWithTimestamp(key K, value V, timestamp time.Time) error {
+	return s.Shard(key).SetWithTimestamp(key, value, timestamp)
+}
+
+// SetWithTTL inserts/updates an entry with its own ttl in the shard of the provided key
+// See TLRU.SetWithTTL
+func (s *Sharded[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	return s.Shard(key).SetWithTTL(key, value, ttl)
+}
+
+// GetOrCompute returns the value of the provided key from its shard or loads it. See TLRU.GetOrCompute
+func (s *Sharded[K, V]) GetOrCompute(key K, loader func(key K) (V, error)) (V, error) {
+	return s.Shard(key).GetOrCompute(key, loader)
+}
+
+// GetOrComputeWithContext returns the value of the provided key from its shard or loads it
+// See TLRU.GetOrComputeWithContext
+func (s *Sharded[K, V]) GetOrComputeWithContext(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	return s.Shard(key).GetOrComputeWithContext(ctx, key, loader)
+}
+
+// Delete removes the entry of the provided key from its shard. See TLRU.Delete
+func (s *Sharded[K, V]) Delete(key K) {
+	s.Shard(key).Delete(key)
+}
+
+// Len returns the number of entries in all shards
+func (s *Sharded[K, V]) Len() int {
+	length := 0
+	for _, shard := range s.shards {
+		length += shard.Len()
+	}
+
+	return length
+}
+
+// Keys returns an unordered slice of the keys of all shards. See TLRU.Keys
+func (s *Sharded[K, V]) Keys() []K {
+	keys := make([]K, 0)
+	for _, shard := range s.shards {
+		keys = shard.AppendKeys(keys)
+	}
+
+	return keys
+}
+
+// Entries returns an unordered slice of the entries of all shards. See TLRU.Entries
+func (s *Sharded[K, V]) Entries() []CacheEntry[K, V] {
+	entries := make([]CacheEntry[K, V], 0)
+	for _, shard := range s.shards {
+		entries = shard.AppendEntries(entries)
+	}
+
+	return entries
+}
+
+// Clear removes all entries from all shards. See TLRU.Clear
+func (s *Sharded[K, V]) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+// Stats returns the sum of the Stats of all shards. The ratios are computed from the sums
+// and the latency percentiles are the highest ones among the shards
+func (s *Sharded[K, V]) Stats() Stats {
+	var stats Stats
+	for _, shard := range s.shards {
+		shardStats := shard.Stats()
+		stats.GetHits += shardStats.GetHits
+		stats.GetMisses += shardStats.GetMisses
+		stats.Sets += shardStats.Sets
+		stats.Deletes += shardStats.Deletes
+		stats.Expirations += shardStats.Expirations
+		stats.Drops += shardStats.Drops
+		stats.Invalidations += shardStats.Invalidations
+		stats.BudgetExceeded += shardStats.BudgetExceeded
+		stats.DroppedEvictionNotifications += shardStats.DroppedEvictionNotifications
+		stats.PendingEvictionNotifications += shardStats.PendingEvictionNotifications
+		stats.Hits += shardStats.Hits
+		stats.Misses += shardStats.Misses
+		stats.HitLatency = maxLatency(stats.HitLatency, shardStats.HitLatency)
+		stats.MissLatency = maxLatency(stats.MissLatency, shardStats.MissLatency)
+		stats.Insertions += shardStats.Insertions
+		stats.Readmissions += shardStats.Readmissions
+		stats.DistinctKeys += shardStats.DistinctKeys
+		stats.CoalescedWrites += shardStats.CoalescedWrites
+		stats.GarbageCollections += shardStats.GarbageCollections
+		stats.GarbageCollectionTime += shardStats.GarbageCollectionTime
+	}
+	if lookups := stats.GetHits + stats.GetMisses; lookups > 0 {
+		stats.HitRatio = float64(stats.GetHits) / float64(lookups)
+	}
+	if stats.Insertions > 0 {
+		stats.ChurnRate = float64(stats.Readmissions) / float64(stats.Insertions)
+	}
+
+	return stats
+}
+
+// GetState returns the State of all shards whose entries are ordered from the most
+// to the least recently used one across the shards. The State holds the number of shards
+// and every entry holds the index of its shard. See TLRU.GetState
+func (s *Sharded[K, V]) GetState() State[K, V] {
+	return mergeStates(s.ShardStates())
+}
+
+// ShardStates returns the State of every shard in the order of Shards
+// The State of every shard holds the number of shards and every entry holds the index of its shard
+func (s *Sharded[K, V]) ShardStates() []State[K, V] {
+	states := make([]State[K, V], len(s.shards))
+	for i, shard := range s.shards {
+		states[i] = withShard(shard.GetState(), i, len(s.shards))
+	}
+
+	return states
+}
+
+// SetState partitions the entries of the provided State across the shards and
+// replaces the entries of every shard with its part. See TLRU.SetState
+// The State may have been exported from a TLRU cache or a Sharded cache with any number
+// of shards since the keys are rehashed. The shard assignment of the State is ignored as
+// every Sharded cache hashes its keys with its own seed
+func (s *Sharded[K, V]) SetState(state State[K, V]) error {
+	states := make([]State[K, V], len(s.shards))
+	for i := range states {
+		states[i] = State[K, V]{EvictionPolicy: state.EvictionPolicy, ExtractedAt: state.ExtractedAt}
+	}
+	for _, entry := range state.Entries {
+		i := s.hash(entry.Key) % uint64(len(s.shards))
+		entry.Shard = 0
+		states[i].Entries = append(states[i].Entries, entry)
+	}
+
+	for i, shard := range s.shards {
+		if err := shard.SetState(states[i]); err != nil {
+			return fmt.Errorf("tlru.Sharded.SetState: Shard %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// CloseAndExport closes all shards and returns their merged final State. See TLRU.CloseAndExport
+func (s *Sharded[K, V]) CloseAndExport() (State[K, V], error) {
+	states := make([]State[K, V], len(s.shards))
+	for i, shard := range s.shards {
+		state, err := shard.CloseAndExport()
+		if err != nil {
+			return State[K, V]{}, fmt.Errorf("tlru.Sharded.CloseAndExport: Shard %d: %w", i, err)
+		}
+		states[i] = withShard(state, i, len(s.shards))
+	}
+
+	return mergeStates(states), nil
+}
+
+// withShard sets the shard assignment of the provided State of a shard
+func withShard[K comparable, V any](state State[K, V], shard int, numShards int) State[K, V] {
+	state.Shards = numShards
+	for i := range state.Entries {
+		state.Entries[i].Shard = shard
+	}
+
+	return state
+}
+
+// hash returns the hash of the provided key. Strings and integers are hashed directly
+// while other keys are hashed via their fmt representation
+func (s *Sharded[K, V]) hash(key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(s.seed)
+	switch k := any(key).(type) {
+	case string:
+		h.WriteString(k)
+	case int:
+		writeUint64(&h, uint64(k))
+	case int64:
+		writeUint64(&h, uint64(k))
+	case int32:
+		writeUint64(&h, uint64(k))
+	case uint:
+		writeUint64(&h, uint64(k))
+	case uint64:
+		writeUint64(&h, k)
+	case uint32:
+		writeUint64(&h, uint64(k))
+	default:
+		fmt.Fprintf(&h, "%#v", key)
+	}
+
+	return h.Sum64()
+}
+
+func writeUint64(h *maphash.Hash, x uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	h.Write(b[:])
+}
+
+// mergeStates returns a State with the entries of the provided States ordered
+// from the most to the least recently used one
+func mergeStates[K comparable, V any](states []State[K, V]) State[K, V] {
+	merged := State[K, V]{Entries: make([]StateEntry[K, V], 0), ExtractedAt: time.Now().UTC()}
+	for _, state := range states {
+		merged.EvictionPolicy = state.EvictionPolicy
+		merged.Shards = state.Shards
+		merged.Entries = append(merged.Entries, state.Entries...)
+	}
+	sort.SliceStable(merged.Entries, func(i, j int) bool {
+		return merged.Entries[i].LastUsedAt.After(merged.Entries[j].LastUsedAt)
+	})
+
+	return merged
+}
+
+func maxLatency(a LatencyStats, b LatencyStats) LatencyStats {
+	if b.P50 > a.P50 {
+		a.P50 = b.P50
+	}
+	if b.P99 > a.P99 {
+		a.P99 = b.P99
+	}
+
+	return a
+}
@@ -0,0 +1,145 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedCache(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		// Every shard has enough headroom for all keys since the hash seed is random
+		config := Config[string, int]{
+			MaxSize:        800,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := NewSharded(config, 4)
+		assert.Equal(4, len(cache.Shards()))
+		assert.Equal(200, cache.Shards()[0].Config().MaxSize)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					cache.Set(fmt.Sprintf("key-%d-%d", i, j), i*10+j)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(20, cache.Len())
+		assert.Equal(20, len(cache.Keys()))
+		assert.Equal(20, len(cache.Entries()))
+		assert.Equal(32, cache.Get("key-3-2").Value)
+		assert.True(cache.Has("key-3-2"))
+		assert.Nil(cache.Get("missing"))
+		assert.True(cache.Shard("key-3-2").Has("key-3-2"))
+
+		cache.Delete("key-3-2")
+		assert.Nil(cache.Peek("key-3-2"))
+		stats := cache.Stats()
+		assert.Equal(uint64(20), stats.Sets)
+		assert.Equal(uint64(1), stats.GetHits)
+		assert.Equal(uint64(1), stats.GetMisses)
+		assert.Equal(uint64(1), stats.Deletes)
+		assert.Equal(0.5, stats.HitRatio)
+
+		state := cache.GetState()
+		assert.Equal(19, len(state.Entries))
+		assert.True(sort.SliceIsSorted(state.Entries, func(i, j int) bool {
+			return state.Entries[i].LastUsedAt.After(state.Entries[j].LastUsedAt)
+		}))
+
+		restored := NewSharded(config, 3)
+		assert.NoError(restored.SetState(state))
+		assert.Equal(19, restored.Len())
+		assert.Equal(21, restored.Get("key-2-1").Value)
+
+		exported, err := restored.CloseAndExport()
+		assert.NoError(err)
+		assert.Equal(19, len(exported.Entries))
+		assert.True(errors.Is(restored.Set(entry1.Key, entry1.Value), ErrClosed))
+	}
+}
+
+func TestShardedCacheState(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[int, int]{
+			MaxSize:        800,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := NewSharded(config, 4)
+		for i := 0; i < 40; i++ {
+			assert.NoError(cache.Set(i, i))
+		}
+
+		shardStates := cache.ShardStates()
+		assert.Equal(4, len(shardStates))
+		for i, shardState := range shardStates {
+			assert.Equal(4, shardState.Shards)
+			assert.Equal(cache.Shards()[i].Len(), len(shardState.Entries))
+			for _, entry := range shardState.Entries {
+				assert.Equal(i, entry.Shard)
+				assert.True(cache.Shards()[i].Has(entry.Key))
+			}
+		}
+
+		state := cache.GetState()
+		assert.Equal(4, state.Shards)
+		assert.Equal(40, len(state.Entries))
+		for _, entry := range state.Entries {
+			assert.True(cache.Shards()[entry.Shard].Has(entry.Key))
+		}
+
+		for _, numShards := range []int{1, 3, 8} {
+			restored := NewSharded(config, numShards)
+			assert.NoError(restored.SetState(state))
+			assert.Equal(40, restored.Len())
+			restoredState := restored.GetState()
+			assert.Equal(numShards, restoredState.Shards)
+			for _, entry := range restoredState.Entries {
+				assert.Equal(entry.Key, entry.Value)
+				assert.True(restored.Shards()[entry.Shard].Has(entry.Key))
+			}
+		}
+
+		single := New(config)
+		assert.NoError(single.SetState(state))
+		assert.Equal(40, single.Len())
+		assert.Equal(0, single.GetState().Shards)
+	}
+}
+
+func TestShardedCacheHash(t *testing.T) {
+	assert := assert.New(t)
+	type compositeKey struct {
+		tenant string
+		id     int
+	}
+	cache := NewSharded(Config[compositeKey, int]{MaxSize: 10, TTL: time.Minute}, 8)
+	key := compositeKey{tenant: "a", id: 1}
+	assert.Equal(cache.hash(key), cache.hash(compositeKey{tenant: "a", id: 1}))
+	assert.NoError(cache.Set(key, 1))
+	assert.Equal(1, cache.Get(key).Value)
+
+	distinct := map[*TLRU[int, int]]struct{}{}
+	ints := NewSharded(Config[int, int]{TTL: time.Minute}, 4)
+	for i := 0; i < 100; i++ {
+		distinct[ints.Shard(i)] = struct{}{}
+	}
+	assert.Equal(4, len(distinct))
+}
@@ -0,0 +1,56 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"time"
+)
+
+// simulationTTL makes sure that no entry expires while a trace is replayed
+const simulationTTL = 100 * 365 * 24 * time.Hour
+
+// SimResult is the outcome of replaying a key trace against a Config
+type SimResult struct {
+	EvictionPolicy evictionPolicy `json:"eviction_policy"`
+	MaxSize        int            `json:"max_size"`
+	Hits           int            `json:"hits"`
+	Misses         int            `json:"misses"`
+	// Hits divided by the length of the trace
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Simulate replays the provided key trace against an in-memory cache per Config and
+// reports the hit ratio of each one, so that EvictionPolicies and sizes can be compared
+// with real data. Every key of the trace is looked up and inserted on a miss
+// The trace carries no timing information so the TTL of the configs is ignored and
+// entries never expire. Channels and callbacks of the configs are not used
+func Simulate[K comparable](trace []K, configs []Config[K, struct{}]) []SimResult {
+	results := make([]SimResult, 0, len(configs))
+	for _, config := range configs {
+		cache := New(Config[K, struct{}]{
+			MaxSize:        config.MaxSize,
+			TTL:            simulationTTL,
+			EvictionPolicy: config.EvictionPolicy,
+		})
+
+		result := SimResult{EvictionPolicy: config.EvictionPolicy, MaxSize: config.MaxSize}
+		for _, key := range trace {
+			if cache.Get(key) != nil {
+				result.Hits++
+				continue
+			}
+			result.Misses++
+			cache.Set(key, struct{}{})
+		}
+		if len(trace) > 0 {
+			result.HitRatio = float64(result.Hits) / float64(len(trace))
+		}
+		cache.Clear()
+
+		results = append(results, result)
+	}
+
+	return results
+}
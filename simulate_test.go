@@ -0,0 +1,29 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	assert := assert.New(t)
+	trace := []string{"a", "b", "a", "c", "a", "d", "b", "a"}
+	configs := []Config[string, struct{}]{
+		{MaxSize: 2, EvictionPolicy: LRA},
+		{MaxSize: 2, EvictionPolicy: LRI},
+		{MaxSize: 10, EvictionPolicy: LRA},
+	}
+
+	results := Simulate(trace, configs)
+	assert.Equal([]SimResult{
+		{EvictionPolicy: LRA, MaxSize: 2, Hits: 2, Misses: 6, HitRatio: 2.0 / 8},
+		{EvictionPolicy: LRI, MaxSize: 2, Hits: 1, Misses: 7, HitRatio: 1.0 / 8},
+		{EvictionPolicy: LRA, MaxSize: 10, Hits: 4, Misses: 4, HitRatio: 4.0 / 8},
+	}, results)
+
+	assert.Equal([]SimResult{{EvictionPolicy: LRA, MaxSize: 2}}, Simulate(nil, configs[:1]))
+}
@@ -0,0 +1,98 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3/internal/batch"
+)
+
+// errSinkPanicked is the error of a WriteBatch which panicked
+var errSinkPanicked = errors.New("EvictionSink panicked")
+
+// EvictionSink persists evicted entries to an external system such as a database or a queue
+type EvictionSink[K comparable, V any] interface {
+	// WriteBatch persists the provided entries. The slice is not reused after WriteBatch returns
+	WriteBatch(evictedEntries []EvictedEntry[K, V]) error
+}
+
+// SinkOptions configure the batching and the retries of DrainEvictionsToSink
+type SinkOptions[K comparable, V any] struct {
+	// Number of evicted entries after which a batch is written. If not set it defaults to 100
+	BatchSize int
+	// Max time an evicted entry waits in a batch before the batch is written.
+	// If not set it defaults to 1 second
+	FlushInterval time.Duration
+	// Number of retries of a failed WriteBatch. If not set it defaults to 3.
+	// A negative value disables retries
+	MaxRetries int
+	// Backoff before the first retry which doubles on every subsequent retry.
+	// If not set it defaults to 100 milliseconds
+	RetryBackoff time.Duration
+	// Optional callback which is invoked with the error of the last attempt and
+	// the batches that couldn't be written after all retries
+	OnError func(err error, evictedEntries []EvictedEntry[K, V])
+}
+
+// DrainEvictionsToSink consumes the EvictionChannel of the provided cache in a new goroutine
+// and writes the evicted entries to the provided EvictionSink in batches. A batch is written
+// once it holds SinkOptions.BatchSize entries or SinkOptions.FlushInterval has elapsed since
+// its first entry. Failed writes are retried with an exponential backoff and a panic of the
+// EvictionSink is reported to Config.OnPanic and handled as a failure
+// Draining stops when the context is done or the EvictionChannel is closed. The pending batch
+// is then written and its retries stop once the context is done. The returned channel is
+// closed once draining has stopped
+// If the cache has no EvictionChannel the returned channel is already closed
+func DrainEvictionsToSink[K comparable, V any](ctx context.Context, cache *TLRU[K, V], sink EvictionSink[K, V], options SinkOptions[K, V]) <-chan struct{} {
+	done := make(chan struct{})
+	if cache.config.EvictionChannel == nil {
+		close(done)
+		return done
+	}
+
+	batcher := batch.New(batch.Config[EvictedEntry[K, V]]{
+		BatchSize:     options.BatchSize,
+		FlushInterval: options.FlushInterval,
+		MaxRetries:    options.MaxRetries,
+		RetryBackoff:  options.RetryBackoff,
+		Write: func(ctx context.Context, evictedEntries []EvictedEntry[K, V]) error {
+			err := errSinkPanicked
+			cache.protect("EvictionSink", func() {
+				err = sink.WriteBatch(evictedEntries)
+			})
+			return err
+		},
+		OnError: func(err error, evictedEntries []EvictedEntry[K, V]) {
+			if options.OnError != nil {
+				cache.protect("OnError", func() {
+					options.OnError(err, evictedEntries)
+				})
+			}
+		},
+	})
+
+	evictionChannel := *cache.config.EvictionChannel
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case evictedEntry, ok := <-evictionChannel:
+				if !ok {
+					batcher.Close(ctx)
+					return
+				}
+				batcher.Add(ctx, evictedEntry)
+			case <-ctx.Done():
+				batcher.Close(ctx)
+				return
+			}
+		}
+	}()
+
+	return done
+}
@@ -0,0 +1,99 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSink struct {
+	sync.Mutex
+	failures int
+	calls    int
+	batches  [][]string
+}
+
+func (s *mockSink) WriteBatch(evictedEntries []EvictedEntry[string, int]) error {
+	defer s.Unlock()
+	s.Lock()
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	keys := make([]string, 0, len(evictedEntries))
+	for _, evictedEntry := range evictedEntries {
+		keys = append(keys, evictedEntry.Key)
+	}
+	s.batches = append(s.batches, keys)
+
+	return nil
+}
+
+func (s *mockSink) written() [][]string {
+	defer s.Unlock()
+	s.Lock()
+
+	return append([][]string(nil), s.batches...)
+}
+
+func TestDrainEvictionsToSink(t *testing.T) {
+	assert := assert.New(t)
+	evictionChannel := make(chan EvictedEntry[string, int])
+	cache := New(Config[string, int]{MaxSize: 1, TTL: time.Minute, EvictionChannel: &evictionChannel})
+	sink := &mockSink{failures: 1}
+	done := DrainEvictionsToSink[string, int](context.Background(), cache, sink, SinkOptions[string, int]{
+		BatchSize:     2,
+		FlushInterval: 20 * time.Millisecond,
+		RetryBackoff:  time.Millisecond,
+	})
+
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+	cache.Set(entry3.Key, entry3.Value)
+	assert.Eventually(func() bool { return len(sink.written()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal([][]string{{entry1.Key, entry2.Key}}, sink.written())
+
+	cache.Set(entry4.Key, entry4.Value)
+	assert.Eventually(func() bool { return len(sink.written()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal([]string{entry3.Key}, sink.written()[1])
+
+	cache.Delete(entry4.Key)
+	close(evictionChannel)
+	<-done
+	assert.Equal([]string{entry4.Key}, sink.written()[2])
+	assert.Equal(4, sink.calls)
+}
+
+func TestDrainEvictionsToSinkOnError(t *testing.T) {
+	assert := assert.New(t)
+	evictionChannel := make(chan EvictedEntry[string, int])
+	cache := New(Config[string, int]{MaxSize: 1, TTL: time.Minute, EvictionChannel: &evictionChannel})
+	sink := &mockSink{failures: 10}
+
+	var failed []EvictedEntry[string, int]
+	var failure error
+	done := DrainEvictionsToSink[string, int](context.Background(), cache, sink, SinkOptions[string, int]{
+		BatchSize:    1,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnError: func(err error, evictedEntries []EvictedEntry[string, int]) {
+			failed, failure = evictedEntries, err
+		},
+	})
+
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+	close(evictionChannel)
+	<-done
+	assert.Equal(3, sink.calls)
+	assert.Equal(entry1.Key, failed[0].Key)
+	assert.Error(failure)
+}
@@ -0,0 +1,108 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// snapshotVersion is the version of the format written by State.WriteTo
+	snapshotVersion = 1
+	// maxSnapshotPreallocation bounds the number of entries ReadStateFrom allocates
+	// upfront so that a corrupted header can't exhaust the memory
+	maxSnapshotPreallocation = 1 << 20
+)
+
+// snapshotHeader precedes the entries of a State written by State.WriteTo
+type snapshotHeader struct {
+	Version        int
+	EvictionPolicy evictionPolicy
+	ExtractedAt    time.Time
+	Entries        int
+}
+
+// countingWriter counts the bytes written to the underlying io.Writer
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+// WriteTo implements io.WriterTo
+// It writes the State in a compact binary format, a header followed by every entry encoded
+// one at a time via encoding/gob, so K and V must be gob-encodable. Unlike json.Marshal the
+// encoding is streamed to w and never held in memory as a whole
+// The State can be read back via ReadStateFrom
+func (s State[K, V]) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{writer: w}
+	buffer := bufio.NewWriter(counter)
+	encoder := gob.NewEncoder(buffer)
+
+	header := snapshotHeader{
+		Version:        snapshotVersion,
+		EvictionPolicy: s.EvictionPolicy,
+		ExtractedAt:    s.ExtractedAt,
+		Entries:        len(s.Entries),
+	}
+	if err := encoder.Encode(header); err != nil {
+		return counter.count, fmt.Errorf("tlru.State.WriteTo: %w", err)
+	}
+	for i := range s.Entries {
+		if err := encoder.Encode(&s.Entries[i]); err != nil {
+			return counter.count, fmt.Errorf("tlru.State.WriteTo: Entry of key '%+v' can't be encoded: %w", s.Entries[i].Key, err)
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		return counter.count, fmt.Errorf("tlru.State.WriteTo: %w", err)
+	}
+
+	return counter.count, nil
+}
+
+// ReadStateFrom reads a State which has been written via State.WriteTo
+// Entries are decoded one at a time straight into the returned State
+// The reader may be read past the end of the State due to buffering
+func ReadStateFrom[K comparable, V any](r io.Reader) (State[K, V], error) {
+	var state State[K, V]
+	decoder := gob.NewDecoder(r)
+
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil {
+		return state, fmt.Errorf("tlru.ReadStateFrom: %w", err)
+	}
+	if header.Version != snapshotVersion {
+		return state, fmt.Errorf("tlru.ReadStateFrom: Unsupported snapshot version %d", header.Version)
+	}
+	if header.Entries < 0 {
+		return state, fmt.Errorf("tlru.ReadStateFrom: Invalid number of entries %d", header.Entries)
+	}
+
+	preallocation := header.Entries
+	if preallocation > maxSnapshotPreallocation {
+		preallocation = maxSnapshotPreallocation
+	}
+	state.EvictionPolicy = header.EvictionPolicy
+	state.ExtractedAt = header.ExtractedAt
+	state.Entries = make([]StateEntry[K, V], 0, preallocation)
+	for len(state.Entries) < header.Entries {
+		var stateEntry StateEntry[K, V]
+		if err := decoder.Decode(&stateEntry); err != nil {
+			return State[K, V]{}, fmt.Errorf("tlru.ReadStateFrom: Entry %d of %d can't be decoded: %w", len(state.Entries)+1, header.Entries, err)
+		}
+		state.Entries = append(state.Entries, stateEntry)
+	}
+
+	return state, nil
+}
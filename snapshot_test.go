@@ -0,0 +1,73 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheStateWriteTo(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		cache.SetMany([]Entry[string, int]{entry1, entry2, entry3})
+		cache.SetWithTTL(entry4.Key, entry4.Value, time.Hour)
+		state := cache.GetState()
+
+		var buf bytes.Buffer
+		n, err := state.WriteTo(&buf)
+		assert.NoError(err)
+		assert.Equal(int64(buf.Len()), n)
+
+		restoredState, err := ReadStateFrom[string, int](&buf)
+		assert.NoError(err)
+		assert.Equal(len(state.Entries), len(restoredState.Entries))
+		assert.Equal(state.EvictionPolicy, restoredState.EvictionPolicy)
+		assert.True(state.ExtractedAt.Equal(restoredState.ExtractedAt))
+		for i, stateEntry := range state.Entries {
+			restoredEntry := restoredState.Entries[i]
+			assert.Equal(stateEntry.Key, restoredEntry.Key)
+			assert.Equal(stateEntry.Value, restoredEntry.Value)
+			assert.Equal(stateEntry.Counter, restoredEntry.Counter)
+			assert.Equal(stateEntry.TTL, restoredEntry.TTL)
+			assert.True(stateEntry.LastUsedAt.Equal(restoredEntry.LastUsedAt))
+		}
+
+		restoredCache := New(config)
+		assert.NoError(restoredCache.SetState(restoredState))
+		assert.Equal(4, restoredCache.Len())
+
+		encoded, err := json.Marshal(state)
+		assert.NoError(err)
+		var written bytes.Buffer
+		state.WriteTo(&written)
+		assert.Less(written.Len(), len(encoded))
+	}
+}
+
+func TestLRUCacheReadStateFromErrors(t *testing.T) {
+	assert := assert.New(t)
+	cache := New(Config[string, int]{MaxSize: 10, TTL: time.Minute})
+	cache.SetMany([]Entry[string, int]{entry1, entry2})
+
+	var buf bytes.Buffer
+	cache.GetState().WriteTo(&buf)
+	_, err := ReadStateFrom[string, int](bytes.NewReader(buf.Bytes()[:buf.Len()-4]))
+	assert.Error(err)
+
+	_, err = ReadStateFrom[string, int](bytes.NewReader(nil))
+	assert.True(errors.Is(err, io.EOF))
+}
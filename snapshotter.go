@@ -0,0 +1,116 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Snapshotter persists the State of the cache so that it can be restored via
+// ReadStateFrom on the next start. See Config.Snapshotter
+type Snapshotter struct {
+	// Interval at which the State is persisted. If not set the State is only
+	// persisted by CloseAndExport and Snapshot
+	Interval time.Duration
+	// Target returns the destination of the next snapshot, e.g. a new file. The State is
+	// written to it via State.WriteTo and it is closed once the snapshot is complete.
+	// If the snapshot fails the target is closed, or aborted instead if it implements
+	// an Abort() error method, e.g. to discard a partially written file.
+	// Every shard of a Sharded cache writes its own snapshot
+	Target func() (io.WriteCloser, error)
+	// Optional callback which is invoked with the error of every failed periodic snapshot
+	OnError func(err error)
+}
+
+// Snapshot writes the State of the cache to a new target of Config.Snapshotter
+// Snapshots never run concurrently so the last one to complete holds the most recent State
+func (c *TLRU[K, V]) Snapshot() error {
+	c.RLock()
+	target := c.config.Snapshotter.Target
+	c.RUnlock()
+	if target == nil {
+		return fmt.Errorf("tlru.Snapshot: Config.Snapshotter.Target is not set")
+	}
+
+	defer c.snapshotMutex.Unlock()
+	c.snapshotMutex.Lock()
+
+	if err := writeSnapshot(target, c.GetState()); err != nil {
+		return fmt.Errorf("tlru.Snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// snapshotAborter is implemented by targets which can discard an incomplete snapshot
+type snapshotAborter interface {
+	Abort() error
+}
+
+// writeSnapshot writes the provided State to a new target and closes it
+func writeSnapshot[K comparable, V any](target func() (io.WriteCloser, error), state State[K, V]) error {
+	writer, err := target()
+	if err != nil {
+		return err
+	}
+	if _, err := state.WriteTo(writer); err != nil {
+		if aborter, ok := writer.(snapshotAborter); ok {
+			aborter.Abort()
+		} else {
+			writer.Close()
+		}
+		return err
+	}
+
+	return writer.Close()
+}
+
+// scheduleSnapshot must be called while holding the lock of the cache
+// Snapshots which have been scheduled before are not rescheduled anymore
+func (c *TLRU[K, V]) scheduleSnapshot() {
+	if c.snapshotTimer != nil {
+		c.snapshotTimer.Stop()
+		c.snapshotTimer = nil
+	}
+	c.snapshotGeneration++
+	if c.config.Snapshotter.Target == nil || c.config.Snapshotter.Interval <= 0 || c.closed {
+		return
+	}
+
+	generation := c.snapshotGeneration
+	c.snapshotTimer = time.AfterFunc(c.config.Snapshotter.Interval, func() {
+		c.periodicSnapshot(generation)
+	})
+}
+
+// periodicSnapshot persists the State of the cache and schedules the next snapshot
+// unless it has been rescheduled
+func (c *TLRU[K, V]) periodicSnapshot(generation uint64) {
+	c.RLock()
+	scheduled := generation == c.snapshotGeneration
+	onError := c.config.Snapshotter.OnError
+	c.RUnlock()
+	if !scheduled {
+		return
+	}
+
+	var err error
+	c.protect("Snapshotter", func() {
+		err = c.Snapshot()
+	})
+	if err != nil && onError != nil {
+		c.protect("Snapshotter.OnError", func() {
+			onError(err)
+		})
+	}
+
+	defer c.unlock()
+	c.Lock()
+	if generation == c.snapshotGeneration {
+		c.scheduleSnapshot()
+	}
+}
@@ -0,0 +1,106 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// snapshotBuffer is an io.WriteCloser which hands its content over once it is closed
+type snapshotBuffer struct {
+	bytes.Buffer
+	snapshots chan []byte
+}
+
+func (b *snapshotBuffer) Close() error {
+	b.snapshots <- b.Bytes()
+	return nil
+}
+
+func TestLRUCacheSnapshotter(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		snapshots := make(chan []byte, 100)
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			Snapshotter: Snapshotter{
+				Interval: 10 * time.Millisecond,
+				Target: func() (io.WriteCloser, error) {
+					return &snapshotBuffer{snapshots: snapshots}, nil
+				},
+			},
+		}
+		cache := New(config)
+		cache.SetMany([]Entry[string, int]{entry1, entry2})
+
+		var state State[string, int]
+		assert.Eventually(func() bool {
+			var err error
+			state, err = ReadStateFrom[string, int](bytes.NewReader(<-snapshots))
+			return err == nil && len(state.Entries) == 2
+		}, time.Second, time.Millisecond)
+
+		cache.Set(entry3.Key, entry3.Value)
+		_, err := cache.CloseAndExport()
+		assert.NoError(err)
+		assert.Equal(0, cache.Status().BackgroundTimers)
+		for len(snapshots) > 1 {
+			<-snapshots
+		}
+		state, err = ReadStateFrom[string, int](bytes.NewReader(<-snapshots))
+		assert.NoError(err)
+		assert.Equal(3, len(state.Entries))
+
+		restoredCache := New(Config[string, int]{MaxSize: 10, TTL: time.Minute, EvictionPolicy: policy})
+		assert.NoError(restoredCache.SetState(state))
+		assert.Equal(entry3.Value, restoredCache.Get(entry3.Key).Value)
+	}
+}
+
+func TestLRUCacheSnapshotterErrors(t *testing.T) {
+	assert := assert.New(t)
+	errUnavailable := errors.New("unavailable")
+	var mutex sync.Mutex
+	var errs []error
+	config := Config[string, int]{
+		MaxSize: 10,
+		TTL:     time.Minute,
+		Snapshotter: Snapshotter{
+			Interval: 10 * time.Millisecond,
+			Target: func() (io.WriteCloser, error) {
+				return nil, errUnavailable
+			},
+			OnError: func(err error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				errs = append(errs, err)
+			},
+		},
+	}
+	cache := New(config)
+	assert.Eventually(func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(errs) > 1
+	}, time.Second, time.Millisecond)
+	mutex.Lock()
+	assert.True(errors.Is(errs[0], errUnavailable))
+	mutex.Unlock()
+
+	assert.True(errors.Is(cache.Snapshot(), errUnavailable))
+	state, err := cache.CloseAndExport()
+	assert.True(errors.Is(err, errUnavailable))
+	assert.Equal(0, len(state.Entries))
+
+	assert.Error(New(Config[string, int]{MaxSize: 10}).Snapshot())
+}
@@ -0,0 +1,70 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"reflect"
+)
+
+// StateDiff describes the changes needed to turn one State into another.
+// It can be used to persist or replicate only the entries that changed
+// between two snapshots
+type StateDiff[K comparable, V any] struct {
+	// Entries that exist only in the newer State
+	Added []StateEntry[K, V] `json:"added"`
+	// Entries that exist only in the older State
+	Removed []StateEntry[K, V] `json:"removed"`
+	// Entries that exist in both States but differ. The newer StateEntry is kept
+	Updated []StateEntry[K, V] `json:"updated"`
+}
+
+// IsEmpty returns true if the StateDiff contains no changes
+func (d StateDiff[K, V]) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// DiffStates computes the added, removed and updated entries between
+// the older State a and the newer State b
+// The entries of each group keep the recency order of the State they originate from
+func DiffStates[K comparable, V any](a, b State[K, V]) StateDiff[K, V] {
+	diff := StateDiff[K, V]{
+		Added:   make([]StateEntry[K, V], 0),
+		Removed: make([]StateEntry[K, V], 0),
+		Updated: make([]StateEntry[K, V], 0),
+	}
+
+	previousEntries := make(map[K]StateEntry[K, V], len(a.Entries))
+	for _, entry := range a.Entries {
+		previousEntries[entry.Key] = entry
+	}
+
+	currentKeys := make(map[K]struct{}, len(b.Entries))
+	for _, entry := range b.Entries {
+		currentKeys[entry.Key] = struct{}{}
+		previousEntry, exists := previousEntries[entry.Key]
+		if !exists {
+			diff.Added = append(diff.Added, entry)
+		} else if !previousEntry.equal(entry) {
+			diff.Updated = append(diff.Updated, entry)
+		}
+	}
+
+	for _, entry := range a.Entries {
+		if _, exists := currentKeys[entry.Key]; !exists {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+
+	return diff
+}
+
+func (e StateEntry[K, V]) equal(other StateEntry[K, V]) bool {
+	return e.Counter == other.Counter &&
+		e.LastUsedAt.Equal(other.LastUsedAt) &&
+		e.CreatedAt.Equal(other.CreatedAt) &&
+		e.TTL == other.TTL &&
+		reflect.DeepEqual(e.Value, other.Value) &&
+		reflect.DeepEqual(e.Metadata, other.Metadata)
+}
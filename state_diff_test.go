@@ -0,0 +1,60 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffStates(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		previousState := cache.GetState()
+
+		cache.Delete(entry1.Key)
+		cache.Get(entry2.Key)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry4.Key, entry4.Value)
+		currentState := cache.GetState()
+
+		diff := DiffStates(previousState, currentState)
+
+		assert.Equal(1, len(diff.Added))
+		assert.Equal(entry4.Key, diff.Added[0].Key)
+		assert.Equal(1, len(diff.Removed))
+		assert.Equal(entry1.Key, diff.Removed[0].Key)
+		assert.Equal(1, len(diff.Updated))
+		assert.Equal(entry2.Key, diff.Updated[0].Key)
+		assert.False(diff.IsEmpty())
+	}
+}
+
+func TestDiffStatesWithIdenticalStates(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize: 10,
+		TTL:     time.Minute,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+
+	state := cache.GetState()
+	diff := DiffStates(state, state)
+
+	assert.True(diff.IsEmpty())
+}
@@ -0,0 +1,187 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencySamples is the number of most recent latencies the percentiles are computed from
+const latencySamples = 1024
+
+// Stats holds statistics about the operations of the cache and the lookups via
+// GetOrCompute and GetOrComputeWithContext
+type Stats struct {
+	// Number of calls of Get which found a non-expired entry
+	GetHits uint64 `json:"get_hits"`
+	// Number of calls of Get which didn't find a non-expired entry
+	GetMisses uint64 `json:"get_misses"`
+	// Ratio of GetHits to all calls of Get
+	HitRatio float64 `json:"hit_ratio"`
+	// Number of entries which have been inserted or updated
+	Sets uint64 `json:"sets"`
+	// Number of entries which have been removed via Delete and its variants
+	Deletes uint64 `json:"deletes"`
+	// Number of entries which have been evicted because they expired
+	Expirations uint64 `json:"expirations"`
+	// Number of entries which have been dropped to make room for others
+	Drops uint64 `json:"drops"`
+	// Number of entries which have been evicted because Config.Validator rejected them
+	Invalidations uint64 `json:"invalidations"`
+	// Number of calls of Get which exceeded Config.GetLatencyBudget while waiting for the lock
+	BudgetExceeded uint64 `json:"budget_exceeded"`
+	// Number of EvictedEntries which have been discarded in the EvictionDeliveryDropNotification policy
+	DroppedEvictionNotifications uint64 `json:"dropped_eviction_notifications"`
+	// Number of EvictedEntries which are queued in the EvictionDeliveryBufferUnbounded policy
+	PendingEvictionNotifications int `json:"pending_eviction_notifications"`
+	// Number of lookups which have been served from the cache
+	Hits uint64 `json:"hits"`
+	// Number of lookups which have been resolved by the loader
+	Misses uint64 `json:"misses"`
+	// Latency of the most recent hits
+	HitLatency LatencyStats `json:"hit_latency"`
+	// Latency of the most recent misses including the time spent in the loader
+	MissLatency LatencyStats `json:"miss_latency"`
+	// Number of keys which have been inserted while absent from the cache.
+	// It is only tracked if Config.TrackChurn is set
+	Insertions uint64 `json:"insertions"`
+	// Number of insertions of keys which had recently been dropped or expired.
+	// It is only tracked if Config.TrackChurn is set
+	Readmissions uint64 `json:"readmissions"`
+	// Estimate of the distinct keys which passed through the cache, i.e. the Insertions
+	// which weren't Readmissions. Keys evicted long enough ago are counted again
+	DistinctKeys uint64 `json:"distinct_keys"`
+	// Ratio of Readmissions to Insertions. A high ratio signals that MaxSize or TTL is too small
+	ChurnRate float64 `json:"churn_rate"`
+	// Number of writes which have been coalesced due to Config.CoalesceWindow
+	CoalescedWrites uint64 `json:"coalesced_writes"`
+	// Number of garbage collection passes which evicted the expired entries
+	GarbageCollections uint64 `json:"garbage_collections"`
+	// Total time spent in garbage collection passes while holding the lock of the cache
+	GarbageCollectionTime time.Duration `json:"garbage_collection_time"`
+}
+
+// LatencyStats holds latency percentiles
+type LatencyStats struct {
+	P50 time.Duration `json:"p50"`
+	P99 time.Duration `json:"p99"`
+}
+
+// Stats returns the operation counters of the cache and the hit and miss statistics of
+// GetOrCompute and GetOrComputeWithContext along with the churn statistics if Config.TrackChurn
+// is set, the number of coalesced writes and the garbage collection passes
+// Lookups of GetOrCompute which fail or return a stale value are not counted as Hits or Misses
+// The percentiles are computed from the 1024 most recent lookups of each kind
+func (c *TLRU[K, V]) Stats() Stats {
+	hits, hitLatency := c.hitLatencies.snapshot()
+	misses, missLatency := c.missLatencies.snapshot()
+	stats := Stats{
+		GetHits:                      atomic.LoadUint64(&c.operations.getHits),
+		GetMisses:                    atomic.LoadUint64(&c.operations.getMisses),
+		Sets:                         atomic.LoadUint64(&c.operations.sets),
+		Deletes:                      atomic.LoadUint64(&c.operations.deletes),
+		Expirations:                  atomic.LoadUint64(&c.operations.expirations),
+		Drops:                        atomic.LoadUint64(&c.operations.drops),
+		Invalidations:                atomic.LoadUint64(&c.operations.invalidations),
+		BudgetExceeded:               atomic.LoadUint64(&c.operations.budgetExceeded),
+		DroppedEvictionNotifications: atomic.LoadUint64(&c.operations.droppedNotifications),
+		PendingEvictionNotifications: c.overflow.pending(),
+		Hits:                         hits,
+		Misses:                       misses,
+		HitLatency:                   hitLatency,
+		MissLatency:                  missLatency,
+	}
+	if lookups := stats.GetHits + stats.GetMisses; lookups > 0 {
+		stats.HitRatio = float64(stats.GetHits) / float64(lookups)
+	}
+
+	c.RLock()
+	stats.CoalescedWrites = c.coalescedWrites
+	stats.GarbageCollections = c.garbageCollections
+	stats.GarbageCollectionTime = c.garbageCollectionTime
+	if c.churn != nil && c.config.TrackChurn {
+		stats.Insertions = c.churn.insertions
+		stats.Readmissions = c.churn.readmissions
+	}
+	c.RUnlock()
+	if stats.Insertions > 0 {
+		stats.DistinctKeys = stats.Insertions - stats.Readmissions
+		stats.ChurnRate = float64(stats.Readmissions) / float64(stats.Insertions)
+	}
+
+	return stats
+}
+
+// operationCounters counts the operations of the cache. Its fields are accessed atomically
+type operationCounters struct {
+	getHits        uint64
+	getMisses      uint64
+	sets           uint64
+	deletes        uint64
+	expirations    uint64
+	drops          uint64
+	invalidations  uint64
+	budgetExceeded uint64
+	// droppedNotifications counts the EvictedEntries discarded in the
+	// EvictionDeliveryDropNotification policy
+	droppedNotifications uint64
+}
+
+func (o *operationCounters) evicted(reason evictionReason) {
+	switch reason {
+	case EvictionReasonDeleted:
+		atomic.AddUint64(&o.deletes, 1)
+	case EvictionReasonExpired:
+		atomic.AddUint64(&o.expirations, 1)
+	case EvictionReasonDropped:
+		atomic.AddUint64(&o.drops, 1)
+	case EvictionReasonInvalidated:
+		atomic.AddUint64(&o.invalidations, 1)
+	}
+}
+
+// latencyRecorder keeps the most recent latencies in a ring buffer which is
+// allocated on the first record
+type latencyRecorder struct {
+	mutex   sync.Mutex
+	count   uint64
+	samples []time.Duration
+}
+
+func (r *latencyRecorder) record(latency time.Duration) {
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+
+	if r.samples == nil {
+		r.samples = make([]time.Duration, latencySamples)
+	}
+	r.samples[r.count%latencySamples] = latency
+	r.count++
+}
+
+func (r *latencyRecorder) snapshot() (uint64, LatencyStats) {
+	r.mutex.Lock()
+	count := r.count
+	samples := make([]time.Duration, 0, latencySamples)
+	if count < latencySamples {
+		samples = append(samples, r.samples[:count]...)
+	} else {
+		samples = append(samples, r.samples...)
+	}
+	r.mutex.Unlock()
+
+	if len(samples) == 0 {
+		return count, LatencyStats{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	return count, LatencyStats{
+		P50: samples[(len(samples)-1)*50/100],
+		P99: samples[(len(samples)-1)*99/100],
+	}
+}
@@ -0,0 +1,164 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheStats(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		assert.Equal(Stats{}, cache.Stats())
+
+		slowLoader := func(key string) (int, error) {
+			time.Sleep(20 * time.Millisecond)
+			return len(key), nil
+		}
+		cache.GetOrCompute(entry1.Key, slowLoader)
+		for i := 0; i < 3; i++ {
+			cache.GetOrCompute(entry1.Key, slowLoader)
+		}
+		cache.GetOrCompute(entry2.Key, func(key string) (int, error) {
+			return 0, errors.New("failed")
+		})
+
+		stats := cache.Stats()
+		assert.Equal(uint64(3), stats.Hits)
+		assert.Equal(uint64(1), stats.Misses)
+		assert.True(stats.MissLatency.P50 >= 20*time.Millisecond)
+		assert.Equal(stats.MissLatency.P50, stats.MissLatency.P99)
+		assert.True(stats.HitLatency.P99 < stats.MissLatency.P50)
+	}
+}
+
+func TestLRUCacheOperationStats(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, time.Now().Add(-time.Hour))
+		cache.Get(entry1.Key)
+		cache.Get(entry1.Key)
+		cache.Get(entry2.Key)
+		cache.Get(entry3.Key)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Set(entry4.Key, entry4.Value)
+		cache.Delete(entry4.Key)
+
+		stats := cache.Stats()
+		assert.Equal(uint64(2), stats.GetHits)
+		assert.Equal(uint64(2), stats.GetMisses)
+		assert.Equal(0.5, stats.HitRatio)
+		assert.Equal(uint64(4), stats.Sets)
+		assert.Equal(uint64(1), stats.Deletes)
+		assert.Equal(uint64(1), stats.Expirations)
+		assert.Equal(uint64(1), stats.Drops)
+
+		cache.collectGarbage()
+		assert.Equal(uint64(1), cache.Stats().GarbageCollections)
+	}
+}
+
+func TestLatencyRecorderPercentiles(t *testing.T) {
+	assert := assert.New(t)
+	var recorder latencyRecorder
+	for i := 1; i <= latencySamples+100; i++ {
+		recorder.record(time.Duration(i))
+	}
+
+	count, latency := recorder.snapshot()
+	assert.Equal(uint64(latencySamples+100), count)
+	assert.Equal(time.Duration(100+latencySamples/2), latency.P50)
+	assert.Equal(time.Duration(100+(latencySamples-1)*99/100+1), latency.P99)
+}
+
+func TestLRUCacheChurnStats(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			TrackChurn:     true,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Delete(entry3.Key)
+		cache.Set(entry3.Key, entry3.Value)
+
+		stats := cache.Stats()
+		assert.Equal(uint64(5), stats.Insertions)
+		assert.Equal(uint64(1), stats.Readmissions)
+		assert.Equal(uint64(4), stats.DistinctKeys)
+		assert.Equal(0.2, stats.ChurnRate)
+
+		assert.Equal(uint64(0), New(Config[string, int]{MaxSize: 2, TTL: time.Minute}).Stats().Insertions)
+	}
+}
+
+func TestChurnTrackerForgetsOldestKeys(t *testing.T) {
+	assert := assert.New(t)
+	tracker := newChurnTracker[int](0)
+	for i := 0; i <= minChurnHistory; i++ {
+		tracker.evicted(i, 0, EvictionReasonExpired)
+	}
+	tracker.evicted(1, 0, EvictionReasonExpired)
+
+	tracker.inserted(0)
+	tracker.inserted(1)
+	tracker.inserted(2)
+	assert.Equal(uint64(3), tracker.insertions)
+	assert.Equal(uint64(2), tracker.readmissions)
+	assert.Equal(minChurnHistory-2, len(tracker.states))
+}
+
+func TestLRUCacheGhostAdmission(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			GhostAdmission: true,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		for i := 0; i < 3; i++ {
+			cache.Get(entry1.Key)
+		}
+		counter := cache.Peek(entry1.Key).Counter
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		assert.False(cache.Has(entry1.Key))
+
+		cache.Set(entry1.Key, entry1.Value)
+		assert.Equal(counter+cache.initialCounter(), cache.Peek(entry1.Key).Counter)
+		assert.Equal(uint64(0), cache.Stats().Insertions)
+
+		cache.Delete(entry1.Key)
+		cache.Set(entry1.Key, entry1.Value)
+		assert.Equal(cache.initialCounter(), cache.Peek(entry1.Key).Counter)
+	}
+}
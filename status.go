@@ -0,0 +1,93 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Status holds the number of goroutines and timers which are currently owned by the cache
+// Goroutines started by helpers such as DrainEvictions, DrainEvictionsToSink or a
+// Watchdog are owned by their caller and are not included
+// The number of Goroutines is bounded by:
+//   - one Loader per concurrent call of GetOrCompute and GetOrComputeWithContext plus
+//     one per key being loaded via GetWithContext. While the loads exceed
+//     Config.MaxConcurrentLoads the surplus ones only wait for a slot or fail
+//     fast in the LoadLimitFailFast policy
+//   - Config.OnEvictWorkers plus one forwarder in the EvictionDeliveryBufferUnbounded policy
+//   - one trimmer if Config.SoftMaxSize is greater than MaxSize
+//   - one ScopeWatcher per distinct context passed to SetScoped which isn't done yet
+//   - one Verifier per restore via SetState, MergeState or Warmup whose entries are
+//     being verified if Config.Verifier is set
+//
+// The number of Timers is bounded by 5, for the garbage collection, the polling of
+// Config.Provider, the checks of Config.ExpiryWarning, the flush of buffered accesses
+// and the periodic snapshots of Config.Snapshotter,
+// plus one per entry if Config.ExpirationTimers is set and one per entry with
+// coalesced writes if Config.CoalesceWindow is set
+type Status struct {
+	// Total number of goroutines owned by the cache
+	Goroutines int `json:"goroutines"`
+	// Number of goroutines which run a loader or wait for a slot to run it
+	Loaders int `json:"loaders"`
+	// Number of goroutines which invoke Config.OnEvict or forward EvictedEntries
+	// to the EvictionChannel
+	EvictionNotifiers int `json:"eviction_notifiers"`
+	// Number of goroutines which drop the entries above MaxSize in the background
+	Trimmers int `json:"trimmers"`
+	// Number of goroutines which wait for the contexts passed to SetScoped
+	ScopeWatchers int `json:"scope_watchers"`
+	// Number of goroutines which verify restored entries via Config.Verifier
+	Verifiers int `json:"verifiers"`
+	// Total number of timers owned by the cache
+	Timers int `json:"timers"`
+	// Number of timers of the garbage collection, the polling of Config.Provider,
+	// the checks of Config.ExpiryWarning, the flush of buffered accesses and the
+	// periodic snapshots
+	BackgroundTimers int `json:"background_timers"`
+	// Number of timers which expire entries if Config.ExpirationTimers is set
+	ExpirationTimers int `json:"expiration_timers"`
+	// Number of timers which flush the coalesced writes of entries
+	CoalesceTimers int `json:"coalesce_timers"`
+}
+
+// Status returns the number of goroutines and timers which are currently owned by the cache
+func (c *TLRU[K, V]) Status() Status {
+	status := Status{
+		Loaders:        int(atomic.LoadInt32(&c.runningLoaders)),
+		Verifiers:      int(atomic.LoadInt32(&c.runningVerifiers)),
+		CoalesceTimers: int(atomic.LoadInt32(&c.coalesceTimers)),
+	}
+	if atomic.LoadInt32(&c.accessFlushPending) == 1 {
+		status.BackgroundTimers++
+	}
+	c.overflow.mutex.Lock()
+	if c.overflow.forwarding {
+		status.EvictionNotifiers++
+	}
+	c.overflow.mutex.Unlock()
+
+	c.RLock()
+	status.EvictionNotifiers += len(c.evictionSlots)
+	if c.trimming {
+		status.Trimmers++
+	}
+	status.ScopeWatchers = len(c.scopes)
+	for _, timer := range []*time.Timer{c.garbageCollectionTimer, c.providerTimer, c.warningTimer, c.snapshotTimer} {
+		if timer != nil {
+			status.BackgroundTimers++
+		}
+	}
+	if c.config.ExpirationTimers && !c.closed {
+		status.ExpirationTimers = len(c.cache)
+	}
+	c.RUnlock()
+
+	status.Goroutines = status.Loaders + status.EvictionNotifiers + status.Trimmers + status.ScopeWatchers + status.Verifiers
+	status.Timers = status.BackgroundTimers + status.ExpirationTimers + status.CoalesceTimers
+
+	return status
+}
@@ -0,0 +1,61 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheStatus(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:          10,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			ExpirationTimers: true,
+		}
+		cache := New(config)
+		assert.Equal(Status{}, cache.Status())
+
+		cache.Set(entry1.Key, entry1.Value)
+		ctx, cancel := context.WithCancel(context.Background())
+		cache.SetScoped(ctx, entry2.Key, entry2.Value)
+
+		release := make(chan struct{})
+		loaded := make(chan struct{})
+		go func() {
+			cache.GetOrCompute(entry3.Key, func(key string) (int, error) {
+				<-release
+				return entry3.Value, nil
+			})
+			close(loaded)
+		}()
+		assert.Eventually(func() bool {
+			return cache.Status().Loaders == 1
+		}, time.Second, time.Millisecond)
+
+		status := cache.Status()
+		assert.Equal(1, status.ScopeWatchers)
+		assert.Equal(2, status.Goroutines)
+		assert.Equal(1, status.BackgroundTimers)
+		assert.Equal(2, status.ExpirationTimers)
+		assert.Equal(3, status.Timers)
+
+		close(release)
+		<-loaded
+		cancel()
+		assert.Eventually(func() bool {
+			return cache.Status().Goroutines == 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(2, cache.Status().ExpirationTimers)
+
+		cache.CloseAndExport()
+		assert.Equal(0, cache.Status().Timers)
+	}
+}
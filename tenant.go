@@ -0,0 +1,82 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+// TenantLen returns the number of entries of the provided tenant
+// It always returns 0 if Config.Tenant is not set
+func (c *TLRU[K, V]) TenantLen(tenant string) int {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.tenantSizes[tenant]
+}
+
+// admitTenant must be called while holding the lock of the cache whenever a key is added to it
+func (c *TLRU[K, V]) admitTenant(key K) {
+	if c.config.Tenant == nil {
+		return
+	}
+	if c.tenantSizes == nil {
+		c.tenantSizes = make(map[string]int)
+	}
+	c.tenantSizes[c.tenantOf(key)]++
+}
+
+// removeTenant must be called while holding the lock of the cache whenever a key is removed from it
+func (c *TLRU[K, V]) removeTenant(key K) {
+	if c.config.Tenant == nil {
+		return
+	}
+	tenant := c.tenantOf(key)
+	if c.tenantSizes[tenant] <= 1 {
+		delete(c.tenantSizes, tenant)
+		return
+	}
+	c.tenantSizes[tenant]--
+}
+
+// shrinkFairly drops the least recently used entry which isn't borrowed of the tenant
+// with the most entries until the cache holds at most n entries. Ties are broken in
+// favor of the tenant whose entry is the least recently used. If all the entries of
+// the largest tenants are borrowed the least recently used entry of any tenant is dropped
+func (c *TLRU[K, V]) shrinkFairly(n int) {
+	for len(c.cache) > n && !c.closed {
+		largest := 0
+		for _, size := range c.tenantSizes {
+			if size > largest {
+				largest = size
+			}
+		}
+
+		var droppedNode, fallbackNode *doublyLinkedNode[K, V]
+		for previousNode := c.sentinel.previous; previousNode != c.sentinel; previousNode = previousNode.previous {
+			if c.borrows[previousNode.key] != nil {
+				continue
+			}
+			if fallbackNode == nil {
+				fallbackNode = previousNode
+			}
+			if c.tenantSizes[c.tenantOf(previousNode.key)] == largest {
+				droppedNode = previousNode
+				break
+			}
+		}
+		if droppedNode == nil {
+			droppedNode = fallbackNode
+		}
+		if droppedNode == nil {
+			return
+		}
+		c.evictEntry(droppedNode, EvictionReasonDropped)
+	}
+}
+
+// tenantOf invokes Config.Tenant for the provided key
+func (c *TLRU[K, V]) tenantOf(key K) string {
+	var tenant string
+	c.underLock(func() { tenant = c.config.Tenant(key) })
+
+	return tenant
+}
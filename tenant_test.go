@@ -0,0 +1,53 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheTenantFairness(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:         4,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+			Tenant: func(key string) string {
+				return strings.Split(key, ":")[0]
+			},
+		}
+		cache := New(config)
+		cache.Set("b:1", 1)
+		cache.Set("c:1", 1)
+		for i, key := range []string{"a:1", "a:2", "a:3", "a:4"} {
+			cache.Set(key, i)
+		}
+
+		assert.Equal(4, cache.Len())
+		assert.Equal(1, cache.TenantLen("b"))
+		assert.Equal(1, cache.TenantLen("c"))
+		assert.Equal(2, cache.TenantLen("a"))
+		assert.Equal("a:1", (<-evictionChannel).Key)
+		assert.Equal("a:2", (<-evictionChannel).Key)
+
+		cache.Delete("b:1")
+		assert.Equal(0, cache.TenantLen("b"))
+		cache.Set("c:2", 2)
+		cache.Set("c:3", 3)
+		assert.Equal(2, cache.TenantLen("c"))
+		assert.Equal(2, cache.TenantLen("a"))
+
+		assert.NoError(cache.SetState(cache.GetState()))
+		assert.Equal(2, cache.TenantLen("c"))
+		cache.Clear()
+		assert.Equal(0, cache.TenantLen("a"))
+	}
+}
@@ -6,8 +6,13 @@
 package tlru
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,10 +24,206 @@ type Config[K comparable, V any] struct {
 	TTL time.Duration
 	// Channel to listen for evicted entries events
 	EvictionChannel *chan EvictedEntry[K, V]
+	// Channel to listen for operations that modify the cache. It can be used to
+	// replicate the cache
+	OperationChannel *chan Operation[K, V]
 	// Eviction policy of tlru. Default is LRA
 	EvictionPolicy evictionPolicy
 	// GarbageCollectionInterval. If not set it defaults to 10 seconds
 	GarbageCollectionInterval time.Duration
+	// EntryLocking enables a sync.RWMutex per entry which guards its value.
+	// The View and Update methods then hold only the lock of the entry instead
+	// of the lock of the whole cache while executing the provided function
+	EntryLocking bool
+	// Optional callback which is invoked at most once per garbage collection cycle
+	// with all the entries that expired since the previous cycle, including the ones
+	// evicted by Get, Keys or Entries. It is invoked outside of the lock of the cache
+	OnExpiredBatch func([]EvictedEntry[K, V])
+	// Source of randomness of all randomized features of the cache. Providing a seeded
+	// source makes their behavior deterministic. If not set a source seeded with
+	// the current time is used
+	RandSource rand.Source
+	// Max time that GetOrCompute waits for the loader to refresh an expired entry which
+	// hasn't been evicted yet before returning its stale value. If not set expired
+	// entries are never returned
+	StaleRefreshTimeout time.Duration
+	// Max time an entry can stay in the cache since its creation regardless of how
+	// often it is used or re-inserted. If not set the lifetime of entries is unlimited
+	MaxLifetime time.Duration
+	// Max time GetOrComputeWithContext waits for the loader before its context is
+	// canceled. If not set loads are only bounded by the context of the caller
+	LoadTimeout time.Duration
+	// Time during which a load that exceeded Config.LoadTimeout, or failed in the
+	// LoadErrorCached policy, is remembered so that subsequent loads of the same key
+	// fail fast. If not set failed loads are not remembered
+	NegativeTTL time.Duration
+	// Handling of loader errors. Default is LoadErrorNotCached
+	LoadErrorPolicy loadErrorPolicy
+	// Initial backoff of a failing key in the LoadErrorBackoff policy which doubles
+	// on every consecutive failure. If not set it defaults to 1 second
+	LoadErrorBackoff time.Duration
+	// Max backoff of a failing key in the LoadErrorBackoff policy.
+	// If not set it defaults to 1 minute
+	LoadErrorMaxBackoff time.Duration
+	// Number of accesses after which Get moves an entry to the head of the list in the
+	// LRA EvictionPolicy. Counter, LastUsedAt and the expiration of the entry are still
+	// updated on every access. If neither PromoteAfter nor PromoteAge is set every
+	// access moves the entry
+	PromoteAfter int
+	// Time since an entry has been moved to the head of the list after which Get moves
+	// it again in the LRA EvictionPolicy regardless of PromoteAfter
+	PromoteAge time.Duration
+	// Min time between two moves of the same entry to the head of the list by Get in the
+	// LRA EvictionPolicy. It takes precedence over PromoteAfter and PromoteAge and reduces
+	// list writes when a handful of keys receive most of the traffic
+	PromoteInterval time.Duration
+	// ExpirationTimers creates a time.Timer per entry which evicts the entry within
+	// milliseconds of its deadline instead of at the next garbage collection cycle.
+	// Every entry then holds a runtime timer and a closure so it is meant for small
+	// caches with strict latency requirements on invalidation
+	ExpirationTimers bool
+	// Optional callback which is invoked exactly once for every value that permanently
+	// leaves the cache, whether it is dropped, expired, deleted, cleared or replaced by
+	// Set, Swap or MergeState. It is meant for releasing resources tied to values such
+	// as readers or pooled buffers. It is invoked after the lock of the cache is released
+	OnFinalize func(key K, value V)
+	// Optional callback which is invoked with every EvictedEntry as an alternative to the
+	// EvictionChannel. It is invoked after the lock of the cache is released so that it can
+	// call into the cache, by the goroutine which caused the eviction unless
+	// Config.OnEvictWorkers is set
+	OnEvict func(evictedEntry EvictedEntry[K, V])
+	// Max number of goroutines which invoke Config.OnEvict asynchronously. The goroutine
+	// which caused an eviction waits only while all of them are busy. The order of the
+	// invocations is not guaranteed. If not set OnEvict is invoked synchronously
+	OnEvictWorkers int
+	// Max time Get waits for the lock of the cache. If it is exceeded Get returns nil
+	// as on a miss instead of blocking further, while a hit whose lock for marking it as
+	// used can't be acquired in time is returned without being marked as used.
+	// If not set Get waits for the lock as long as it takes
+	GetLatencyBudget time.Duration
+	// Handling of EvictedEntries which can't be sent to the EvictionChannel right away
+	// because it is unbuffered or full. Default is EvictionDeliveryBlock
+	EvictionDelivery evictionDeliveryPolicy
+	// Point in time the TTL of entries is measured from. Default is SlidingTTL
+	ExpirationMode expirationMode
+	// Size above which Set drops entries synchronously. If it is greater than MaxSize
+	// Set still succeeds immediately while the cache holds less than SoftMaxSize entries
+	// and the entries above MaxSize are dropped in the background, keeping the
+	// eviction and the send to the EvictionChannel out of the write path
+	SoftMaxSize int
+	// Handling of States with more entries than MaxSize in SetState.
+	// Default is OversizedStateTruncate
+	OversizedState oversizedStatePolicy
+	// Optional callback which is invoked with the panics that are recovered from
+	// OnExpiredBatch, OnFinalize, loaders and DrainEvictions callbacks. A panicking
+	// loader fails the load with the PanicError
+	OnPanic func(err *PanicError)
+	// Max number of loaders that GetOrCompute and GetOrComputeWithContext run concurrently.
+	// If not set the number of concurrent loads is unlimited
+	MaxConcurrentLoads int
+	// Handling of loads which exceed Config.MaxConcurrentLoads. Default is LoadLimitWait
+	LoadLimitPolicy loadLimitPolicy
+	// ResetCountersOnRestore makes SetState and MergeState reset the Counter of restored
+	// entries to the value of a newly inserted entry instead of restoring it
+	ResetCountersOnRestore bool
+	// TrackChurn enables the Insertions, Readmissions and ChurnRate statistics of Stats.
+	// The most recently dropped or expired keys, at least as many as MaxSize, are
+	// remembered in order to detect their re-insertion
+	TrackChurn bool
+	// GhostAdmission restores the Counter of keys which are re-inserted after they have been
+	// dropped due to MaxSize, so that periodically used keys don't start cold. Dropped keys
+	// are remembered the same way as by TrackChurn
+	GhostAdmission bool
+	// Optional Provider which is polled for updated MaxSize, TTL and GarbageCollectionInterval
+	// so that they can be changed without restarting. See ReloadConfig
+	Provider Provider
+	// Interval at which Config.Provider is polled. If not set it defaults to 30 seconds
+	ProviderPollInterval time.Duration
+	// Optional function which encodes values in MarshalBinary and MarshalJSON. It allows
+	// persisting values which encoding/gob or encoding/json can't handle, such as values
+	// holding channels or functions. It must be set along with ValueUnmarshaler
+	ValueMarshaler func(value V) ([]byte, error)
+	// Optional function which decodes the values encoded by ValueMarshaler in
+	// UnmarshalBinary and UnmarshalJSON
+	ValueUnmarshaler func(data []byte) (V, error)
+	// Optional Snapshotter which persists the State of the cache every Snapshotter.Interval
+	// and on CloseAndExport so that the cache can be warmed up on the next start
+	Snapshotter Snapshotter
+	// Optional function which returns the tenant of a key. If it is set the cache drops the
+	// least recently used entry of the tenant which holds the most entries whenever it
+	// exceeds its MaxSize, so that the burst of one tenant can't evict the entries of the rest
+	Tenant func(key K) string
+	// Optional function which returns a secondary index of a value, e.g. the email of
+	// a user cached by its id, so that the entry can be retrieved via GetByIndex as well.
+	// The index must be comparable like a map key. It is invoked while holding the lock of the
+	// cache. If it panics the entry isn't indexed and the panic is reported to Config.OnPanic
+	IndexFunc func(value V) any
+	// Optional function which is invoked outside of the lock of the cache before Get and
+	// its variants return an entry, e.g. to check that a pooled connection is still alive.
+	// If it returns false the entry is evicted with EvictionReasonInvalidated and the
+	// lookup misses so that GetOrCompute and GetWithContext fall through to the loader
+	Validator func(key K, value V) bool
+	// Optional function which verifies the entries restored via SetState, MergeState and
+	// Warmup in a background pass, e.g. by checking the checksums of values. Entries for
+	// which it returns an error are evicted with EvictionReasonInvalidated
+	Verifier func(key K, value V) error
+	// Optional callback which is invoked with the report of every background pass of Config.Verifier
+	OnVerified func(report VerificationReport[K])
+	// Max sum of the costs of all entries. If it is exceeded the least recently used
+	// entries are dropped until the sum fits again. Entries whose cost alone exceeds it
+	// are rejected with ErrCostExceeded. If not set the cost of entries is not tracked
+	MaxCost int64
+	// Optional function which returns the cost of an entry, e.g. the length of a byte
+	// slice value. If not set every entry costs 1. It is invoked once per insert while
+	// holding the lock of the cache. If it panics the insert fails with a PanicError
+	Weigher func(key K, value V) int64
+	// Number of randomly sampled entries among which the least recently used one is dropped
+	// whenever the cache exceeds its MaxSize. If it is set Get doesn't move entries in the list
+	// so accesses never reorder it, trading the precision of the LRA EvictionPolicy for cheaper
+	// reads. Redis uses 5 samples by default. It is ignored if Config.Tenant is set
+	// The list is still kept in insertion order for the entries which can't be sampled and
+	// Get still holds the write lock of the cache in the LRA EvictionPolicy to update the
+	// Counter, LastUsedAt and expiration of the entry unless Config.AccessBufferSize is set
+	EvictionSamples int
+	// Number of accesses of Get that are buffered per buffer before they are applied to the list
+	// in the LRA EvictionPolicy. There are GOMAXPROCS buffers, each one guarded by its own lock,
+	// and successive accesses are recorded in them round-robin regardless of the goroutine or P
+	// they come from. If it is set Get only holds the read lock of the cache and
+	// accesses are applied in batches once a buffer is full, Config.AccessFlushInterval
+	// elapses or the cache is written to. Accesses are dropped while a buffer is full and the
+	// cache is busy, and Get returns the Counter and LastUsedAt of the entry before its access
+	AccessBufferSize int
+	// Max time buffered accesses wait before they are applied. If not set it defaults to 1 second
+	AccessFlushInterval time.Duration
+	// Window during which successive writes of an existing key by Set, SetIfPresent,
+	// SetIfVersion and Swap are coalesced. A write within the window since the last
+	// uncoalesced write of the key only replaces the value, without moving the entry in
+	// the list or emitting an Operation. A single Operation with the last value is emitted
+	// once the window elapses. Writes with a Timestamp are never coalesced
+	CoalesceWindow time.Duration
+	// Fraction of the TTL, between 0 and 1, after which an OperationExpiring is emitted to the
+	// OperationChannel for entries which are about to expire so that they can be refreshed or
+	// persisted in time. Entries are checked every half of the remaining TTL so the warning
+	// is emitted at most once per deadline of an entry. If not set no warnings are emitted
+	ExpiryWarning float64
+	// Time after which a lease granted by GetWithLease expires if it hasn't been used.
+	// If not set it defaults to 10 seconds
+	LeaseTimeout time.Duration
+	// Detection of calls of a closed cache and of calls from the callbacks which are invoked
+	// while holding the lock of the cache, i.e. the fn of View and Update or Config.Tenant,
+	// which would deadlock. It slows down every call so it is meant for tests.
+	// Default is MisuseDetectionOff
+	MisuseDetection misuseDetection
+	// Optional callback which is invoked with the MisuseError of the calls which
+	// MisuseDetectionError skips and which can't return it, e.g. Get. It must not call into the cache
+	OnMisuse func(err *MisuseError)
+	// Optional loader which GetWithContext invokes on a miss. Concurrent misses of the same
+	// key share a single invocation of the loader, which is bounded by Config.LoadTimeout
+	// and Config.MaxConcurrentLoads and whose errors are handled according to
+	// Config.LoadErrorPolicy like the loaders of GetOrCompute
+	Loader func(ctx context.Context, key K) (V, error)
+	// Optional name of the cache which identifies it e.g. in the metrics of the tlruprom package
+	Name string
 }
 
 // Entry in cache
@@ -34,6 +235,11 @@ type Entry[K comparable, V any] struct {
 	// Optional field. If provided TTL of entry will be checked against this field
 	// Timestamp is in UTC
 	Timestamp *time.Time `json:"timestamp"`
+	// Optional small set of tags such as source, tenant or trace information
+	// The map must not be modified after it has been passed to the cache
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Optional time to live of the entry which overrides Config.TTL
+	TTL time.Duration `json:"ttl,omitempty"`
 }
 
 // CacheEntry holds the cached value along with some additional information
@@ -50,6 +256,13 @@ type CacheEntry[K comparable, V any] struct {
 	LastUsedAt time.Time `json:"last_used_at"`
 	// The time this entry was inserted to the cache
 	CreatedAt time.Time `json:"created_at"`
+	// The version of the value of this entry. Versions are unique within a cache
+	// and increase on every insert or update of a value
+	Version uint64 `json:"version"`
+	// The metadata the entry was inserted with
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// The time to live of the entry which is either its own or Config.TTL
+	TTL time.Duration `json:"ttl"`
 }
 
 // EvictedEntry is an entry that is removed from the cache due to
@@ -62,12 +275,39 @@ type EvictedEntry[K comparable, V any] struct {
 	Reason evictionReason `json:"reason"`
 }
 
+// Operation is emitted for every Set, Delete and Clear that modifies the cache and for
+// entries which are about to expire if Config.ExpiryWarning is set
+type Operation[K comparable, V any] struct {
+	// The type of the operation
+	Type operationType `json:"type"`
+	// The key of the affected entry. It is not set for OperationClear
+	Key K `json:"key"`
+	// Whether an OperationSet inserted a new entry or updated an existing one.
+	// It is EventNone for OperationDelete and OperationClear
+	Event eventType `json:"event"`
+	// The value of the inserted entry. It is only set for OperationSet and OperationExpiring
+	Value V `json:"value"`
+	// The value the entry had before it was updated. It is only set for EventUpdated
+	PreviousValue V `json:"previous_value"`
+	// The time that the inserted entry was last used. It is only set for OperationSet
+	// and OperationExpiring
+	LastUsedAt time.Time `json:"last_used_at"`
+	// The time the operation occurred
+	OccurredAt time.Time `json:"occurred_at"`
+	// The number of writes which have been coalesced into an OperationSet due to
+	// Config.CoalesceWindow. PreviousValue is not set for coalesced writes
+	Coalesced int `json:"coalesced,omitempty"`
+}
+
 // State is the internal representation of the cache.
 // State can be retrieved/set via the GetState/SetState methods respectively
 type State[K comparable, V any] struct {
 	Entries        []StateEntry[K, V] `json:"entries"`
 	EvictionPolicy evictionPolicy     `json:"eviction_policy"`
 	ExtractedAt    time.Time          `json:"extracted_at"`
+	// The number of shards of the Sharded cache the State has been exported from
+	// It is 0 for the State of a TLRU cache
+	Shards int `json:"shards,omitempty"`
 }
 
 // StateEntry is a representation of a doublyLinkedNode without pointer references
@@ -77,6 +317,13 @@ type StateEntry[K comparable, V any] struct {
 	Counter    int64     `json:"counter"`
 	LastUsedAt time.Time `json:"last_used_at"`
 	CreatedAt  time.Time `json:"created_at"`
+	// The metadata the entry was inserted with
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// The time to live of the entry. It is restored as the own TTL of the entry
+	// unless it is 0 or equals Config.TTL
+	TTL time.Duration `json:"ttl,omitempty"`
+	// The index of the shard of the Sharded cache which held the entry
+	Shard int `json:"shard,omitempty"`
 }
 
 const (
@@ -86,6 +333,53 @@ const (
 	LRI
 )
 
+const (
+	// LoadErrorNotCached returns loader errors to the caller without remembering them
+	LoadErrorNotCached loadErrorPolicy = iota
+	// LoadErrorCached remembers loader errors for Config.NegativeTTL
+	LoadErrorCached
+	// LoadErrorBackoff remembers loader errors for an exponentially growing backoff
+	// per key which is reset once a load of the key succeeds
+	LoadErrorBackoff
+)
+
+const (
+	// LoadLimitWait makes loads which exceed Config.MaxConcurrentLoads wait until
+	// a running load finishes or their context is done
+	LoadLimitWait loadLimitPolicy = iota
+	// LoadLimitFailFast fails loads which exceed Config.MaxConcurrentLoads with ErrTooManyLoads
+	LoadLimitFailFast
+)
+
+const (
+	// SlidingTTL measures the TTL of an entry from its LastUsedAt so that every use extends it
+	SlidingTTL expirationMode = iota
+	// AbsoluteTTL measures the TTL of an entry from its CreatedAt so that it expires
+	// regardless of how often it is used. Like Config.MaxLifetime it isn't extended
+	// by updates of the entry either
+	AbsoluteTTL
+)
+
+const (
+	// EvictionDeliveryBlock makes evictions wait while the EvictionChannel is full which
+	// blocks the whole cache until the EvictedEntry is received
+	EvictionDeliveryBlock evictionDeliveryPolicy = iota
+	// EvictionDeliveryDropNotification discards EvictedEntries which can't be sent to the
+	// EvictionChannel right away. Discarded ones are counted by Stats.DroppedEvictionNotifications
+	EvictionDeliveryDropNotification
+	// EvictionDeliveryBufferUnbounded queues EvictedEntries which can't be sent to the
+	// EvictionChannel right away and forwards them in order from a background goroutine
+	EvictionDeliveryBufferUnbounded
+)
+
+const (
+	// OversizedStateTruncate drops the least recently used entries of a State which
+	// exceed Config.MaxSize and emits them to the EvictionChannel with EvictionReasonDropped
+	OversizedStateTruncate oversizedStatePolicy = iota
+	// OversizedStateError rejects a State which exceeds Config.MaxSize
+	OversizedStateError
+)
+
 const (
 	// EvictionReasonDropped occurs when cache is full
 	EvictionReasonDropped evictionReason = iota
@@ -93,45 +387,267 @@ const (
 	EvictionReasonExpired
 	// EvictionReasonDeleted occurs when the Delete method is called for a key
 	EvictionReasonDeleted
+	// EvictionReasonInvalidated occurs when Config.Validator, Config.Verifier or Verify rejects an entry
+	EvictionReasonInvalidated
+)
+
+const (
+	// OperationSet occurs when an entry is inserted via Set or SetWithTimestamp
+	OperationSet operationType = iota
+	// OperationDelete occurs when an existing entry is removed via Delete
+	OperationDelete
+	// OperationClear occurs when the Clear method is called
+	OperationClear
+	// OperationExpiring occurs once Config.ExpiryWarning of the TTL of an entry has elapsed
+	// without it being used. It doesn't modify the cache and carries the current value of the entry
+	OperationExpiring
+)
+
+const (
+	// EventNone is the Event of operations other than OperationSet
+	EventNone eventType = iota
+	// EventInserted occurs when an OperationSet inserts a new entry
+	EventInserted
+	// EventUpdated occurs when an OperationSet replaces the value of an existing entry
+	EventUpdated
 )
 
+// ErrReplacementNotAllowed is returned when an existing key is set in the LRA EvictionPolicy
+var ErrReplacementNotAllowed = errors.New("Entry replacement is not allowed in LRA EvictionPolicy")
+
+// ErrVersionMismatch is returned by SetIfVersion when the version of an entry has changed
+var ErrVersionMismatch = errors.New("Version mismatch")
+
+// ErrStateTooLarge is returned by SetState when a State exceeds Config.MaxSize
+// in the OversizedStateError policy
+var ErrStateTooLarge = errors.New("State exceeds MaxSize")
+
+// ErrTooManyLoads is returned by GetOrCompute when Config.MaxConcurrentLoads is
+// exceeded in the LoadLimitFailFast policy
+var ErrTooManyLoads = errors.New("Too many concurrent loads")
+
+// ErrClosed is returned by write methods after CloseAndExport has been called
+var ErrClosed = errors.New("Cache is closed")
+
+// ErrUnregisteredType is returned by TypeRegistry for values of types which haven't been registered
+var ErrUnregisteredType = errors.New("Type is not registered")
+
+// ErrNotFound is returned by GetWithContext on a miss if Config.Loader is not set
+var ErrNotFound = errors.New("Key not found")
+
+// ErrCorruptedSnapshot is returned by NewPersistent when its snapshot file is corrupted
+var ErrCorruptedSnapshot = errors.New("Snapshot is corrupted")
+
+// ErrCostExceeded is returned when the cost of an entry alone exceeds Config.MaxCost
+var ErrCostExceeded = errors.New("Entry cost exceeds MaxCost")
+
+// ErrKeyExists is returned by Rekey when an entry for the new key exists
+var ErrKeyExists = errors.New("Key already exists")
+
+// ErrBorrowed is returned by Rekey when the entry has been borrowed via Checkout
+var ErrBorrowed = errors.New("Entry is borrowed")
+
+// ErrLeaseHeld is returned by GetWithLease when another caller holds the lease of a missing key
+var ErrLeaseHeld = errors.New("Lease is held by another caller")
+
+// ErrLeaseInvalid is returned by SetWithLease when the lease has expired or has been
+// invalidated by a write or delete of the key
+var ErrLeaseInvalid = errors.New("Lease is invalid")
+
 const (
 	defaultGarbageCollectionInterval = 10 * time.Second
+	defaultLoadErrorBackoff          = time.Second
+	defaultLoadErrorMaxBackoff       = time.Minute
 )
 
+// Cache is the minimal interface implemented by TLRU
+// Libraries can accept a Cache in order to work with any cache implementation
+type Cache[K comparable, V any] interface {
+	Get(key K) *CacheEntry[K, V]
+	Set(key K, value V) error
+	Delete(key K)
+	Len() int
+}
+
+var _ Cache[string, any] = (*TLRU[string, any])(nil)
+
 // TLRU cache
 type TLRU[K comparable, V any] struct {
+	// version is the last version assigned to an entry. It is accessed atomically
+	// and kept as the first field to guarantee 64-bit alignment
+	version uint64
+	// operations counts the operations reported by Stats. Its counters are accessed
+	// atomically and it follows version to guarantee their 64-bit alignment
+	operations operationCounters
 	sync.RWMutex
-	cache                     map[K]*doublyLinkedNode[K, V]
-	config                    Config[K, V]
-	headNode                  *doublyLinkedNode[K, V]
-	tailNode                  *doublyLinkedNode[K, V]
+	cache  map[K]*doublyLinkedNode[K, V]
+	config Config[K, V]
+	// epoch is incremented whenever the list is replaced by Clear or SetState so that
+	// nodes which have been looked up before the replacement are never touched afterwards
+	epoch uint64
+	// sentinel marks both ends of the doubly linked list. Its next node is the most
+	// recently used entry and its previous node the least recently used one.
+	// It is never stored in the cache map so it doesn't occupy any key
+	sentinel                  *doublyLinkedNode[K, V]
 	garbageCollectionInterval time.Duration
 	garbageCollectionTimer    *time.Timer
+	// garbageCollectionGeneration invalidates the garbage collections which have
+	// been scheduled before the timer is replaced or stopped
+	garbageCollectionGeneration uint64
+	providerTimer               *time.Timer
+	providerGeneration          uint64
+	expiredEntries              []EvictedEntry[K, V]
+	negativeEntries             map[K]negativeEntry
+	borrows                     map[K]*borrow[V]
+	// trimming is set while a trim of the entries above Config.MaxSize is pending
+	trimming bool
+	// closed is set by CloseAndExport after which writes are rejected
+	closed bool
+	// finalized holds the values to be handed over to Config.OnFinalize once the
+	// lock of the cache is released
+	finalized []finalizedValue[K, V]
+	// evicted holds the entries to be handed over to Config.OnEvict once the
+	// lock of the cache is released
+	evicted []EvictedEntry[K, V]
+	// panicked holds the panics of the callbacks which have been invoked while holding
+	// the lock of the cache to be reported to Config.OnPanic once it is released
+	panicked []*PanicError
+	// weighed holds the cost of the entry being inserted so that Config.Weigher
+	// is invoked once per insert
+	weighed *weighedEntry[K]
+	// evictionSlots is a semaphore which bounds the goroutines that invoke
+	// Config.OnEvict to Config.OnEvictWorkers
+	evictionSlots chan struct{}
+	// overflow queues the EvictedEntries in the EvictionDeliveryBufferUnbounded policy
+	overflow evictionOverflow[K, V]
+	churn    *churnTracker[K]
+	// sequence is the last sequence assigned to a node. See IterateFrom
+	sequence uint64
+	// tenantSizes holds the number of entries of every tenant if Config.Tenant is set
+	tenantSizes map[string]int
+	// samples holds all nodes in random order if Config.EvictionSamples is set
+	samples []*doublyLinkedNode[K, V]
+	// expiries holds all nodes ordered by their expiration
+	expiries      expiryHeap[K, V]
+	hitLatencies  latencyRecorder
+	missLatencies latencyRecorder
+	// warningTimer triggers the next check of Config.ExpiryWarning and warningGeneration
+	// invalidates the checks which have been scheduled before it is replaced
+	warningTimer      *time.Timer
+	warningGeneration uint64
+	// snapshotTimer triggers the next snapshot of Config.Snapshotter and snapshotGeneration
+	// invalidates the snapshots which have been scheduled before it is replaced.
+	// snapshotMutex serializes the snapshots
+	snapshotTimer      *time.Timer
+	snapshotGeneration uint64
+	snapshotMutex      sync.Mutex
+	// closedFlag mirrors closed for Config.MisuseDetection which reads it without
+	// holding the lock of the cache. It is accessed atomically
+	closedFlag int32
+	// callbackGoroutines holds the ids of the goroutines which run a callback while
+	// holding the lock of the cache if Config.MisuseDetection is set
+	callbackGoroutines sync.Map
+	// loaderCalls holds the running invocations of Config.Loader
+	loaderCalls map[K]*loaderCall[V]
+	// leases holds the outstanding leases granted by GetWithLease and leaseToken
+	// is the last token granted
+	leases     map[K]Lease
+	leaseToken uint64
+	// scopes holds the keys and sequences of the entries set via SetScoped
+	// by the done channel of their context
+	scopes map[<-chan struct{}]map[K]uint64
+	// secondary holds the nodes by their index if Config.IndexFunc is set
+	secondary map[any]*doublyLinkedNode[K, V]
+	// totalCost is the sum of the costs of all nodes if Config.MaxCost is set
+	totalCost int64
+	// coalescedWrites is the number of writes coalesced due to Config.CoalesceWindow
+	coalescedWrites uint64
+	// garbageCollections is the number of garbage collection passes and
+	// garbageCollectionTime the total time spent in them
+	garbageCollections    uint64
+	garbageCollectionTime time.Duration
+	// accessBuffers hold the accesses of Get which haven't been applied to the list
+	// if Config.AccessBufferSize is set
+	accessBuffers []accessBuffer[K, V]
+	// accessShard selects the buffer of the next access round-robin. It is accessed atomically
+	accessShard uint32
+	// accessFlushPending is set while a flush of the buffered accesses is scheduled.
+	// It is accessed atomically
+	accessFlushPending int32
+	// runningLoaders is the number of goroutines which run a loader and coalesceTimers
+	// the number of scheduled flushes of coalesced writes. They are accessed atomically
+	runningLoaders int32
+	coalesceTimers int32
+	// runningVerifiers is the number of background passes of Config.Verifier.
+	// It is accessed atomically
+	runningVerifiers int32
+	// loadSlots is a semaphore which bounds the concurrent loads to Config.MaxConcurrentLoads
+	loadSlots chan struct{}
+	// random is created from Config.RandSource and must only be used while
+	// holding the lock of the cache
+	random *rand.Rand
 }
 
 // New returns a new instance of TLRU cache
 func New[K comparable, V any](config Config[K, V]) *TLRU[K, V] {
-	var headNodeRef, tailNodeRef K
-	headNode := &doublyLinkedNode[K, V]{key: headNodeRef}
-	tailNode := &doublyLinkedNode[K, V]{key: tailNodeRef}
-	headNode.next = tailNode
-	tailNode.previous = headNode
+	cache := &TLRU[K, V]{}
+	cache.init(config)
+
+	return cache
+}
 
-	garbageCollectionInterval := defaultGarbageCollectionInterval
-	if config.GarbageCollectionInterval > 0 {
-		garbageCollectionInterval = config.GarbageCollectionInterval
+// init resolves the defaults of the provided config and resets the cache to an empty one
+func (c *TLRU[K, V]) init(config Config[K, V]) {
+	if config.GarbageCollectionInterval <= 0 {
+		config.GarbageCollectionInterval = defaultGarbageCollectionInterval
+	}
+	if config.LoadErrorBackoff <= 0 {
+		config.LoadErrorBackoff = defaultLoadErrorBackoff
+	}
+	if config.LoadErrorMaxBackoff <= 0 {
+		config.LoadErrorMaxBackoff = defaultLoadErrorMaxBackoff
+	}
+	if config.ProviderPollInterval <= 0 {
+		config.ProviderPollInterval = defaultProviderPollInterval
+	}
+	if config.AccessFlushInterval <= 0 {
+		config.AccessFlushInterval = defaultAccessFlushInterval
+	}
+	if config.LeaseTimeout <= 0 {
+		config.LeaseTimeout = defaultLeaseTimeout
 	}
 
-	cache := &TLRU[K, V]{
-		config:                    config,
-		cache:                     make(map[K]*doublyLinkedNode[K, V]),
-		garbageCollectionInterval: garbageCollectionInterval,
+	if config.RandSource == nil {
+		config.RandSource = rand.NewSource(time.Now().UnixNano())
 	}
 
-	cache.initializeDoublyLinkedList()
+	c.config = config
+	c.cache = make(map[K]*doublyLinkedNode[K, V])
+	c.garbageCollectionInterval = config.GarbageCollectionInterval
+	c.random = rand.New(config.RandSource)
+	c.loadSlots = nil
+	c.churn = nil
+	c.tenantSizes = nil
+	c.samples = nil
+	c.secondary = nil
+	c.totalCost = 0
+	c.expiries = nil
+	if config.TrackChurn || config.GhostAdmission {
+		c.churn = newChurnTracker[K](config.MaxSize)
+	}
+	if config.MaxConcurrentLoads > 0 {
+		c.loadSlots = make(chan struct{}, config.MaxConcurrentLoads)
+	}
+	c.evictionSlots = nil
+	if config.OnEvictWorkers > 0 {
+		c.evictionSlots = make(chan struct{}, config.OnEvictWorkers)
+	}
+	c.initializeDoublyLinkedList()
+	c.initAccessBuffers()
 
-	return cache
+	c.scheduleProviderPoll()
+	c.scheduleExpiryWarning()
+	c.scheduleSnapshot()
 }
 
 // Get retrieves an entry from the cache by key
@@ -146,28 +662,72 @@ func New[K comparable, V any](config Config[K, V]) *TLRU[K, V] {
 // * EvictionPolicy.LRI - (Least Recenty Inserted):
 //   - If an entry for the specified key doesn't exist then it returns nil
 func (c *TLRU[K, V]) Get(key K) *CacheEntry[K, V] {
-	c.RLock()
+	if c.misused("Get", false) {
+		return nil
+	}
+	cacheEntry := c.validate(c.get(key))
+	c.countGet(cacheEntry)
+
+	return cacheEntry
+}
+
+func (c *TLRU[K, V]) get(key K) *CacheEntry[K, V] {
+	deadline := c.budgetDeadline()
+	if !c.rlockBefore(deadline) {
+		return nil
+	}
 
 	linkedNode, exists := c.cache[key]
 	if !exists {
 		c.RUnlock()
 		return nil
 	}
+	epoch := c.epoch
 
-	if c.config.TTL < time.Since(linkedNode.lastUsedAt) {
+	if linkedNode.isExpired(time.Now()) {
 		c.RUnlock()
-		c.Lock()
-		defer c.Unlock()
+		if !c.lockBefore(deadline) {
+			return nil
+		}
+		defer c.unlock()
+		if c.isStale(key, linkedNode, epoch) {
+			return nil
+		}
+		// A buffered access might extend the expiration of the entry
+		c.applyAccesses()
+		if !linkedNode.isExpired(time.Now()) {
+			c.access(linkedNode, time.Now())
+			cacheEntry := linkedNode.ToCacheEntry()
+			return &cacheEntry
+		}
 		c.evictEntry(linkedNode, EvictionReasonExpired)
 		return nil
 	}
 
+	if c.accessBuffers != nil {
+		cacheEntry := linkedNode.ToCacheEntry()
+		c.RUnlock()
+		c.recordAccess(linkedNode, time.Now())
+		return &cacheEntry
+	}
+
 	if c.config.EvictionPolicy == LRA {
+		var cacheEntry CacheEntry[K, V]
+		if !deadline.IsZero() {
+			cacheEntry = linkedNode.ToCacheEntry()
+		}
 		c.RUnlock()
-		c.Lock()
-		c.handleNodeState(Entry[K, V]{Key: key, Value: linkedNode.value})
-		c.Unlock()
-		c.RLock()
+		if !c.lockBefore(deadline) {
+			return &cacheEntry
+		}
+		defer c.unlock()
+		// The entry might have been removed or the list replaced while upgrading the lock
+		if c.isStale(key, linkedNode, epoch) {
+			return nil
+		}
+		c.access(linkedNode, time.Now())
+		cacheEntry = linkedNode.ToCacheEntry()
+		return &cacheEntry
 	}
 
 	defer c.RUnlock()
@@ -176,6 +736,70 @@ func (c *TLRU[K, V]) Get(key K) *CacheEntry[K, V] {
 	return &cacheEntry
 }
 
+// Peek retrieves an entry from the cache by key without marking it as used
+// It neither updates the Counter/LastUsedAt properties nor the position of the entry
+// If an entry for the specified key doesn't exist or is expired then it returns nil
+func (c *TLRU[K, V]) Peek(key K) *CacheEntry[K, V] {
+	if c.misused("Peek", false) {
+		return nil
+	}
+	defer c.RUnlock()
+	c.RLock()
+
+	linkedNode, exists := c.cache[key]
+	if !exists || linkedNode.isExpired(time.Now()) {
+		return nil
+	}
+	cacheEntry := linkedNode.ToCacheEntry()
+
+	return &cacheEntry
+}
+
+// LRUKey returns the key of the least recently used entry which is the next one to be
+// dropped when the cache is full. Expired entries which haven't been evicted yet are included
+// It returns false if the cache is empty
+func (c *TLRU[K, V]) LRUKey() (K, bool) {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.sentinel.previous.key, c.sentinel.previous != c.sentinel
+}
+
+// MRUKey returns the key of the most recently used entry
+// Expired entries which haven't been evicted yet are included
+// It returns false if the cache is empty
+func (c *TLRU[K, V]) MRUKey() (K, bool) {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.sentinel.next.key, c.sentinel.next != c.sentinel
+}
+
+// Epoch returns the number of times the entries of the cache have been replaced
+// as a whole via Clear or SetState
+func (c *TLRU[K, V]) Epoch() uint64 {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.epoch
+}
+
+// TTL returns the time to live of cached entries
+func (c *TLRU[K, V]) TTL() time.Duration {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.config.TTL
+}
+
+// Config returns the effective configuration of the cache with all defaults filled in
+func (c *TLRU[K, V]) Config() Config[K, V] {
+	defer c.RUnlock()
+	c.RLock()
+
+	return c.config
+}
+
 // Set inserts/updates an entry in the cache
 // Set behaves differently depending on the EvictionPolicy used
 // * EvictionPolicy.LRA - (Least Recenty Accessed):
@@ -183,7 +807,7 @@ func (c *TLRU[K, V]) Get(key K) *CacheEntry[K, V] {
 //     recently used entry with Counter = 0
 //   - If the key entry already exists then it will return an error
 //   - If the cache is full (Config.MaxSize) then the least recently accessed
-//     entry(the node before the tailNode) will be dropped and an
+//     entry(the tail of the list) will be dropped and an
 //     EvictedEntry will be emitted to the EvictionChannel(if present)
 //     with EvictionReasonDropped
 //
@@ -194,107 +818,451 @@ func (c *TLRU[K, V]) Get(key K) *CacheEntry[K, V] {
 //     the Value, Counter and LastUsedAt properties of
 //     the existing entry and mark it as the most recently used entry
 //   - If the cache is full (Config.MaxSize) then
-//     the least recently inserted entry(the node before the tailNode)
+//     the least recently inserted entry(the tail of the list)
 //     will be dropped and an EvictedEntry will be emitted to
 //     the EvictionChannel(if present) with EvictionReasonDropped
 func (c *TLRU[K, V]) Set(key K, value V) error {
-	return c.set(key, value, nil)
+	return c.set(Entry[K, V]{Key: key, Value: value})
 }
 
 // SetWithTimestamp is identical to the Set function but it allows to set the timestamp for the inserted entry
 func (c *TLRU[K, V]) SetWithTimestamp(key K, value V, timestamp time.Time) error {
-	return c.set(key, value, &timestamp)
+	return c.set(Entry[K, V]{Key: key, Value: value, Timestamp: &timestamp})
+}
+
+// SetWithTTL inserts an entry which expires after the provided ttl instead of Config.TTL
+// Subsequent uses of the entry extend its expiration by the provided ttl as well
+// A ttl which isn't positive falls back to Config.TTL. It behaves like Set otherwise
+func (c *TLRU[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	return c.set(Entry[K, V]{Key: key, Value: value, TTL: ttl})
+}
+
+// SetWithMetadata is identical to the Set function but it attaches the provided metadata
+// to the inserted entry. The metadata is carried through CacheEntry, StateEntry and EvictedEntry
+func (c *TLRU[K, V]) SetWithMetadata(key K, value V, metadata map[string]string) error {
+	return c.set(Entry[K, V]{Key: key, Value: value, Metadata: metadata})
 }
 
-func (c *TLRU[K, V]) set(key K, value V, timestamp *time.Time) error {
-	defer c.Unlock()
+// SetIfAbsent inserts the provided entry only if no entry exists for the key
+// An expired entry is treated as absent and is evicted with EvictionReasonExpired
+// It returns true if the entry was stored
+func (c *TLRU[K, V]) SetIfAbsent(key K, value V) bool {
+	if c.misused("SetIfAbsent", true) {
+		return false
+	}
+	defer c.unlock()
 	c.Lock()
 
-	if c.garbageCollectionTimer == nil {
-		c.garbageCollectionTimer = time.AfterFunc(c.garbageCollectionInterval, func() {
-			c.Lock()
-			c.evictExpiredEntries()
-			c.Unlock()
-		})
+	linkedNode, exists := c.cache[key]
+	if exists {
+		if !linkedNode.isExpired(time.Now()) {
+			return false
+		}
+		c.evictEntry(linkedNode, EvictionReasonExpired)
+	}
+
+	return c.insert(Entry[K, V]{Key: key, Value: value}) == nil
+}
+
+// SetIfPresent replaces the value of an existing non-expired entry
+// Unlike Set it replaces existing entries in both EvictionPolicies. The replaced
+// entry is marked as the most recently used one and its Counter is incremented
+// It returns true if the entry was updated. A value whose cost alone exceeds
+// Config.MaxCost isn't stored
+func (c *TLRU[K, V]) SetIfPresent(key K, value V) bool {
+	if c.misused("SetIfPresent", true) {
+		return false
 	}
+	defer c.unlock()
+	c.Lock()
 
-	entry := Entry[K, V]{Key: key, Value: value, Timestamp: timestamp}
+	linkedNode, exists := c.cache[key]
+	if !exists || linkedNode.isExpired(time.Now()) {
+		return false
+	}
+
+	return c.put(Entry[K, V]{Key: key, Value: value}) == nil
+}
+
+// SetIfVersion inserts or replaces the entry of the provided key only if its current
+// version equals the provided version. The version of an absent or expired entry is 0
+// Unlike Set it replaces existing entries in both EvictionPolicies
+// If the version doesn't match an error that wraps ErrVersionMismatch is returned
+func (c *TLRU[K, V]) SetIfVersion(key K, value V, version uint64) error {
+	if err := c.checkMisuse("SetIfVersion", true); err != nil {
+		return err
+	}
+	defer c.unlock()
+	c.Lock()
+	if c.closed {
+		return fmt.Errorf("tlru.SetIfVersion: %w", ErrClosed)
+	}
+
+	var currentVersion uint64
+	linkedNode, exists := c.cache[key]
+	if exists && !linkedNode.isExpired(time.Now()) {
+		_, currentVersion = linkedNode.readVersionedValue()
+	}
+	if currentVersion != version {
+		return fmt.Errorf("tlru.SetIfVersion: Key '%+v' has version %d. %w", key, currentVersion, ErrVersionMismatch)
+	}
+
+	if err := c.put(Entry[K, V]{Key: key, Value: value}); err != nil {
+		return fmt.Errorf("tlru.SetIfVersion: %w", err)
+	}
+
+	return nil
+}
+
+// Swap inserts or replaces the entry of the provided key and returns the value it replaced
+// It returns false if no entry existed for the provided key. The value of an expired entry
+// which hasn't been evicted yet is returned as well so that resources tied to it can be released
+// Unlike Set it replaces existing entries in both EvictionPolicies
+// If the entry can't be stored, e.g. because the cache has been closed via CloseAndExport
+// or because its cost exceeds Config.MaxCost, the existing entry is kept and an error is returned
+func (c *TLRU[K, V]) Swap(key K, value V) (V, bool, error) {
+	var previous V
+	if err := c.checkMisuse("Swap", true); err != nil {
+		return previous, false, err
+	}
+	defer c.unlock()
+	c.Lock()
+
+	linkedNode, exists := c.cache[key]
+	if exists {
+		previous = linkedNode.readValue()
+	}
+	if err := c.put(Entry[K, V]{Key: key, Value: value}); err != nil {
+		var zero V
+		return zero, false, fmt.Errorf("tlru.Swap: %w", err)
+	}
+
+	return previous, exists, nil
+}
+
+func (c *TLRU[K, V]) set(entry Entry[K, V]) error {
+	if err := c.checkMisuse("Set", true); err != nil {
+		return err
+	}
+	defer c.unlock()
+	c.Lock()
+
+	if err := c.insert(entry); err != nil {
+		return fmt.Errorf("tlru.Set: %w", err)
+	}
+
+	return nil
+}
+
+// insert adds the provided entry to the cache and must be called while holding the lock of the cache
+// Existing entries are only replaced in the LRI EvictionPolicy
+func (c *TLRU[K, V]) insert(entry Entry[K, V]) error {
+	if c.closed {
+		return ErrClosed
+	}
+	if _, exists := c.cache[entry.Key]; exists && c.config.EvictionPolicy == LRA {
+		return fmt.Errorf("Key '%+v' already exist. %w", entry.Key, ErrReplacementNotAllowed)
+	}
+
+	return c.put(entry)
+}
+
+// put inserts or replaces the provided entry regardless of the EvictionPolicy once it has been
+// validated and room has been made for it. It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) put(entry Entry[K, V]) error {
+	if c.closed {
+		return ErrClosed
+	}
 	_, exists := c.cache[entry.Key]
-	if c.config.MaxSize != 0 && !exists && len(c.cache) == c.config.MaxSize {
-		c.evictEntry(c.tailNode.previous, EvictionReasonDropped)
+	if c.config.MaxCost > 0 {
+		cost, err := c.weigh(entry.Key, entry.Value)
+		if err != nil {
+			return err
+		}
+		if cost > c.config.MaxCost {
+			return fmt.Errorf("Key '%+v' exceeds MaxCost. %w", entry.Key, ErrCostExceeded)
+		}
+		c.weighed = &weighedEntry[K]{key: entry.Key, cost: cost}
+		defer func() { c.weighed = nil }()
 	}
 
-	if exists && c.config.EvictionPolicy == LRA {
-		return fmt.Errorf("tlru.Set: Key '%+v' already exist. Entry replacement is not allowed in LRA EvictionPolicy", entry.Key)
+	// The entry is valid so room is made for it
+	c.ensureGarbageCollection()
+	if !exists {
+		c.shrink(c.maxSize() - 1)
 	}
+	c.store(entry)
+	c.shrinkCost()
 
-	c.handleNodeState(entry)
+	if c.maxSize() > c.config.MaxSize && len(c.cache) > c.config.MaxSize && !c.trimming {
+		c.trimming = true
+		go c.trim()
+	}
 
 	return nil
 }
 
+// maxSize returns the size above which entries are dropped synchronously on insertion
+func (c *TLRU[K, V]) maxSize() int {
+	if c.config.MaxSize != 0 && c.config.SoftMaxSize > c.config.MaxSize {
+		return c.config.SoftMaxSize
+	}
+
+	return c.config.MaxSize
+}
+
+// trim drops the entries above Config.MaxSize in the background
+func (c *TLRU[K, V]) trim() {
+	defer c.unlock()
+	c.Lock()
+
+	c.shrink(c.config.MaxSize)
+	c.trimming = false
+}
+
+// store inserts or replaces the provided entry regardless of the EvictionPolicy
+func (c *TLRU[K, V]) store(entry Entry[K, V]) {
+	operation := Operation[K, V]{Type: OperationSet, Event: EventInserted, Key: entry.Key, Value: entry.Value}
+	atomic.AddUint64(&c.operations.sets, 1)
+	delete(c.leases, entry.Key)
+	if linkedNode, exists := c.cache[entry.Key]; exists {
+		if c.coalesce(linkedNode, entry) {
+			return
+		}
+		operation.Event = EventUpdated
+		operation.PreviousValue = linkedNode.readValue()
+	}
+	c.handleNodeState(entry)
+	operation.LastUsedAt = c.cache[entry.Key].lastUsedAt
+	c.emitOperation(operation)
+}
+
 // Delete removes the entry that corresponds to the provided key from cache
 // An EvictedEntry will be emitted to the EvictionChannel(if present)
 // with EvictionReasonDeleted
 func (c *TLRU[K, V]) Delete(key K) {
-	defer c.Unlock()
+	if c.misused("Delete", true) {
+		return
+	}
+	defer c.unlock()
+	c.Lock()
+
+	c.delete(key)
+}
+
+// DeleteIfPresent removes the entry that corresponds to the provided key like Delete
+// and returns true if it was a non-expired entry. An expired entry which hasn't been
+// evicted yet is evicted with EvictionReasonExpired instead and false is returned
+func (c *TLRU[K, V]) DeleteIfPresent(key K) bool {
+	if c.misused("DeleteIfPresent", true) {
+		return false
+	}
+	defer c.unlock()
 	c.Lock()
 
 	linkedNode, exists := c.cache[key]
-	if exists {
-		c.evictEntry(linkedNode, EvictionReasonDeleted)
+	if !exists || c.closed {
+		return false
+	}
+	if linkedNode.isExpired(time.Now()) {
+		c.evictEntry(linkedNode, EvictionReasonExpired)
+		return false
 	}
+	c.delete(key)
+
+	return true
 }
 
 // Keys returns an unordered slice of all available keys in the cache
 // The order of keys is not guaranteed
 // It will also evict expired entries based on the TTL of the cache
 func (c *TLRU[K, V]) Keys() []K {
+	return c.AppendKeys(make([]K, 0))
+}
+
+// AppendKeys appends all available keys in the cache to dst and returns the extended slice
+// It allows callers that poll the cache frequently to reuse the same buffer
+// The order of keys is not guaranteed
+// It will also evict expired entries based on the TTL of the cache
+func (c *TLRU[K, V]) AppendKeys(dst []K) []K {
+	if c.misused("Keys", false) {
+		return dst
+	}
 	c.Lock()
 	c.evictExpiredEntries()
-	c.Unlock()
+	c.unlock()
 
 	defer c.RUnlock()
 	c.RLock()
 
-	keys := make([]K, 0, len(c.cache))
+	dst = grow(dst, len(c.cache))
 	for key := range c.cache {
-		keys = append(keys, key)
+		dst = append(dst, key)
 	}
 
-	return keys
+	return dst
 }
 
 // Entries returns an unordered slice of all available entries in the cache
 // The order of entries is not guaranteed
 // It will also evict expired entries based on the TTL of the cache
 func (c *TLRU[K, V]) Entries() []CacheEntry[K, V] {
+	return c.AppendEntries(make([]CacheEntry[K, V], 0))
+}
+
+// AppendEntries appends all available entries in the cache to dst and returns the extended slice
+// It allows callers that poll the cache frequently to reuse the same buffer
+// The order of entries is not guaranteed
+// It will also evict expired entries based on the TTL of the cache
+func (c *TLRU[K, V]) AppendEntries(dst []CacheEntry[K, V]) []CacheEntry[K, V] {
+	if c.misused("Entries", false) {
+		return dst
+	}
 	c.Lock()
 	c.evictExpiredEntries()
-	c.Unlock()
+	c.unlock()
 
 	defer c.RUnlock()
 	c.RLock()
 
-	entries := make([]CacheEntry[K, V], 0, len(c.cache))
+	dst = grow(dst, len(c.cache))
 	for _, linkedNode := range c.cache {
-		entries = append(entries, linkedNode.ToCacheEntry())
+		dst = append(dst, linkedNode.ToCacheEntry())
 	}
 
-	return entries
+	return dst
+}
+
+// EntriesOlderThan returns the non-expired entries that were created more than d ago
+// The entries are ordered from the most to the least recently used one
+func (c *TLRU[K, V]) EntriesOlderThan(d time.Duration) []CacheEntry[K, V] {
+	return c.entriesByAge(d, true)
+}
+
+// EntriesYoungerThan returns the non-expired entries that were created less than d ago
+// The entries are ordered from the most to the least recently used one
+func (c *TLRU[K, V]) EntriesYoungerThan(d time.Duration) []CacheEntry[K, V] {
+	return c.entriesByAge(d, false)
+}
+
+// ExpiringWithin returns the keys of the non-expired entries which expire within d
+// The keys are ordered from the most to the least recently used entry
+func (c *TLRU[K, V]) ExpiringWithin(d time.Duration) []K {
+	defer c.RUnlock()
+	c.RLock()
+
+	keys := make([]K, 0)
+	now := time.Now()
+	deadline := now.Add(d)
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
+		if !nextNode.isExpired(now) && !nextNode.expiresAt.After(deadline) {
+			keys = append(keys, nextNode.key)
+		}
+		nextNode = nextNode.next
+	}
+
+	return keys
+}
+
+// RemainingTTL returns the time until the entry of the provided key expires, which takes
+// its own TTL, Config.MaxLifetime and the ExpirationMode into account
+// It returns false if the key doesn't exist or its entry has expired
+func (c *TLRU[K, V]) RemainingTTL(key K) (time.Duration, bool) {
+	defer c.RUnlock()
+	c.RLock()
+
+	linkedNode, exists := c.cache[key]
+	now := time.Now()
+	if !exists || linkedNode.isExpired(now) {
+		return 0, false
+	}
+
+	return linkedNode.expiresAt.Sub(now), true
+}
+
+// TTLDistribution returns how many non-expired entries fall into each remaining TTL bucket
+// The buckets are inclusive upper bounds sorted in ascending order. The returned slice
+// has len(buckets)+1 elements where the last one counts the entries that outlive the last bucket
+// It can be used to predict upcoming expiration storms
+func (c *TLRU[K, V]) TTLDistribution(buckets []time.Duration) []int {
+	defer c.RUnlock()
+	c.RLock()
+
+	distribution := make([]int, len(buckets)+1)
+	now := time.Now()
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
+		if !nextNode.isExpired(now) {
+			remaining := nextNode.expiresAt.Sub(now)
+			distribution[sort.Search(len(buckets), func(i int) bool { return remaining <= buckets[i] })]++
+		}
+		nextNode = nextNode.next
+	}
+
+	return distribution
+}
+
+// AgeDistribution returns how many non-expired entries fall into each age bucket, where the age
+// of an entry is the time since it was inserted. The buckets are inclusive upper bounds sorted
+// in ascending order. The returned slice has len(buckets)+1 elements where the last one counts
+// the entries that are older than the last bucket
+func (c *TLRU[K, V]) AgeDistribution(buckets []time.Duration) []int {
+	defer c.RUnlock()
+	c.RLock()
+
+	distribution := make([]int, len(buckets)+1)
+	now := time.Now()
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
+		if !nextNode.isExpired(now) {
+			age := now.Sub(nextNode.createdAt)
+			distribution[sort.Search(len(buckets), func(i int) bool { return age <= buckets[i] })]++
+		}
+		nextNode = nextNode.next
+	}
+
+	return distribution
+}
+
+// CounterDistribution returns how many non-expired entries fall into each Counter bucket
+// The buckets are inclusive upper bounds sorted in ascending order. The returned slice has
+// len(buckets)+1 elements where the last one counts the entries whose Counter exceeds the last bucket
+func (c *TLRU[K, V]) CounterDistribution(buckets []int64) []int {
+	defer c.RUnlock()
+	c.RLock()
+
+	distribution := make([]int, len(buckets)+1)
+	now := time.Now()
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
+		if !nextNode.isExpired(now) {
+			counter := nextNode.counter
+			distribution[sort.Search(len(buckets), func(i int) bool { return counter <= buckets[i] })]++
+		}
+		nextNode = nextNode.next
+	}
+
+	return distribution
 }
 
 // Clear removes all entries from the cache and frees underlying resources
+// Clear has no effect after CloseAndExport has been called
 func (c *TLRU[K, V]) Clear() {
-	defer c.Unlock()
+	if c.misused("Clear", true) {
+		return
+	}
+	defer c.unlock()
 	c.Lock()
+	if c.closed {
+		return
+	}
 
 	c.clear()
+	c.negativeEntries = nil
 
-	if c.garbageCollectionTimer != nil {
-		c.garbageCollectionTimer.Stop()
-		c.garbageCollectionTimer = nil
-	}
+	c.stopGarbageCollection()
+	c.emitOperation(Operation[K, V]{Type: OperationClear})
 }
 
 // GetState returns the internal State of the cache
@@ -304,14 +1272,19 @@ func (c *TLRU[K, V]) GetState() State[K, V] {
 	defer c.RUnlock()
 	c.RLock()
 
+	return c.state()
+}
+
+// state must be called while holding the lock of the cache
+func (c *TLRU[K, V]) state() State[K, V] {
 	state := State[K, V]{
 		EvictionPolicy: c.config.EvictionPolicy,
 		Entries:        make([]StateEntry[K, V], 0, len(c.cache)),
 		ExtractedAt:    time.Now().UTC(),
 	}
 
-	nextNode := c.headNode.next
-	for nextNode != nil && nextNode != c.tailNode {
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
 		state.Entries = append(state.Entries, nextNode.ToStateEntry())
 		nextNode = nextNode.next
 	}
@@ -320,38 +1293,225 @@ func (c *TLRU[K, V]) GetState() State[K, V] {
 }
 
 // SetState sets the internal State of the cache
+// A State with more entries than Config.MaxSize is handled according to Config.OversizedState
+// Entries are restored the same way in both EvictionPolicies:
+//   - Counter is restored as is unless Config.ResetCountersOnRestore is set
+//   - LastUsedAt is restored as is and the entry expires TTL after it
+//   - CreatedAt is restored as is, or set to LastUsedAt if it is zero, and
+//     Config.MaxLifetime is applied from it
+//
+// The restored entries are checked by Config.Verifier(if present) in the background
 func (c *TLRU[K, V]) SetState(state State[K, V]) error {
-	defer c.Unlock()
+	defer c.unlock()
 	c.Lock()
 	if state.EvictionPolicy != c.config.EvictionPolicy {
 		return fmt.Errorf("tlru.SetState: Incompatible state EvictionPolicy %s", state.EvictionPolicy.String())
 	}
+	if c.closed {
+		return fmt.Errorf("tlru.SetState: %w", ErrClosed)
+	}
+	if c.config.OversizedState == OversizedStateError && c.config.MaxSize != 0 && len(state.Entries) > c.config.MaxSize {
+		return fmt.Errorf("tlru.SetState: State has %d entries and MaxSize is %d. %w", len(state.Entries), c.config.MaxSize, ErrStateTooLarge)
+	}
 	c.clear()
 
-	previousNode := c.headNode
+	previousNode := c.sentinel
 	cache := make(map[K]*doublyLinkedNode[K, V], 0)
 	for _, StateEntry := range state.Entries {
+		counter, createdAt := c.restoredCounterAndCreatedAt(StateEntry)
+		ttl, customTTL := c.entryTTL(StateEntry.TTL)
 		rehydratedNode := &doublyLinkedNode[K, V]{
 			key:        StateEntry.Key,
 			value:      StateEntry.Value,
-			counter:    StateEntry.Counter,
+			version:    c.nextVersion(),
+			sequence:   c.nextSequence(),
+			lock:       c.newEntryLock(),
+			counter:    counter,
 			lastUsedAt: StateEntry.LastUsedAt,
-			createdAt:  StateEntry.CreatedAt,
+			expiresAt:  c.limitLifetime(c.deadline(StateEntry.LastUsedAt, ttl), createdAt, ttl),
+			createdAt:  createdAt,
+			metadata:   StateEntry.Metadata,
+			ttl:        ttl,
+			customTTL:  customTTL,
 		}
 		previousNode.next = rehydratedNode
 		rehydratedNode.previous = previousNode
 		previousNode = rehydratedNode
 		cache[rehydratedNode.key] = rehydratedNode
+		c.index(rehydratedNode)
 	}
-	previousNode.next = c.tailNode
-	c.tailNode.previous = previousNode
+	previousNode.next = c.sentinel
+	c.sentinel.previous = previousNode
 	c.cache = cache
 
+	for _, linkedNode := range cache {
+		c.scheduleExpiration(linkedNode)
+	}
+	c.shrink(c.config.MaxSize)
+	c.ensureGarbageCollection()
+	c.verifyRestored(stateKeys(state.Entries))
+
+	return nil
+}
+
+// GetStateSince returns a State that contains only the entries which have been
+// created or used after the provided time
+// It can be used for cheap periodic delta backups which are applied via the MergeState method
+func (c *TLRU[K, V]) GetStateSince(t time.Time) State[K, V] {
+	defer c.RUnlock()
+	c.RLock()
+
+	state := State[K, V]{
+		EvictionPolicy: c.config.EvictionPolicy,
+		Entries:        make([]StateEntry[K, V], 0),
+		ExtractedAt:    time.Now().UTC(),
+	}
+
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
+		if nextNode.lastUsedAt.After(t) || nextNode.createdAt.After(t) {
+			state.Entries = append(state.Entries, nextNode.ToStateEntry())
+		}
+		nextNode = nextNode.next
+	}
+
+	return state
+}
+
+// MergeState merges the provided State into the internal State of the cache
+// Entries of the provided State replace existing entries with the same key and
+// are marked as the most recently used entries, preserving their relative order
+// Counter, CreatedAt and LastUsedAt are restored following the same rules as SetState
+// If the cache exceeds its MaxSize after the merge then the least recently used
+// entries will be dropped and an EvictedEntry will be emitted to the
+// EvictionChannel(if present) with EvictionReasonDropped
+func (c *TLRU[K, V]) MergeState(state State[K, V]) error {
+	defer c.unlock()
+	c.Lock()
+	if state.EvictionPolicy != c.config.EvictionPolicy {
+		return fmt.Errorf("tlru.MergeState: Incompatible state EvictionPolicy %s", state.EvictionPolicy.String())
+	}
+	if c.closed {
+		return fmt.Errorf("tlru.MergeState: %w", ErrClosed)
+	}
+
+	for i := len(state.Entries) - 1; i >= 0; i-- {
+		stateEntry := state.Entries[i]
+		linkedNode, exists := c.cache[stateEntry.Key]
+		if exists {
+			linkedNode.unlink()
+			c.finalize(linkedNode)
+		} else {
+			linkedNode = &doublyLinkedNode[K, V]{key: stateEntry.Key, sequence: c.nextSequence(), lock: c.newEntryLock()}
+			c.cache[stateEntry.Key] = linkedNode
+			c.index(linkedNode)
+		}
+		linkedNode.writeValue(stateEntry.Value, c.nextVersion())
+		c.rewrite(linkedNode)
+		linkedNode.counter, linkedNode.createdAt = c.restoredCounterAndCreatedAt(stateEntry)
+		linkedNode.lastUsedAt = stateEntry.LastUsedAt
+		linkedNode.ttl, linkedNode.customTTL = c.entryTTL(stateEntry.TTL)
+		linkedNode.expiresAt = c.limitLifetime(c.deadline(stateEntry.LastUsedAt, linkedNode.ttl), linkedNode.createdAt, linkedNode.ttl)
+		linkedNode.metadata = stateEntry.Metadata
+		c.pushFront(linkedNode)
+		c.scheduleExpiration(linkedNode)
+	}
+
+	c.shrink(c.config.MaxSize)
+	c.ensureGarbageCollection()
+	c.verifyRestored(stateKeys(state.Entries))
+
 	return nil
 }
 
+// View executes fn with the value of the entry that corresponds to the provided key
+// If Config.EntryLocking is enabled fn is executed under the read lock of the entry,
+// otherwise under the read lock of the cache
+// It returns false if an entry for the specified key doesn't exist or is expired
+// View doesn't mark the entry as used
+func (c *TLRU[K, V]) View(key K, fn func(value V)) bool {
+	if c.misused("View", false) {
+		return false
+	}
+	c.RLock()
+	linkedNode, exists := c.cache[key]
+	if !exists || linkedNode.isExpired(time.Now()) {
+		c.RUnlock()
+		return false
+	}
+	if linkedNode.lock == nil {
+		defer c.RUnlock()
+		c.underLock(func() { fn(linkedNode.value) })
+		return true
+	}
+	c.RUnlock()
+
+	defer linkedNode.lock.RUnlock()
+	linkedNode.lock.RLock()
+	fn(linkedNode.value)
+
+	return true
+}
+
+// Update executes fn with a pointer to the value of the entry that corresponds
+// to the provided key so that the value can be modified in place
+// If Config.EntryLocking is enabled fn is executed under the write lock of the entry,
+// otherwise under the write lock of the cache
+// It returns false if an entry for the specified key doesn't exist or is expired or if the
+// cache has been closed via CloseAndExport
+// Update doesn't mark the entry as used
+func (c *TLRU[K, V]) Update(key K, fn func(value *V)) bool {
+	if c.misused("Update", true) {
+		return false
+	}
+	if !c.config.EntryLocking {
+		defer c.unlock()
+		c.Lock()
+		linkedNode, exists := c.cache[key]
+		if c.closed || !exists || linkedNode.isExpired(time.Now()) {
+			return false
+		}
+		c.underLock(func() { fn(&linkedNode.value) })
+		linkedNode.version = c.nextVersion()
+		c.rewrite(linkedNode)
+		c.shrinkCost()
+		return true
+	}
+
+	c.RLock()
+	linkedNode, exists := c.cache[key]
+	if c.closed || !exists || linkedNode.isExpired(time.Now()) {
+		c.RUnlock()
+		return false
+	}
+	c.RUnlock()
+
+	defer c.rewriteUpdated(linkedNode)
+	defer linkedNode.lock.Unlock()
+	linkedNode.lock.Lock()
+	fn(&linkedNode.value)
+	linkedNode.version = c.nextVersion()
+
+	return true
+}
+
+// Len returns the number of entries in the cache
+// Expired entries which haven't been evicted yet are included
+func (c *TLRU[K, V]) Len() int {
+	if c.misused("Len", false) {
+		return 0
+	}
+	defer c.RUnlock()
+	c.RLock()
+
+	return len(c.cache)
+}
+
 // Has returns true if the provided keys exists in cache otherwise it returns false
 func (c *TLRU[K, V]) Has(key K) bool {
+	if c.misused("Has", false) {
+		return false
+	}
 	defer c.RUnlock()
 	c.RLock()
 	_, exists := c.cache[key]
@@ -359,53 +1519,127 @@ func (c *TLRU[K, V]) Has(key K) bool {
 	return exists
 }
 
+// doublyLinkedNode holds a cached entry. lastUsedAt is the wall clock time
+// exposed via the API while expiresAt is the monotonic deadline which
+// is used for expiration checks
 type doublyLinkedNode[K comparable, V any] struct {
 	key        K
 	value      V
 	counter    int64
 	lastUsedAt time.Time
+	expiresAt  time.Time
 	createdAt  time.Time
 	previous   *doublyLinkedNode[K, V]
 	next       *doublyLinkedNode[K, V]
+	version    uint64
+	metadata   map[string]string
+	// accesses counts the accesses since the node has been moved to the head of
+	// the list at promotedAt
+	accesses   int
+	promotedAt time.Time
+	// sequence is assigned on the creation of the node and never changes
+	sequence uint64
+	// slot is the index of the node in the samples of the cache
+	slot int
+	// expiryIndex is the index of the node in the expiries of the cache or -1 if it isn't cached
+	expiryIndex int
+	// indexedBy is the index of the node in the secondary index if Config.IndexFunc is set
+	indexedBy any
+	// cost is the cost of the node as computed by Config.Weigher if Config.MaxCost is set
+	cost int64
+	// writtenAt is the time of the last write which hasn't been coalesced and
+	// coalesced is the number of writes coalesced since then
+	writtenAt time.Time
+	coalesced int
+	// ttl is the time to live of the node and customTTL is set if it
+	// has been provided on insertion instead of being Config.TTL
+	ttl       time.Duration
+	customTTL bool
+	// warnedFor is the deadline an OperationExpiring has been emitted for
+	warnedFor time.Time
+	// timer evicts the node at expiresAt if Config.ExpirationTimers is enabled
+	timer *time.Timer
+	// lock guards value and version if Config.EntryLocking is enabled
+	lock *sync.RWMutex
 }
 
 func (d *doublyLinkedNode[K, V]) ToCacheEntry() CacheEntry[K, V] {
+	value, version := d.readVersionedValue()
 	return CacheEntry[K, V]{
 		Key:        d.key,
-		Value:      d.value,
+		Value:      value,
+		Version:    version,
 		Counter:    d.counter,
 		LastUsedAt: d.lastUsedAt,
 		CreatedAt:  d.createdAt,
+		Metadata:   d.metadata,
+		TTL:        d.ttl,
 	}
 }
 func (d *doublyLinkedNode[K, V]) ToEvictedEntry(reason evictionReason) EvictedEntry[K, V] {
 	return EvictedEntry[K, V]{
-		CacheEntry: CacheEntry[K, V]{
-			Key:        d.key,
-			Value:      d.value,
-			Counter:    d.counter,
-			LastUsedAt: d.lastUsedAt,
-			CreatedAt:  d.createdAt,
-		},
-		EvictedAt: time.Now().UTC(),
-		Reason:    reason,
+		CacheEntry: d.ToCacheEntry(),
+		EvictedAt:  time.Now().UTC(),
+		Reason:     reason,
 	}
 }
 
 func (d *doublyLinkedNode[K, V]) ToStateEntry() StateEntry[K, V] {
 	return StateEntry[K, V]{
 		Key:        d.key,
-		Value:      d.value,
+		Value:      d.readValue(),
 		Counter:    d.counter,
 		LastUsedAt: d.lastUsedAt,
 		CreatedAt:  d.createdAt,
+		Metadata:   d.metadata,
+		TTL:        d.ttl,
+	}
+}
+
+func (d *doublyLinkedNode[K, V]) readValue() V {
+	if d.lock != nil {
+		defer d.lock.RUnlock()
+		d.lock.RLock()
+	}
+
+	return d.value
+}
+
+func (d *doublyLinkedNode[K, V]) readVersionedValue() (V, uint64) {
+	if d.lock != nil {
+		defer d.lock.RUnlock()
+		d.lock.RLock()
+	}
+
+	return d.value, d.version
+}
+
+func (d *doublyLinkedNode[K, V]) writeValue(value V, version uint64) {
+	if d.lock != nil {
+		defer d.lock.Unlock()
+		d.lock.Lock()
 	}
+
+	d.value = value
+	d.version = version
 }
 
 type evictionReason int
 
 func (e evictionReason) String() string {
-	return [...]string{0: "Dropped", 1: "Expired", 2: "Deleted"}[e]
+	return [...]string{0: "Dropped", 1: "Expired", 2: "Deleted", 3: "Invalidated"}[e]
+}
+
+type operationType int
+
+func (o operationType) String() string {
+	return [...]string{0: "Set", 1: "Delete", 2: "Clear", 3: "Expiring"}[o]
+}
+
+type eventType int
+
+func (e eventType) String() string {
+	return [...]string{0: "None", 1: "Inserted", 2: "Updated"}[e]
 }
 
 type evictionPolicy int
@@ -414,80 +1648,513 @@ func (p evictionPolicy) String() string {
 	return [...]string{0: "LRA", 1: "LRI"}[p]
 }
 
+type loadLimitPolicy int
+
+func (p loadLimitPolicy) String() string {
+	return [...]string{0: "Wait", 1: "FailFast"}[p]
+}
+
+type expirationMode int
+
+func (m expirationMode) String() string {
+	return [...]string{0: "SlidingTTL", 1: "AbsoluteTTL"}[m]
+}
+
+type oversizedStatePolicy int
+
+func (p oversizedStatePolicy) String() string {
+	return [...]string{0: "Truncate", 1: "Error"}[p]
+}
+
+type loadErrorPolicy int
+
+func (p loadErrorPolicy) String() string {
+	return [...]string{0: "NotCached", 1: "Cached", 2: "Backoff"}[p]
+}
+
+// grow makes sure that n more elements can be appended to s without reallocation
+func grow[T any](s []T, n int) []T {
+	if cap(s)-len(s) >= n {
+		return s
+	}
+	grown := make([]T, len(s), len(s)+n)
+	copy(grown, s)
+
+	return grown
+}
+
+func (c *TLRU[K, V]) entriesByAge(d time.Duration, older bool) []CacheEntry[K, V] {
+	defer c.RUnlock()
+	c.RLock()
+
+	entries := make([]CacheEntry[K, V], 0)
+	now := time.Now()
+	createdBefore := now.Add(-d)
+	nextNode := c.sentinel.next
+	for nextNode != c.sentinel {
+		if !nextNode.isExpired(now) && nextNode.createdAt.Before(createdBefore) == older {
+			entries = append(entries, nextNode.ToCacheEntry())
+		}
+		nextNode = nextNode.next
+	}
+
+	return entries
+}
+
+func (c *TLRU[K, V]) delete(key K) {
+	delete(c.leases, key)
+	linkedNode, exists := c.cache[key]
+	if exists && !c.closed {
+		c.evictEntry(linkedNode, EvictionReasonDeleted)
+		c.emitOperation(Operation[K, V]{Type: OperationDelete, Key: key})
+	}
+}
+
+func (c *TLRU[K, V]) emitOperation(operation Operation[K, V]) {
+	if c.config.OperationChannel != nil {
+		operation.OccurredAt = time.Now().UTC()
+		*c.config.OperationChannel <- operation
+	}
+}
+
 func (c *TLRU[K, V]) clear() {
+	c.applyAccesses()
+	c.epoch++
+	if c.config.ExpirationTimers {
+		for _, linkedNode := range c.cache {
+			linkedNode.timer.Stop()
+		}
+	}
+	if c.config.OnFinalize != nil {
+		for _, linkedNode := range c.cache {
+			c.finalize(linkedNode)
+		}
+	}
 	if len(c.cache) > 0 {
 		c.cache = make(map[K]*doublyLinkedNode[K, V])
 		c.initializeDoublyLinkedList()
 	}
+	c.tenantSizes = nil
+	c.samples = nil
+	c.secondary = nil
+	c.totalCost = 0
+	c.expiries = nil
+	c.leases = nil
+}
+
+// index must be called while holding the lock of the cache whenever a node is added to it
+func (c *TLRU[K, V]) index(linkedNode *doublyLinkedNode[K, V]) {
+	c.admitTenant(linkedNode.key)
+	c.addSample(linkedNode)
+	c.addExpiry(linkedNode)
+	c.addIndex(linkedNode)
+	c.addCost(linkedNode)
+}
+
+// unindex must be called while holding the lock of the cache whenever a node is removed from it
+func (c *TLRU[K, V]) unindex(linkedNode *doublyLinkedNode[K, V]) {
+	c.removeTenant(linkedNode.key)
+	c.removeSample(linkedNode)
+	c.removeExpiry(linkedNode)
+	c.removeIndex(linkedNode)
+	c.removeCost(linkedNode)
+}
+
+// rewrite must be called while holding the lock of the cache whenever the value of a cached node changes
+func (c *TLRU[K, V]) rewrite(linkedNode *doublyLinkedNode[K, V]) {
+	c.reindex(linkedNode)
+	c.removeCost(linkedNode)
+	c.addCost(linkedNode)
+}
+
+// rewriteUpdated rewrites the node after its value has been updated in place under
+// the lock of the entry unless it has been removed in the meantime
+func (c *TLRU[K, V]) rewriteUpdated(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.IndexFunc == nil && c.config.MaxCost <= 0 {
+		return
+	}
+	defer c.unlock()
+	c.Lock()
+	if c.cache[linkedNode.key] == linkedNode {
+		c.rewrite(linkedNode)
+		c.shrinkCost()
+	}
+}
+
+// isStale reports whether a node that has been looked up in the provided epoch is no
+// longer the cached node of key. It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) isStale(key K, linkedNode *doublyLinkedNode[K, V], epoch uint64) bool {
+	return c.epoch != epoch || c.cache[key] != linkedNode
 }
 
 func (c *TLRU[K, V]) initializeDoublyLinkedList() {
-	var headNodeRef, tailNodeRef K
-	headNode := &doublyLinkedNode[K, V]{key: headNodeRef}
-	tailNode := &doublyLinkedNode[K, V]{key: tailNodeRef}
-	headNode.next = tailNode
-	tailNode.previous = headNode
-	c.headNode = headNode
-	c.tailNode = tailNode
+	sentinel := &doublyLinkedNode[K, V]{}
+	sentinel.next = sentinel
+	sentinel.previous = sentinel
+	c.sentinel = sentinel
 }
 
-func (c *TLRU[K, V]) handleNodeState(e Entry[K, V]) {
-	var counter int64
+func (c *TLRU[K, V]) nextVersion() uint64 {
+	return atomic.AddUint64(&c.version, 1)
+}
+
+func (c *TLRU[K, V]) newEntryLock() *sync.RWMutex {
+	if !c.config.EntryLocking {
+		return nil
+	}
+
+	return &sync.RWMutex{}
+}
+
+// touch marks the provided node as the most recently used entry and
+// increments its counter unless the entry is already expired
+func (c *TLRU[K, V]) touch(linkedNode *doublyLinkedNode[K, V], now time.Time) {
+	if !linkedNode.isExpired(now) {
+		linkedNode.counter++
+	}
+	linkedNode.lastUsedAt = now.UTC()
+	linkedNode.expiresAt = c.limitLifetime(now.Add(linkedNode.ttl), linkedNode.createdAt, linkedNode.ttl)
+	linkedNode.accesses = 0
+	linkedNode.promotedAt = now
+	linkedNode.unlink()
+	c.pushFront(linkedNode)
+	c.scheduleExpiration(linkedNode)
+}
+
+// access marks the node as used and moves it to the head of the list only if
+// Config.PromoteInterval, Config.PromoteAfter and Config.PromoteAge allow it
+func (c *TLRU[K, V]) access(linkedNode *doublyLinkedNode[K, V], now time.Time) {
+	if c.shouldPromote(linkedNode, now) {
+		c.touch(linkedNode, now)
+		return
+	}
+
+	if !linkedNode.isExpired(now) {
+		linkedNode.counter++
+	}
+	linkedNode.lastUsedAt = now.UTC()
+	linkedNode.expiresAt = c.limitLifetime(now.Add(linkedNode.ttl), linkedNode.createdAt, linkedNode.ttl)
+	linkedNode.accesses++
+	c.scheduleExpiration(linkedNode)
+}
+
+func (c *TLRU[K, V]) shouldPromote(linkedNode *doublyLinkedNode[K, V], now time.Time) bool {
+	if c.config.EvictionSamples > 0 {
+		return false
+	}
+	if c.config.PromoteInterval > 0 && now.Sub(linkedNode.promotedAt) < c.config.PromoteInterval {
+		return false
+	}
+	if c.config.PromoteAfter <= 1 && c.config.PromoteAge <= 0 {
+		return true
+	}
+	if c.config.PromoteAfter > 0 && linkedNode.accesses+1 >= c.config.PromoteAfter {
+		return true
+	}
+
+	return c.config.PromoteAge > 0 && now.Sub(linkedNode.promotedAt) >= c.config.PromoteAge
+}
+
+// deadline converts the wall clock time an entry was last used at to
+// the monotonic time the entry expires at
+func (c *TLRU[K, V]) deadline(lastUsedAt time.Time, ttl time.Duration) time.Time {
+	return time.Now().Add(ttl - time.Since(lastUsedAt))
+}
+
+// entryTTL returns the time to live of the provided entry and whether it is its own
+func (c *TLRU[K, V]) entryTTL(ttl time.Duration) (time.Duration, bool) {
+	if ttl <= 0 || ttl == c.config.TTL {
+		return c.config.TTL, false
+	}
+
+	return ttl, true
+}
+
+// limitLifetime caps the provided deadline to Config.MaxLifetime after createdAt and
+// to ttl after createdAt in the AbsoluteTTL ExpirationMode
+func (c *TLRU[K, V]) limitLifetime(expiresAt time.Time, createdAt time.Time, ttl time.Duration) time.Time {
+	if c.config.ExpirationMode == AbsoluteTTL {
+		if absoluteExpiresAt := c.deadline(createdAt, ttl); expiresAt.After(absoluteExpiresAt) {
+			expiresAt = absoluteExpiresAt
+		}
+	}
+	if c.config.MaxLifetime <= 0 {
+		return expiresAt
+	}
+	maxExpiresAt := time.Now().Add(c.config.MaxLifetime - time.Since(createdAt))
+	if expiresAt.After(maxExpiresAt) {
+		return maxExpiresAt
+	}
+
+	return expiresAt
+}
+
+// isExpired compares monotonic readings so that wall clock jumps don't affect expiration
+func (d *doublyLinkedNode[K, V]) isExpired(now time.Time) bool {
+	return now.After(d.expiresAt)
+}
+
+// pushFront marks the provided node as the most recently used entry
+func (c *TLRU[K, V]) pushFront(linkedNode *doublyLinkedNode[K, V]) {
+	linkedNode.previous = c.sentinel
+	linkedNode.next = c.sentinel.next
+	c.sentinel.next.previous = linkedNode
+	c.sentinel.next = linkedNode
+}
+
+// unlink re-wires the siblings of the node so that it is removed from the list
+func (d *doublyLinkedNode[K, V]) unlink() {
+	d.next.previous = d.previous
+	d.previous.next = d.next
+}
+
+// initialCounter returns the Counter of a newly inserted entry
+func (c *TLRU[K, V]) initialCounter() int64 {
 	if c.config.EvictionPolicy == LRI {
-		counter++
+		return 1
+	}
+
+	return 0
+}
+
+// restoredCounterAndCreatedAt returns the Counter and CreatedAt of an entry restored from a State
+func (c *TLRU[K, V]) restoredCounterAndCreatedAt(stateEntry StateEntry[K, V]) (int64, time.Time) {
+	counter, createdAt := stateEntry.Counter, stateEntry.CreatedAt
+	if c.config.ResetCountersOnRestore {
+		counter = c.initialCounter()
+	}
+	if createdAt.IsZero() {
+		createdAt = stateEntry.LastUsedAt
 	}
 
-	lastUsedAt := time.Now().UTC()
+	return counter, createdAt
+}
+
+func (c *TLRU[K, V]) handleNodeState(e Entry[K, V]) {
+	counter := c.initialCounter()
+
+	now := time.Now()
+	ttl, customTTL := c.entryTTL(e.TTL)
+	lastUsedAt := now.UTC()
+	expiresAt := now.Add(ttl)
 	if e.Timestamp != nil {
 		lastUsedAt = *e.Timestamp
+		expiresAt = c.deadline(lastUsedAt, ttl)
 	}
 	linkedNode, exists := c.cache[e.Key]
 	if exists {
-		if c.config.TTL >= time.Since(linkedNode.lastUsedAt) {
-			linkedNode.counter++
-		}
+		c.finalize(linkedNode)
+		linkedNode.writeValue(e.Value, c.nextVersion())
+		c.rewrite(linkedNode)
+		linkedNode.metadata = e.Metadata
+		linkedNode.ttl, linkedNode.customTTL = ttl, customTTL
+		linkedNode.writtenAt = now
+		c.touch(linkedNode, now)
 		linkedNode.lastUsedAt = lastUsedAt
+		linkedNode.expiresAt = c.limitLifetime(expiresAt, linkedNode.createdAt, ttl)
+		c.scheduleExpiration(linkedNode)
+		return
+	}
 
-		// Re-wire siblings of linkedNode
-		linkedNode.next.previous = linkedNode.previous
-		linkedNode.previous.next = linkedNode.next
-	} else {
-		linkedNode = &doublyLinkedNode[K, V]{
-			key:        e.Key,
-			value:      e.Value,
-			counter:    counter,
-			lastUsedAt: lastUsedAt,
-			previous:   c.headNode,
-			next:       c.headNode.next,
-			createdAt:  time.Now().UTC(),
+	linkedNode = &doublyLinkedNode[K, V]{
+		key:        e.Key,
+		value:      e.Value,
+		counter:    counter,
+		lastUsedAt: lastUsedAt,
+		expiresAt:  c.limitLifetime(expiresAt, now, ttl),
+		createdAt:  now.UTC(),
+		version:    c.nextVersion(),
+		sequence:   c.nextSequence(),
+		metadata:   e.Metadata,
+		promotedAt: now,
+		writtenAt:  now,
+		ttl:        ttl,
+		customTTL:  customTTL,
+		lock:       c.newEntryLock(),
+	}
+	if c.churn != nil {
+		previousCounter, dropped := c.churn.inserted(e.Key)
+		if dropped && c.config.GhostAdmission {
+			linkedNode.counter += previousCounter
 		}
+	}
+	c.cache[e.Key] = linkedNode
+	c.index(linkedNode)
+	c.pushFront(linkedNode)
+	c.scheduleExpiration(linkedNode)
+}
+
+// finalize hands the current value of the node over to Config.OnFinalize
+// It is deferred until the last Checkin if the entry is borrowed
+func (c *TLRU[K, V]) finalize(linkedNode *doublyLinkedNode[K, V]) {
+	if c.config.OnFinalize == nil {
+		return
+	}
+	if borrow := c.borrows[linkedNode.key]; borrow != nil {
+		borrow.retired = append(borrow.retired, linkedNode.readValue())
+		return
+	}
+	c.finalized = append(c.finalized, finalizedValue[K, V]{key: linkedNode.key, value: linkedNode.readValue()})
+}
+
+// scheduleExpiration (re)arms the timer of the node if Config.ExpirationTimers is enabled
+// It must be called while holding the lock of the cache whenever expiresAt changes
+func (c *TLRU[K, V]) scheduleExpiration(linkedNode *doublyLinkedNode[K, V]) {
+	c.fixExpiry(linkedNode)
+	if !c.config.ExpirationTimers {
+		return
+	}
+	if linkedNode.timer == nil {
+		linkedNode.timer = time.AfterFunc(time.Until(linkedNode.expiresAt), func() {
+			c.expire(linkedNode)
+		})
+		return
+	}
+	linkedNode.timer.Reset(time.Until(linkedNode.expiresAt))
+}
+
+// expire evicts the node if it is still cached and expired
+func (c *TLRU[K, V]) expire(linkedNode *doublyLinkedNode[K, V]) {
+	defer c.unlock()
+	c.Lock()
 
-		c.cache[e.Key] = linkedNode
+	if c.cache[linkedNode.key] != linkedNode {
+		return
+	}
+	if !linkedNode.isExpired(time.Now()) {
+		c.scheduleExpiration(linkedNode)
+		return
 	}
+	c.evictEntry(linkedNode, EvictionReasonExpired)
+}
 
-	// Re-wire headNode
-	linkedNode.previous = c.headNode
-	linkedNode.next = c.headNode.next
-	c.headNode.next.previous = linkedNode
-	c.headNode.next = linkedNode
+// shrink drops the least recently used entries which aren't borrowed until the cache
+// holds at most n entries. It has no effect if Config.MaxSize is not set
+// If Config.Tenant or Config.EvictionSamples is set the entries are dropped via
+// shrinkFairly or shrinkSampled respectively
+func (c *TLRU[K, V]) shrink(n int) {
+	if c.config.MaxSize == 0 && c.config.MaxCost <= 0 {
+		return
+	}
+	c.applyAccesses()
+	c.shrinkCost()
+	if c.config.MaxSize == 0 {
+		return
+	}
+	if c.config.Tenant != nil {
+		c.shrinkFairly(n)
+		return
+	}
+	if c.config.EvictionSamples > 0 {
+		c.shrinkSampled(n)
+		return
+	}
+	previousNode := c.sentinel.previous
+	for len(c.cache) > n && previousNode != c.sentinel {
+		droppedNode := previousNode
+		previousNode = previousNode.previous
+		if c.borrows[droppedNode.key] == nil {
+			c.evictEntry(droppedNode, EvictionReasonDropped)
+		}
+	}
 }
 
+// evictEntry removes the node from the cache. Borrowed entries are only removed
+// with EvictionReasonDeleted and the rest of the evictions are retried on Checkin
 func (c *TLRU[K, V]) evictEntry(evictedNode *doublyLinkedNode[K, V], reason evictionReason) {
-	evictedNode.previous.next = evictedNode.next
-	evictedNode.next.previous = evictedNode.previous
+	if evictedNode == c.sentinel || c.closed {
+		return
+	}
+	if reason != EvictionReasonDeleted && c.borrows[evictedNode.key] != nil {
+		return
+	}
+	evictedNode.unlink()
 	delete(c.cache, evictedNode.key)
+	c.unindex(evictedNode)
+	c.operations.evicted(reason)
+	if c.churn != nil && reason != EvictionReasonDeleted {
+		c.churn.evicted(evictedNode.key, evictedNode.counter, reason)
+	}
+	c.finalize(evictedNode)
+	if evictedNode.timer != nil {
+		evictedNode.timer.Stop()
+	}
 
+	// Expired entries are only batched while a garbage collection is scheduled to drain them
+	if reason == EvictionReasonExpired && c.config.OnExpiredBatch != nil && c.garbageCollectionTimer != nil {
+		c.expiredEntries = append(c.expiredEntries, evictedNode.ToEvictedEntry(reason))
+	}
 	if c.config.EvictionChannel != nil {
-		*c.config.EvictionChannel <- evictedNode.ToEvictedEntry(reason)
+		c.deliverEviction(evictedNode.ToEvictedEntry(reason))
+	}
+	if c.config.OnEvict != nil {
+		c.evicted = append(c.evicted, evictedNode.ToEvictedEntry(reason))
 	}
 }
 
-func (c *TLRU[K, V]) evictExpiredEntries() {
-	previousNode := c.tailNode.previous
-	for previousNode != nil && previousNode != c.headNode {
-		if c.config.TTL < time.Since(previousNode.lastUsedAt) {
-			c.evictEntry(previousNode, EvictionReasonExpired)
-		}
-		previousNode = previousNode.previous
+// ensureGarbageCollection schedules the garbage collection unless it is already scheduled
+// It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) ensureGarbageCollection() {
+	if c.garbageCollectionTimer == nil {
+		c.scheduleGarbageCollection()
+	}
+}
+
+// scheduleGarbageCollection must be called while holding the lock of the cache
+// Garbage collections which have been scheduled before are not rescheduled anymore
+func (c *TLRU[K, V]) scheduleGarbageCollection() {
+	c.stopGarbageCollection()
+	if c.closed {
+		return
+	}
+
+	generation := c.garbageCollectionGeneration
+	c.garbageCollectionTimer = time.AfterFunc(c.garbageCollectionInterval, func() {
+		c.periodicGarbageCollection(generation)
+	})
+}
+
+// stopGarbageCollection must be called while holding the lock of the cache
+func (c *TLRU[K, V]) stopGarbageCollection() {
+	if c.garbageCollectionTimer != nil {
+		c.garbageCollectionTimer.Stop()
+		c.garbageCollectionTimer = nil
+	}
+	c.garbageCollectionGeneration++
+}
+
+// periodicGarbageCollection collects the garbage and schedules the next cycle unless it has
+// been rescheduled. Once the cache is empty no cycle is scheduled until an entry is inserted
+func (c *TLRU[K, V]) periodicGarbageCollection(generation uint64) {
+	c.collectGarbage()
+
+	defer c.unlock()
+	c.Lock()
+	if generation != c.garbageCollectionGeneration {
+		return
+	}
+	if len(c.cache) > 0 {
+		c.scheduleGarbageCollection()
+	} else {
+		c.stopGarbageCollection()
+	}
+}
+
+// collectGarbage evicts all expired entries and hands them over to Config.OnExpiredBatch
+func (c *TLRU[K, V]) collectGarbage() {
+	c.Lock()
+	startedAt := time.Now()
+	c.evictExpiredEntries()
+	c.evictExpiredNegativeEntries()
+	c.garbageCollections++
+	c.garbageCollectionTime += time.Since(startedAt)
+	expiredEntries := c.expiredEntries
+	c.expiredEntries = nil
+	c.unlock()
+
+	if len(expiredEntries) > 0 {
+		c.protect("OnExpiredBatch", func() {
+			c.config.OnExpiredBatch(expiredEntries)
+		})
 	}
 }
@@ -4,7 +4,10 @@
 package tlru
 
 import (
+	"fmt"
+	"math/rand"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -113,6 +116,45 @@ func BenchmarkGet_FullCache_100000_Parallel_LRA(b *testing.B) {
 	})
 }
 
+func BenchmarkGet_FullCache_100000_Parallel_Sampled_LRA(b *testing.B) {
+	config := lraConfig
+	config.EvictionSamples = 5
+	cache := New(config)
+
+	for i := 0; i < bigSize; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+
+	b.ResetTimer()
+
+	var i int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cache.Get(strconv.Itoa(int(atomic.AddInt64(&i, 1) % bigSize)))
+		}
+	})
+}
+
+func BenchmarkGet_FullCache_100000_Parallel_Sampled_Buffered_LRA(b *testing.B) {
+	config := lraConfig
+	config.EvictionSamples = 5
+	config.AccessBufferSize = 64
+	cache := New(config)
+
+	for i := 0; i < bigSize; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+
+	b.ResetTimer()
+
+	var i int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cache.Get(strconv.Itoa(int(atomic.AddInt64(&i, 1) % bigSize)))
+		}
+	})
+}
+
 func BenchmarkGet_FullCache_100000_Parallel_LRI(b *testing.B) {
 	cache := New(lriConfig)
 
@@ -299,6 +341,18 @@ func BenchmarkSet_Parallel_LRI(b *testing.B) {
 	})
 }
 
+func BenchmarkSet_Sharded_Parallel_LRI(b *testing.B) {
+	cache := NewSharded(lriConfig, 0)
+
+	var i int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := atomic.AddInt64(&i, 1)
+			cache.Set(strconv.FormatInt(i, 10), int(i))
+		}
+	})
+}
+
 func BenchmarkDelete_FullCache_100000_Parallel_LRA(b *testing.B) {
 	cache := New(lraConfig)
 
@@ -475,3 +529,197 @@ func BenchmarkEntries_FullCache_100000_LRI(b *testing.B) {
 		cache.Entries()
 	}
 }
+
+type structKey struct {
+	tenant uint32
+	id     int64
+}
+
+func newInt64Cache(policy evictionPolicy) *TLRU[int64, int] {
+	cache := New(Config[int64, int]{
+		MaxSize:        bigSize,
+		TTL:            time.Minute,
+		EvictionPolicy: policy,
+	})
+	for i := 0; i < bigSize; i++ {
+		cache.Set(int64(i), i)
+	}
+
+	return cache
+}
+
+func newStructKeyCache(policy evictionPolicy) *TLRU[structKey, int] {
+	cache := New(Config[structKey, int]{
+		MaxSize:        bigSize,
+		TTL:            time.Minute,
+		EvictionPolicy: policy,
+	})
+	for i := 0; i < bigSize; i++ {
+		cache.Set(structKey{tenant: uint32(i % smallSize), id: int64(i)}, i)
+	}
+
+	return cache
+}
+
+func BenchmarkGet_Int64Key_ExistingKey_LRA(b *testing.B) {
+	cache := newInt64Cache(LRA)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Get(int64(i % bigSize))
+	}
+}
+
+func BenchmarkGet_Int64Key_ExistingKey_LRI(b *testing.B) {
+	cache := newInt64Cache(LRI)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Get(int64(i % bigSize))
+	}
+}
+
+func BenchmarkGet_Int64Key_FullCache_100000_Parallel_LRA(b *testing.B) {
+	cache := newInt64Cache(LRA)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			cache.Get(int64(i % bigSize))
+		}
+	})
+}
+
+func BenchmarkGet_Int64Key_FullCache_100000_Parallel_LRI(b *testing.B) {
+	cache := newInt64Cache(LRI)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			cache.Get(int64(i % bigSize))
+		}
+	})
+}
+
+func BenchmarkSet_Int64Key_LRA(b *testing.B) {
+	cache := New(Config[int64, int]{MaxSize: bigSize, TTL: time.Minute, EvictionPolicy: LRA})
+	for i := 0; i < b.N; i++ {
+		cache.Set(int64(i), i)
+	}
+}
+
+func BenchmarkSet_Int64Key_LRI(b *testing.B) {
+	cache := New(Config[int64, int]{MaxSize: bigSize, TTL: time.Minute, EvictionPolicy: LRI})
+	for i := 0; i < b.N; i++ {
+		cache.Set(int64(i), i)
+	}
+}
+
+func BenchmarkGet_StructKey_ExistingKey_LRA(b *testing.B) {
+	cache := newStructKeyCache(LRA)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := i % bigSize
+		cache.Get(structKey{tenant: uint32(id % smallSize), id: int64(id)})
+	}
+}
+
+func BenchmarkGet_StructKey_ExistingKey_LRI(b *testing.B) {
+	cache := newStructKeyCache(LRI)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := i % bigSize
+		cache.Get(structKey{tenant: uint32(id % smallSize), id: int64(id)})
+	}
+}
+
+func BenchmarkSet_StructKey_LRA(b *testing.B) {
+	cache := New(Config[structKey, int]{MaxSize: bigSize, TTL: time.Minute, EvictionPolicy: LRA})
+	for i := 0; i < b.N; i++ {
+		cache.Set(structKey{tenant: uint32(i % smallSize), id: int64(i)}, i)
+	}
+}
+
+func BenchmarkSet_StructKey_LRI(b *testing.B) {
+	cache := New(Config[structKey, int]{MaxSize: bigSize, TTL: time.Minute, EvictionPolicy: LRI})
+	for i := 0; i < b.N; i++ {
+		cache.Set(structKey{tenant: uint32(i % smallSize), id: int64(i)}, i)
+	}
+}
+
+// mixedWorkload describes a parallel read/write workload whose keys follow a zipfian distribution
+// The hit ratio is driven by the ratio between the number of distinct keys and the size of the cache
+type mixedWorkload struct {
+	readRatio float64
+	keys      int
+}
+
+var mixedWorkloads = []mixedWorkload{
+	{readRatio: 0.9, keys: smallSize * 1000},
+	{readRatio: 0.9, keys: bigSize},
+	{readRatio: 0.5, keys: smallSize * 1000},
+	{readRatio: 0.5, keys: bigSize},
+}
+
+var mixedConfigs = map[string]Config[string, int]{
+	"LRA":                 {MaxSize: smallSize * 1000, TTL: time.Minute, EvictionPolicy: LRA},
+	"LRI":                 {MaxSize: smallSize * 1000, TTL: time.Minute, EvictionPolicy: LRI},
+	"LRA_PromoteAfter":    {MaxSize: smallSize * 1000, TTL: time.Minute, EvictionPolicy: LRA, PromoteAfter: 4},
+	"LRA_PromoteInterval": {MaxSize: smallSize * 1000, TTL: time.Minute, EvictionPolicy: LRA, PromoteInterval: time.Millisecond},
+}
+
+// BenchmarkMixed_Zipfian_Parallel reports the hit ratio of every policy next to its latency
+// Run it with -cpu to measure the impact of contention, e.g. go test -bench=Mixed -cpu=1,4,16
+func BenchmarkMixed_Zipfian_Parallel(b *testing.B) {
+	for _, name := range []string{"LRA", "LRI", "LRA_PromoteAfter", "LRA_PromoteInterval"} {
+		for _, workload := range mixedWorkloads {
+			b.Run(fmt.Sprintf("%s/Reads_%d%%/Keys_%d", name, int(workload.readRatio*100), workload.keys), func(b *testing.B) {
+				benchmarkMixed(b, mixedConfigs[name], workload)
+			})
+		}
+	}
+}
+
+func benchmarkMixed(b *testing.B, config Config[string, int], workload mixedWorkload) {
+	keys := make([]string, workload.keys)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	cache := New(config)
+	for i := 0; i < config.MaxSize && i < len(keys); i++ {
+		cache.Set(keys[i], i)
+	}
+
+	var seed, reads, hits int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		random := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+		zipf := rand.NewZipf(random, 1.1, 1, uint64(len(keys)-1))
+		var localReads, localHits int64
+		for pb.Next() {
+			key := keys[zipf.Uint64()]
+			if random.Float64() >= workload.readRatio {
+				cache.Set(key, 0)
+				continue
+			}
+			localReads++
+			if cache.Get(key) != nil {
+				localHits++
+				continue
+			}
+			cache.Set(key, 0)
+		}
+		atomic.AddInt64(&reads, localReads)
+		atomic.AddInt64(&hits, localHits)
+	})
+
+	if reads > 0 {
+		b.ReportMetric(float64(hits)/float64(reads), "hit-ratio")
+	}
+}
@@ -4,6 +4,7 @@
 package tlru
 
 import (
+	"errors"
 	"strconv"
 	"sync"
 	"testing"
@@ -71,6 +72,12 @@ func TestLRUCacheDelete(t *testing.T) {
 		cachedEntry2 = cache.Get(entry2.Key)
 		assert.Equal(entry1.Value, cachedEntry1.Value)
 		assert.Nil(cachedEntry2)
+
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, time.Now().Add(-time.Hour))
+		assert.True(cache.DeleteIfPresent(entry1.Key))
+		assert.False(cache.DeleteIfPresent(entry1.Key))
+		assert.False(cache.DeleteIfPresent(entry3.Key))
+		assert.Equal(0, cache.Len())
 	}
 }
 
@@ -129,6 +136,326 @@ func TestLRUCacheEntries(t *testing.T) {
 	}
 }
 
+func TestLRUCacheAppendKeysAndAppendEntries(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+
+		keysBuffer := make([]string, 0, 10)
+		keys := cache.AppendKeys(keysBuffer[:0])
+		assert.ElementsMatch([]string{entry1.Key, entry2.Key}, keys)
+		assert.Equal(&keysBuffer[:1][0], &keys[0])
+
+		keys = cache.AppendKeys([]string{entry3.Key})
+		assert.Equal(3, len(keys))
+		assert.Equal(entry3.Key, keys[0])
+
+		entriesBuffer := make([]CacheEntry[string, int], 0, 10)
+		entries := cache.AppendEntries(entriesBuffer[:0])
+		assert.Equal(2, len(entries))
+		assert.Equal(&entriesBuffer[:1][0], &entries[0])
+	}
+}
+
+func TestLRUCacheEntriesOlderAndYoungerThan(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		time.Sleep(20 * time.Millisecond)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.SetWithTimestamp(entry4.Key, entry4.Value, time.Now().Add(-time.Hour))
+
+		olderEntries := cache.EntriesOlderThan(10 * time.Millisecond)
+		assert.Equal(2, len(olderEntries))
+		assert.Equal(entry2.Key, olderEntries[0].Key)
+		assert.Equal(entry1.Key, olderEntries[1].Key)
+
+		youngerEntries := cache.EntriesYoungerThan(10 * time.Millisecond)
+		assert.Equal(1, len(youngerEntries))
+		assert.Equal(entry3.Key, youngerEntries[0].Key)
+	}
+}
+
+func TestLRUCacheEpoch(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		assert.Equal(uint64(0), cache.Epoch())
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Get(entry1.Key)
+		cache.Delete(entry1.Key)
+		assert.Equal(uint64(0), cache.Epoch())
+
+		cache.Clear()
+		assert.Equal(uint64(1), cache.Epoch())
+
+		state := cache.GetState()
+		assert.NoError(cache.SetState(state))
+		assert.Equal(uint64(2), cache.Epoch())
+	}
+}
+
+func TestLRUCachePromoteAfter(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:        2,
+		TTL:            time.Minute,
+		EvictionPolicy: LRA,
+		PromoteAfter:   2,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+
+	cachedEntry := cache.Get(entry1.Key)
+	assert.Equal(int64(1), cachedEntry.Counter)
+	cache.Set(entry3.Key, entry3.Value)
+	assert.Nil(cache.Peek(entry1.Key))
+	assert.NotNil(cache.Peek(entry2.Key))
+
+	cache.Get(entry2.Key)
+	cache.Get(entry2.Key)
+	cache.Set(entry4.Key, entry4.Value)
+	assert.Nil(cache.Peek(entry3.Key))
+	assert.NotNil(cache.Peek(entry2.Key))
+}
+
+func TestLRUCachePromoteAge(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:        2,
+		TTL:            time.Minute,
+		EvictionPolicy: LRA,
+		PromoteAge:     20 * time.Millisecond,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+
+	cache.Get(entry1.Key)
+	assert.Equal(entry1.Key, cache.sentinel.previous.key)
+
+	time.Sleep(30 * time.Millisecond)
+	cache.Get(entry1.Key)
+	assert.Equal(entry1.Key, cache.sentinel.next.key)
+	assert.Equal(int64(2), cache.Peek(entry1.Key).Counter)
+}
+
+func TestLRUCachePromoteInterval(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:         2,
+		TTL:             time.Minute,
+		EvictionPolicy:  LRA,
+		PromoteInterval: 20 * time.Millisecond,
+	}
+	cache := New(config)
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry2.Key, entry2.Value)
+
+	cache.Get(entry1.Key)
+	cache.Get(entry1.Key)
+	assert.Equal(entry1.Key, cache.sentinel.previous.key)
+	assert.Equal(int64(2), cache.Peek(entry1.Key).Counter)
+
+	time.Sleep(30 * time.Millisecond)
+	cache.Get(entry1.Key)
+	assert.Equal(entry1.Key, cache.sentinel.next.key)
+}
+
+func TestLRUCacheExpirationTimers(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 2)
+		config := Config[string, int]{
+			MaxSize:                   10,
+			TTL:                       20 * time.Millisecond,
+			EvictionChannel:           &evictionChannel,
+			EvictionPolicy:            policy,
+			GarbageCollectionInterval: time.Hour,
+			ExpirationTimers:          true,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Delete(entry2.Key)
+		<-evictionChannel
+
+		select {
+		case evictedEntry := <-evictionChannel:
+			assert.Equal(entry1.Key, evictedEntry.Key)
+			assert.Equal(EvictionReasonExpired, evictedEntry.Reason)
+		case <-time.After(time.Second):
+			assert.Fail("entry has not been evicted by its timer")
+		}
+		assert.Equal(0, cache.Len())
+
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Clear()
+		time.Sleep(40 * time.Millisecond)
+		assert.Equal(0, len(evictionChannel))
+	}
+}
+
+func TestLRUCacheLRUKeyAndMRUKey(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		_, ok := cache.LRUKey()
+		assert.False(ok)
+		_, ok = cache.MRUKey()
+		assert.False(ok)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+
+		key, ok := cache.LRUKey()
+		assert.True(ok)
+		assert.Equal(entry1.Key, key)
+		key, ok = cache.MRUKey()
+		assert.True(ok)
+		assert.Equal(entry3.Key, key)
+
+		cache.Get(entry1.Key)
+		key, _ = cache.LRUKey()
+		if policy == LRA {
+			assert.Equal(entry2.Key, key)
+		} else {
+			assert.Equal(entry1.Key, key)
+		}
+	}
+}
+
+func TestLRUCacheExpiringWithin(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		now := time.Now()
+		cache.SetWithTimestamp(entry1.Key, entry1.Value, now.Add(-55*time.Second))
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, now.Add(-50*time.Second))
+		cache.Set(entry3.Key, entry3.Value)
+		cache.SetWithTimestamp(entry4.Key, entry4.Value, now.Add(-time.Hour))
+
+		assert.Equal([]string{entry2.Key, entry1.Key}, cache.ExpiringWithin(20*time.Second))
+		assert.Equal([]string{entry1.Key}, cache.ExpiringWithin(7*time.Second))
+		assert.Empty(cache.ExpiringWithin(0))
+	}
+}
+
+func TestLRUCacheSoftMaxSize(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:         2,
+			SoftMaxSize:     3,
+			TTL:             time.Minute,
+			EvictionChannel: &evictionChannel,
+			EvictionPolicy:  policy,
+		}
+		cache := New(config)
+
+		cache.Lock()
+		for _, entry := range []Entry[string, int]{entry1, entry2, entry3, entry4} {
+			cache.insert(entry)
+		}
+		assert.Equal(3, len(cache.cache))
+		assert.Equal(1, len(evictionChannel))
+		cache.Unlock()
+
+		assert.Eventually(func() bool { return cache.Len() == 2 }, time.Second, time.Millisecond)
+		assert.Equal(entry1.Key, (<-evictionChannel).Key)
+		assert.Equal(entry2.Key, (<-evictionChannel).Key)
+		assert.NotNil(cache.Peek(entry3.Key))
+		assert.NotNil(cache.Peek(entry4.Key))
+	}
+}
+
+func TestLRUCacheTTLDistribution(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		now := time.Now()
+		cache.SetWithTimestamp(entry1.Key, entry1.Value, now.Add(-55*time.Second))
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, now.Add(-30*time.Second))
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, now.Add(-25*time.Second))
+		cache.Set(entry4.Key, entry4.Value)
+		cache.SetWithTimestamp("expired", 0, now.Add(-time.Hour))
+
+		buckets := []time.Duration{10 * time.Second, 40 * time.Second}
+		assert.Equal([]int{1, 2, 1}, cache.TTLDistribution(buckets))
+		assert.Equal([]int{4}, cache.TTLDistribution(nil))
+	}
+}
+
+func TestLRUCacheAgeAndCounterDistribution(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		now := time.Now()
+		cache.SetState(State[string, int]{
+			EvictionPolicy: policy,
+			Entries: []StateEntry[string, int]{
+				{Key: entry1.Key, Value: entry1.Value, Counter: 1, LastUsedAt: now, CreatedAt: now.Add(-time.Hour)},
+				{Key: entry2.Key, Value: entry2.Value, Counter: 5, LastUsedAt: now, CreatedAt: now.Add(-time.Minute)},
+				{Key: entry3.Key, Value: entry3.Value, Counter: 20, LastUsedAt: now, CreatedAt: now},
+				{Key: "expired", Value: 0, Counter: 20, LastUsedAt: now.Add(-time.Hour), CreatedAt: now.Add(-time.Hour)},
+			},
+		})
+
+		assert.Equal([]int{1, 1, 1}, cache.AgeDistribution([]time.Duration{time.Second, 30 * time.Minute}))
+		assert.Equal([]int{1, 1, 1}, cache.CounterDistribution([]int64{1, 10}))
+		assert.Equal([]int{3}, cache.CounterDistribution(nil))
+	}
+}
+
 func TestCacheClear(t *testing.T) {
 	assert := assert.New(t)
 	for _, policy := range policies {
@@ -158,67 +485,456 @@ func TestCacheClear(t *testing.T) {
 		assert.Nil(cachedEntry1)
 		assert.Nil(cachedEntry2)
 
-		cache.Set(entry2.Key, entry2.Value)
-		cachedEntry2 = cache.Get(entry2.Key)
-		assert.Equal(entry2.Value, cachedEntry2.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cachedEntry2 = cache.Get(entry2.Key)
+		assert.Equal(entry2.Value, cachedEntry2.Value)
+
+		cache.Set(entry1.Key, entry1.Value)
+		time.Sleep(2 * config.TTL)
+		entriesAfterTTLExpired := cache.Keys()
+		assert.Equal(0, len(entriesAfterTTLExpired))
+	}
+}
+
+func TestLRUCacheTTLEvictionDaemon(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 0)
+		ttl := 5 * time.Millisecond
+		config := Config[string, int]{
+			MaxSize:                   10,
+			TTL:                       ttl,
+			EvictionChannel:           &evictionChannel,
+			EvictionPolicy:            policy,
+			GarbageCollectionInterval: ttl,
+		}
+		cache := New(config)
+
+		var (
+			evictedEntry1 EvictedEntry[string, int]
+			evictedEntry2 EvictedEntry[string, int]
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			evictedEntry1 = <-evictionChannel
+			evictedEntry2 = <-evictionChannel
+		}()
+
+		expiredEntryTimestamp := time.Date(1900, 2, 1, 12, 30, 0, 0, time.UTC)
+		expiredEntry1 := Entry[string, int]{Key: "expired-1", Value: 1, Timestamp: &expiredEntryTimestamp}
+		expiredEntry2 := Entry[string, int]{Key: "expired-2", Value: 2, Timestamp: &expiredEntryTimestamp}
+		nonExpiredEntryTimestamp := time.Now().Add(time.Minute)
+		nonExpiredEntry := Entry[string, int]{Key: "non-expired", Value: 1, Timestamp: &nonExpiredEntryTimestamp}
+
+		cache.SetWithTimestamp(nonExpiredEntry.Key, nonExpiredEntry.Value, *nonExpiredEntry.Timestamp)
+		cache.SetWithTimestamp(expiredEntry1.Key, expiredEntry1.Value, *expiredEntry1.Timestamp)
+		cache.SetWithTimestamp(expiredEntry2.Key, expiredEntry2.Value, *expiredEntry2.Timestamp)
+		wg.Wait()
+
+		assert.Equal(expiredEntry1.Key, evictedEntry1.Key)
+		assert.Equal(expiredEntry2.Key, evictedEntry2.Key)
+		assert.Equal(EvictionReasonExpired, evictedEntry1.Reason)
+		assert.Equal(EvictionReasonExpired, evictedEntry2.Reason)
+
+		cachedNonExpiredEntry := cache.Get(nonExpiredEntry.Key)
+		cachedExpiredEntry1 := cache.Get(expiredEntry1.Key)
+		cachedExpiredEntry2 := cache.Get(expiredEntry2.Key)
+
+		assert.Equal(nonExpiredEntry.Value, cachedNonExpiredEntry.Value)
+		assert.Nil(cachedExpiredEntry1)
+		assert.Nil(cachedExpiredEntry2)
+	}
+}
+
+func TestLRUCacheSetIfAbsentAndSetIfPresent(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		assert.False(cache.SetIfPresent(entry1.Key, entry1.Value))
+		assert.Nil(cache.Get(entry1.Key))
+
+		assert.True(cache.SetIfAbsent(entry1.Key, entry1.Value))
+		assert.False(cache.SetIfAbsent(entry1.Key, 10))
+		assert.Equal(entry1.Value, cache.Get(entry1.Key).Value)
+
+		assert.True(cache.SetIfPresent(entry1.Key, 10))
+		assert.Equal(10, cache.Get(entry1.Key).Value)
+
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, time.Now().Add(-time.Hour))
+		assert.False(cache.SetIfPresent(entry2.Key, 20))
+		assert.True(cache.SetIfAbsent(entry2.Key, 20))
+		assert.Equal(20, cache.Get(entry2.Key).Value)
+	}
+}
+
+func TestLRUCacheSwap(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		previous, loaded, err := cache.Swap(entry1.Key, entry1.Value)
+		assert.NoError(err)
+		assert.False(loaded)
+		assert.Equal(0, previous)
+
+		previous, loaded, err = cache.Swap(entry1.Key, 10)
+		assert.NoError(err)
+		assert.True(loaded)
+		assert.Equal(entry1.Value, previous)
+		assert.Equal(10, cache.Get(entry1.Key).Value)
+
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, time.Now().Add(-time.Hour))
+		previous, loaded, err = cache.Swap(entry2.Key, 20)
+		assert.NoError(err)
+		assert.True(loaded)
+		assert.Equal(entry2.Value, previous)
+		assert.Equal(20, cache.Get(entry2.Key).Value)
+
+		_, err = cache.CloseAndExport()
+		assert.NoError(err)
+		_, loaded, err = cache.Swap(entry1.Key, 30)
+		assert.True(errors.Is(err, ErrClosed))
+		assert.False(loaded)
+	}
+}
+
+func TestLRUCacheOnFinalize(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		finalized := make(map[string][]int)
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			OnFinalize: func(key string, value int) {
+				finalized[key] = append(finalized[key], value)
+			},
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Swap(entry1.Key, 10)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Delete(entry2.Key)
+		cache.SetWithTimestamp(entry4.Key, entry4.Value, time.Now().Add(-time.Hour))
+		cache.Get(entry4.Key)
+		cache.Set("entry5", 5)
+		cache.Clear()
+
+		assert.Equal(map[string][]int{
+			entry1.Key: {entry1.Value, 10},
+			entry2.Key: {entry2.Value},
+			entry3.Key: {entry3.Value},
+			entry4.Key: {entry4.Value},
+			"entry5":   {5},
+		}, finalized)
+	}
+}
+
+func TestLRUCacheMetadata(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 1)
+		config := Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+		}
+		cache := New(config)
+		metadata := map[string]string{"tenant": "acme"}
+
+		assert.NoError(cache.SetWithMetadata(entry1.Key, entry1.Value, metadata))
+		cache.Set(entry2.Key, entry2.Value)
+		assert.Equal(metadata, cache.Get(entry1.Key).Metadata)
+		assert.Nil(cache.Get(entry2.Key).Metadata)
+
+		state := cache.GetState()
+		rehydratedCache := New(config)
+		assert.NoError(rehydratedCache.SetState(state))
+		assert.Equal(metadata, rehydratedCache.Peek(entry1.Key).Metadata)
+
+		cache.Delete(entry1.Key)
+		assert.Equal(metadata, (<-evictionChannel).Metadata)
+	}
+}
+
+func TestLRUCacheVersions(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 1)
+		config := Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		version1 := cache.Get(entry1.Key).Version
+		version2 := cache.Get(entry2.Key).Version
+		assert.Less(version1, version2)
+		assert.Equal(version1, cache.Get(entry1.Key).Version)
+
+		cache.Update(entry1.Key, func(value *int) {
+			*value++
+		})
+		updatedVersion1 := cache.Get(entry1.Key).Version
+		assert.Greater(updatedVersion1, version2)
+
+		assert.True(errors.Is(cache.SetIfVersion(entry1.Key, 10, version1), ErrVersionMismatch))
+		assert.NoError(cache.SetIfVersion(entry1.Key, 10, updatedVersion1))
+		assert.Equal(10, cache.Get(entry1.Key).Value)
+		assert.Greater(cache.Get(entry1.Key).Version, updatedVersion1)
+
+		assert.True(errors.Is(cache.SetIfVersion(entry3.Key, entry3.Value, 1), ErrVersionMismatch))
+		assert.NoError(cache.SetIfVersion(entry3.Key, entry3.Value, 0))
+		assert.Equal(entry3.Value, cache.Get(entry3.Key).Value)
+
+		version3 := cache.Get(entry3.Key).Version
+		cache.Delete(entry3.Key)
+		assert.Equal(version3, (<-evictionChannel).Version)
+	}
+}
+
+func TestLRUCacheSetWithTTL(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.SetWithTTL(entry2.Key, entry2.Value, 20*time.Millisecond)
+		cache.SetWithTTL(entry3.Key, entry3.Value, time.Hour)
+
+		assert.Equal(time.Minute, cache.Peek(entry1.Key).TTL)
+		assert.Equal(20*time.Millisecond, cache.Peek(entry2.Key).TTL)
+		assert.Equal(time.Hour, cache.Get(entry3.Key).TTL)
+
+		state := cache.GetState()
+		restored := New(config)
+		assert.NoError(restored.SetState(state))
+		assert.Equal(20*time.Millisecond, restored.Peek(entry2.Key).TTL)
+
+		cache.SetTTL(2 * time.Minute)
+		assert.Equal(2*time.Minute, cache.Peek(entry1.Key).TTL)
+		assert.Equal(time.Hour, cache.Peek(entry3.Key).TTL)
+
+		time.Sleep(30 * time.Millisecond)
+		assert.Nil(cache.Get(entry2.Key))
+		assert.Nil(restored.Get(entry2.Key))
+		assert.NotNil(cache.Get(entry1.Key))
+		assert.Equal(2, len(cache.Keys()))
+	}
+}
+
+func TestLRUCacheOperationChannel(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		operationChannel := make(chan Operation[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:          1,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			OperationChannel: &operationChannel,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Delete(entry1.Key)
+		cache.Delete(entry2.Key)
+		cache.Clear()
+		close(operationChannel)
+
+		var operations []Operation[string, int]
+		for operation := range operationChannel {
+			operations = append(operations, operation)
+		}
+		assert.Equal(4, len(operations))
+		assert.Equal(OperationSet, operations[0].Type)
+		assert.Equal(EventInserted, operations[0].Event)
+		assert.Equal(entry1.Key, operations[0].Key)
+		assert.Equal(entry1.Value, operations[0].Value)
+		assert.Equal(OperationSet, operations[1].Type)
+		assert.Equal(OperationDelete, operations[2].Type)
+		assert.Equal(EventNone, operations[2].Event)
+		assert.Equal(entry2.Key, operations[2].Key)
+		assert.Equal(OperationClear, operations[3].Type)
+	}
+}
+
+func TestLRUCacheOperationChannelUpdateEvent(t *testing.T) {
+	assert := assert.New(t)
+	operationChannel := make(chan Operation[string, int], 10)
+	config := Config[string, int]{
+		MaxSize:          10,
+		TTL:              time.Minute,
+		EvictionPolicy:   LRI,
+		OperationChannel: &operationChannel,
+	}
+	cache := New(config)
+
+	cache.Set(entry1.Key, 1)
+	cache.Set(entry1.Key, 2)
+	close(operationChannel)
+
+	inserted, updated := <-operationChannel, <-operationChannel
+	assert.Equal(EventInserted, inserted.Event)
+	assert.Equal(0, inserted.PreviousValue)
+	assert.Equal(EventUpdated, updated.Event)
+	assert.Equal(2, updated.Value)
+	assert.Equal(1, updated.PreviousValue)
+	assert.Equal("Updated", updated.Event.String())
+}
+
+func TestLRUCacheMaxLifetime(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			MaxLifetime:    30 * time.Millisecond,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			assert.NotNil(cache.Get(entry1.Key))
+			if policy == LRI {
+				cache.Set(entry1.Key, entry1.Value)
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		assert.Nil(cache.Get(entry1.Key))
+
+		state := State[string, int]{
+			EvictionPolicy: policy,
+			Entries: []StateEntry[string, int]{
+				{Key: entry2.Key, Value: entry2.Value, LastUsedAt: time.Now().UTC(), CreatedAt: time.Now().Add(-time.Hour).UTC()},
+			},
+		}
+		assert.NoError(cache.SetState(state))
+		assert.Nil(cache.Get(entry2.Key))
+	}
+}
+
+func TestLRUCacheAbsoluteTTL(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            30 * time.Millisecond,
+			EvictionPolicy: policy,
+			ExpirationMode: AbsoluteTTL,
+		}
+		cache := New(config)
 
 		cache.Set(entry1.Key, entry1.Value)
-		time.Sleep(2 * config.TTL)
-		entriesAfterTTLExpired := cache.Keys()
-		assert.Equal(0, len(entriesAfterTTLExpired))
+		cache.SetWithTTL(entry2.Key, entry2.Value, time.Minute)
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			assert.NotNil(cache.Get(entry1.Key))
+		}
+		time.Sleep(30 * time.Millisecond)
+		assert.Nil(cache.Get(entry1.Key))
+		assert.NotNil(cache.Get(entry2.Key))
+
+		state := State[string, int]{
+			EvictionPolicy: policy,
+			Entries: []StateEntry[string, int]{
+				{Key: entry3.Key, Value: entry3.Value, LastUsedAt: time.Now().UTC(), CreatedAt: time.Now().Add(-time.Second).UTC()},
+			},
+		}
+		assert.NoError(cache.SetState(state))
+		assert.Nil(cache.Get(entry3.Key))
 	}
 }
 
-func TestLRUCacheTTLEvictionDaemon(t *testing.T) {
+func TestLRUCacheOnExpiredBatch(t *testing.T) {
 	assert := assert.New(t)
 	for _, policy := range policies {
-		evictionChannel := make(chan EvictedEntry[string, int], 0)
+		batches := make(chan []EvictedEntry[string, int], 1)
 		ttl := 5 * time.Millisecond
 		config := Config[string, int]{
 			MaxSize:                   10,
 			TTL:                       ttl,
-			EvictionChannel:           &evictionChannel,
 			EvictionPolicy:            policy,
-			GarbageCollectionInterval: ttl,
+			GarbageCollectionInterval: 2 * ttl,
+			OnExpiredBatch: func(expiredEntries []EvictedEntry[string, int]) {
+				batches <- expiredEntries
+			},
 		}
 		cache := New(config)
 
-		var (
-			evictedEntry1 EvictedEntry[string, int]
-			evictedEntry2 EvictedEntry[string, int]
-		)
-
-		var wg sync.WaitGroup
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			evictedEntry1 = <-evictionChannel
-			evictedEntry2 = <-evictionChannel
-		}()
-
-		expiredEntryTimestamp := time.Date(1900, 2, 1, 12, 30, 0, 0, time.UTC)
-		expiredEntry1 := Entry[string, int]{Key: "expired-1", Value: 1, Timestamp: &expiredEntryTimestamp}
-		expiredEntry2 := Entry[string, int]{Key: "expired-2", Value: 2, Timestamp: &expiredEntryTimestamp}
-		nonExpiredEntryTimestamp := time.Now().Add(time.Minute)
-		nonExpiredEntry := Entry[string, int]{Key: "non-expired", Value: 1, Timestamp: &nonExpiredEntryTimestamp}
-
-		cache.SetWithTimestamp(nonExpiredEntry.Key, nonExpiredEntry.Value, *nonExpiredEntry.Timestamp)
-		cache.SetWithTimestamp(expiredEntry1.Key, expiredEntry1.Value, *expiredEntry1.Timestamp)
-		cache.SetWithTimestamp(expiredEntry2.Key, expiredEntry2.Value, *expiredEntry2.Timestamp)
-		wg.Wait()
+		expiredEntryTimestamp := time.Now().Add(-time.Minute)
+		cache.SetWithTimestamp(entry1.Key, entry1.Value, expiredEntryTimestamp)
+		cache.SetWithTimestamp(entry2.Key, entry2.Value, expiredEntryTimestamp)
+		cache.SetWithTimestamp(entry3.Key, entry3.Value, time.Now().Add(time.Minute))
+		assert.Nil(cache.Get(entry1.Key))
+
+		batch := <-batches
+		assert.Equal(2, len(batch))
+		assert.ElementsMatch([]string{entry1.Key, entry2.Key}, []string{batch[0].Key, batch[1].Key})
+		assert.Equal(EvictionReasonExpired, batch[0].Reason)
+		assert.Equal(0, len(batches))
+	}
+}
 
-		assert.Equal(expiredEntry1.Key, evictedEntry1.Key)
-		assert.Equal(expiredEntry2.Key, evictedEntry2.Key)
-		assert.Equal(EvictionReasonExpired, evictedEntry1.Reason)
-		assert.Equal(EvictionReasonExpired, evictedEntry2.Reason)
+func TestLRUCacheOnExpiredBatchEveryCycle(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		batches := make(chan []EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:                   10,
+			TTL:                       time.Minute,
+			EvictionPolicy:            policy,
+			GarbageCollectionInterval: 10 * time.Millisecond,
+			OnExpiredBatch: func(expiredEntries []EvictedEntry[string, int]) {
+				batches <- expiredEntries
+			},
+		}
+		cache := New(config)
 
-		cachedNonExpiredEntry := cache.Get(nonExpiredEntry.Key)
-		cachedExpiredEntry1 := cache.Get(expiredEntry1.Key)
-		cachedExpiredEntry2 := cache.Get(expiredEntry2.Key)
+		cache.SetWithTimestamp(entry1.Key, entry1.Value, time.Now().Add(-2*time.Minute))
+		cache.SetWithTTL(entry2.Key, entry2.Value, 50*time.Millisecond)
+		cache.Set(entry3.Key, entry3.Value)
 
-		assert.Equal(nonExpiredEntry.Value, cachedNonExpiredEntry.Value)
-		assert.Nil(cachedExpiredEntry1)
-		assert.Nil(cachedExpiredEntry2)
+		batch := <-batches
+		assert.Equal(entry1.Key, batch[0].Key)
+		batch = <-batches
+		assert.Equal(entry2.Key, batch[0].Key)
+		assert.Equal(uint64(2), cache.Stats().Expirations)
+
+		cache.Delete(entry3.Key)
+		assert.Eventually(func() bool {
+			return cache.Status().BackgroundTimers == 0
+		}, time.Second, time.Millisecond)
+		cache.Lock()
+		assert.Equal(0, len(cache.expiredEntries))
+		cache.Unlock()
 	}
 }
 
@@ -296,6 +1012,78 @@ func TestLRUCacheSetStateError(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestLRUCacheSetStateResetCountersOnRestore(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		lastUsedAt := time.Now().Add(-time.Second)
+		state := State[string, int]{
+			EvictionPolicy: policy,
+			ExtractedAt:    time.Now(),
+			Entries: []StateEntry[string, int]{
+				{Key: entry1.Key, Value: entry1.Value, Counter: 5, LastUsedAt: lastUsedAt},
+			},
+		}
+
+		config := Config[string, int]{
+			MaxSize:        3,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		assert.NoError(cache.SetState(state))
+		restoredEntry := cache.Peek(entry1.Key)
+		assert.Equal(int64(5), restoredEntry.Counter)
+		assert.Equal(lastUsedAt, restoredEntry.CreatedAt)
+
+		config.ResetCountersOnRestore = true
+		cache = New(config)
+		assert.NoError(cache.SetState(state))
+		cache.Set(entry2.Key, entry2.Value)
+		assert.Equal(cache.Peek(entry2.Key).Counter, cache.Peek(entry1.Key).Counter)
+
+		assert.NoError(cache.MergeState(state))
+		assert.Equal(cache.Peek(entry2.Key).Counter, cache.Peek(entry1.Key).Counter)
+	}
+}
+
+func TestLRUCacheSetStateOversized(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		state := State[string, int]{
+			EvictionPolicy: policy,
+			ExtractedAt:    time.Now(),
+			Entries: []StateEntry[string, int]{
+				{Key: entry1.Key, Value: entry1.Value, LastUsedAt: time.Now(), CreatedAt: time.Now()},
+				{Key: entry2.Key, Value: entry2.Value, LastUsedAt: time.Now(), CreatedAt: time.Now()},
+				{Key: entry3.Key, Value: entry3.Value, LastUsedAt: time.Now(), CreatedAt: time.Now()},
+			},
+		}
+
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:         2,
+			TTL:             time.Minute,
+			EvictionChannel: &evictionChannel,
+			EvictionPolicy:  policy,
+		}
+		cache := New(config)
+		assert.NoError(cache.SetState(state))
+		assert.Equal(2, cache.Len())
+		assert.Nil(cache.Peek(entry3.Key))
+		evictedEntry := <-evictionChannel
+		assert.Equal(entry3.Key, evictedEntry.Key)
+		assert.Equal(EvictionReasonDropped, evictedEntry.Reason)
+
+		config.OversizedState = OversizedStateError
+		cache = New(config)
+		cache.Set(entry4.Key, entry4.Value)
+		err := cache.SetState(state)
+		assert.True(errors.Is(err, ErrStateTooLarge))
+		assert.NotNil(cache.Peek(entry4.Key))
+		assert.Equal("Error", OversizedStateError.String())
+	}
+}
+
 func TestLRUCacheSetState(t *testing.T) {
 	assert := assert.New(t)
 	for _, policy := range policies {
@@ -332,6 +1120,7 @@ func TestLRUCacheSetState(t *testing.T) {
 
 		err := cache.SetState(state)
 		assert.NoError(err)
+		restoredEntry1 := cache.Peek(state.Entries[0].Key)
 		cachedEntry1 := cache.Get(state.Entries[0].Key)
 		cachedEntry2 := cache.Get(state.Entries[1].Key)
 		evictedEntry2 := <-evictionChannel
@@ -339,7 +1128,15 @@ func TestLRUCacheSetState(t *testing.T) {
 		cachedEntry4 := cache.Get(entry4.Key)
 
 		assert.Equal(state.Entries[0].Value, cachedEntry1.Value)
-		assert.Equal(int64(2)-int64(policy*policy), cachedEntry1.Counter)
+		assert.Equal(state.Entries[0].Counter, restoredEntry1.Counter)
+		assert.Equal(state.Entries[0].CreatedAt, restoredEntry1.CreatedAt)
+		assert.Equal(state.Entries[0].LastUsedAt, restoredEntry1.LastUsedAt)
+		// Get only increments the Counter in the LRA EvictionPolicy
+		if policy == LRA {
+			assert.Equal(restoredEntry1.Counter+1, cachedEntry1.Counter)
+		} else {
+			assert.Equal(restoredEntry1.Counter, cachedEntry1.Counter)
+		}
 
 		assert.Equal(entry2.Key, evictedEntry2.Key)
 		assert.Equal(int64(2), evictedEntry2.Counter)
@@ -382,6 +1179,124 @@ func TestLRUCacheGetStateAndSetState(t *testing.T) {
 	}
 }
 
+func TestLRUCacheGetStateSinceAndMergeState(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        3,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		replica := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+		err := replica.MergeState(cache.GetState())
+		assert.NoError(err)
+
+		checkpoint := time.Now().UTC()
+		time.Sleep(time.Millisecond)
+		cache.Set(entry3.Key, entry3.Value)
+		cache.Set(entry4.Key, entry4.Value)
+
+		delta := cache.GetStateSince(checkpoint)
+		assert.Equal(2, len(delta.Entries))
+		assert.Equal(entry4.Key, delta.Entries[0].Key)
+		assert.Equal(entry3.Key, delta.Entries[1].Key)
+
+		err = replica.MergeState(delta)
+		assert.NoError(err)
+
+		state := replica.GetState()
+		assert.Equal(3, len(state.Entries))
+		assert.Equal(entry4.Key, state.Entries[0].Key)
+		assert.Equal(entry3.Key, state.Entries[1].Key)
+		assert.Equal(entry2.Key, state.Entries[2].Key)
+		assert.False(replica.Has(entry1.Key))
+	}
+}
+
+func TestLRUCacheMergeStateError(t *testing.T) {
+	assert := assert.New(t)
+	state := State[string, int]{
+		EvictionPolicy: LRI,
+		ExtractedAt:    time.Now(),
+	}
+
+	config := Config[string, int]{
+		MaxSize: 1,
+		TTL:     time.Minute,
+	}
+	cache := New(config)
+
+	err := cache.MergeState(state)
+	assert.Error(err)
+}
+
+func TestLRUCacheZeroValueKeys(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 2)
+		config := Config[string, int]{
+			MaxSize:         2,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+		}
+		cache := New(config)
+
+		cache.Set("", 0)
+		cache.Set(entry1.Key, entry1.Value)
+		assert.True(cache.Has(""))
+		assert.Equal(0, cache.Get("").Value)
+		assert.Equal(2, len(cache.Keys()))
+
+		state := cache.GetState()
+		assert.Equal(2, len(state.Entries))
+		cache.Clear()
+		assert.NoError(cache.SetState(state))
+		assert.True(cache.Has(""))
+
+		cache.Set(entry2.Key, entry2.Value)
+		cache.Set(entry3.Key, entry3.Value)
+		evictedEntries := []EvictedEntry[string, int]{<-evictionChannel, <-evictionChannel}
+		assert.ElementsMatch([]string{"", entry1.Key}, []string{evictedEntries[0].Key, evictedEntries[1].Key})
+		assert.False(cache.Has(""))
+		assert.Equal(2, len(cache.Keys()))
+	}
+
+	intCache := New(Config[int, string]{MaxSize: 1, TTL: time.Minute})
+	intCache.Set(0, "zero")
+	assert.Equal("zero", intCache.Get(0).Value)
+	intCache.Delete(0)
+	assert.Nil(intCache.Get(0))
+	assert.Equal(0, len(intCache.Entries()))
+}
+
+func TestLRUCacheExpirationIgnoresWallClockJumps(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+
+		// Simulate a wall clock jump by moving the wall clock readings of the entries
+		cache.cache[entry1.Key].lastUsedAt = time.Date(1900, 2, 1, 12, 30, 0, 0, time.UTC)
+		cache.cache[entry2.Key].expiresAt = time.Now().Add(-time.Millisecond)
+		cache.fixExpiry(cache.cache[entry2.Key])
+
+		assert.Equal(1, len(cache.Keys()))
+		assert.NotNil(cache.Get(entry1.Key))
+		assert.Nil(cache.Get(entry2.Key))
+	}
+}
+
 func TestEvictionReasonsToString(t *testing.T) {
 	assert := assert.New(t)
 
@@ -415,6 +1330,138 @@ func TestLRUCacheHas(t *testing.T) {
 
 // Integration tests - LRA evictionPolicy
 // -----------------------------------------------------------------------------
+func TestLRUCachePeek(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		cache := New(config)
+
+		cache.Set(entry1.Key, entry1.Value)
+		cache.Set(entry2.Key, entry2.Value)
+
+		peekedEntry1 := cache.Peek(entry1.Key)
+		assert.Equal(entry1.Value, peekedEntry1.Value)
+		assert.Equal(int64(policy), peekedEntry1.Counter)
+		assert.Nil(cache.Peek("non-existent-key"))
+
+		// Peek doesn't mark entry1 as the most recently used entry
+		cache.Set(entry3.Key, entry3.Value)
+		assert.False(cache.Has(entry1.Key))
+	}
+}
+
+func TestLRUCacheConfig(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:          10,
+			TTL:              time.Minute,
+			EvictionPolicy:   policy,
+			LoadErrorBackoff: 2 * time.Second,
+		}
+		cache := New(config)
+
+		effectiveConfig := cache.Config()
+		assert.Equal(config.MaxSize, effectiveConfig.MaxSize)
+		assert.Equal(config.TTL, effectiveConfig.TTL)
+		assert.Equal(policy, effectiveConfig.EvictionPolicy)
+		assert.Equal(defaultGarbageCollectionInterval, effectiveConfig.GarbageCollectionInterval)
+		assert.Equal(2*time.Second, effectiveConfig.LoadErrorBackoff)
+		assert.Equal(defaultLoadErrorMaxBackoff, effectiveConfig.LoadErrorMaxBackoff)
+		assert.NotNil(effectiveConfig.RandSource)
+	}
+}
+
+func TestLRUCacheAsCache(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		var cache Cache[string, int] = New(Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		})
+
+		assert.Equal(0, cache.Len())
+		assert.NoError(cache.Set(entry1.Key, entry1.Value))
+		assert.NoError(cache.Set(entry2.Key, entry2.Value))
+		assert.NoError(cache.Set(entry3.Key, entry3.Value))
+		assert.Equal(2, cache.Len())
+		assert.Equal(entry3.Value, cache.Get(entry3.Key).Value)
+
+		cache.Delete(entry3.Key)
+		assert.Equal(1, cache.Len())
+	}
+}
+
+func TestLRUCacheViewAndUpdate(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		for _, entryLocking := range []bool{false, true} {
+			cache := New(Config[string, []int]{
+				MaxSize:        2,
+				TTL:            time.Minute,
+				EvictionPolicy: policy,
+				EntryLocking:   entryLocking,
+			})
+			cache.Set(entry1.Key, []int{1})
+
+			assert.True(cache.Update(entry1.Key, func(value *[]int) {
+				*value = append(*value, 2)
+			}))
+			assert.False(cache.Update(entry2.Key, func(value *[]int) {}))
+
+			var viewed []int
+			assert.True(cache.View(entry1.Key, func(value []int) {
+				viewed = value
+			}))
+			assert.Equal([]int{1, 2}, viewed)
+			assert.False(cache.View(entry2.Key, func(value []int) {}))
+			cachedEntry1 := cache.Get(entry1.Key)
+			assert.Equal([]int{1, 2}, cachedEntry1.Value)
+			assert.Equal(int64(1), cachedEntry1.Counter)
+		}
+	}
+}
+
+func TestLRUCacheUpdateWithEntryLockingForRaceConditions(t *testing.T) {
+	for _, policy := range policies {
+		cache := New(Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			EntryLocking:   true,
+		})
+		cache.Set(entry1.Key, 0)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				cache.Update(entry1.Key, func(value *int) {
+					*value++
+				})
+			}()
+			go func() {
+				defer wg.Done()
+				cache.View(entry1.Key, func(value int) {})
+				cache.Get(entry1.Key)
+			}()
+			go func() {
+				defer wg.Done()
+				cache.Set(entry2.Key, 0)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 50, cache.Get(entry1.Key).Value)
+	}
+}
+
 func TestLRUCacheSetWithDuplicateKeyErrorLRA(t *testing.T) {
 	assert := assert.New(t)
 	evictionChannel := make(chan EvictedEntry[string, int], 1)
@@ -666,6 +1713,23 @@ func TestLRUCacheEntriesWithAllExpiredLRA(t *testing.T) {
 
 // Integration test - LRI evictionPolicy
 // -----------------------------------------------------------------------------
+func TestLRUCacheSetUpdatesValueLRI(t *testing.T) {
+	assert := assert.New(t)
+	config := Config[string, int]{
+		MaxSize:        10,
+		TTL:            time.Minute,
+		EvictionPolicy: LRI,
+	}
+	cache := New(config)
+
+	cache.Set(entry1.Key, entry1.Value)
+	cache.Set(entry1.Key, entry2.Value)
+
+	cachedEntry1 := cache.Get(entry1.Key)
+	assert.Equal(entry2.Value, cachedEntry1.Value)
+	assert.Equal(int64(2), cachedEntry1.Counter)
+}
+
 func TestLRUCacheSetWithEvictionReasonDroppedLRI(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,127 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrudebug serves histograms of the entry ages, remaining TTLs and counters of a
+// tlru cache over HTTP so that the composition of the cache can be inspected without exporting its state
+package tlrudebug
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+var (
+	defaultDurationBuckets = []time.Duration{
+		time.Second, 10 * time.Second, time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour,
+	}
+	defaultCounterBuckets = []int64{1, 2, 5, 10, 100, 1000}
+)
+
+// Config of Handler
+type Config struct {
+	// Buckets of the age histogram. If not set the default duration buckets are used
+	AgeBuckets []time.Duration
+	// Buckets of the remaining TTL histogram. If not set the default duration buckets are used
+	TTLBuckets []time.Duration
+	// Buckets of the counter histogram. If not set the default counter buckets are used
+	CounterBuckets []int64
+}
+
+// Bucket of a Histogram
+type Bucket struct {
+	// The inclusive upper bound of the bucket or "+Inf" for the last bucket
+	LE string `json:"le"`
+	// The number of entries in the bucket
+	Count int `json:"count"`
+}
+
+// Histogram is a non-cumulative histogram of the non-expired entries of a cache
+type Histogram struct {
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Report is the response of Handler
+type Report struct {
+	// The number of entries in the cache
+	Entries int `json:"entries"`
+	// Time since the entries have been inserted
+	Age Histogram `json:"age"`
+	// Time until the entries expire
+	RemainingTTL Histogram `json:"remaining_ttl"`
+	// The Counter of the entries
+	Counter Histogram `json:"counter"`
+	// The time the report has been generated
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// NewReport returns the Report of the provided cache
+func NewReport[K comparable, V any](cache *tlru.TLRU[K, V], config Config) Report {
+	config = withDefaults(config)
+
+	return Report{
+		Entries:      cache.Len(),
+		Age:          durationHistogram(config.AgeBuckets, cache.AgeDistribution(config.AgeBuckets)),
+		RemainingTTL: durationHistogram(config.TTLBuckets, cache.TTLDistribution(config.TTLBuckets)),
+		Counter:      counterHistogram(config.CounterBuckets, cache.CounterDistribution(config.CounterBuckets)),
+		GeneratedAt:  time.Now(),
+	}
+}
+
+// Handler returns an http.Handler which responds to GET requests with
+// the Report of the provided cache encoded as JSON
+func Handler[K comparable, V any](cache *tlru.TLRU[K, V], config Config) http.Handler {
+	config = withDefaults(config)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewReport(cache, config))
+	})
+}
+
+func withDefaults(config Config) Config {
+	if len(config.AgeBuckets) == 0 {
+		config.AgeBuckets = defaultDurationBuckets
+	}
+	if len(config.TTLBuckets) == 0 {
+		config.TTLBuckets = defaultDurationBuckets
+	}
+	if len(config.CounterBuckets) == 0 {
+		config.CounterBuckets = defaultCounterBuckets
+	}
+
+	return config
+}
+
+func durationHistogram(bounds []time.Duration, distribution []int) Histogram {
+	histogram := Histogram{Buckets: make([]Bucket, 0, len(distribution))}
+	for i, bound := range bounds {
+		histogram.Buckets = append(histogram.Buckets, Bucket{LE: bound.String(), Count: distribution[i]})
+	}
+
+	return withInfBucket(histogram, distribution)
+}
+
+func counterHistogram(bounds []int64, distribution []int) Histogram {
+	histogram := Histogram{Buckets: make([]Bucket, 0, len(distribution))}
+	for i, bound := range bounds {
+		histogram.Buckets = append(histogram.Buckets, Bucket{LE: strconv.FormatInt(bound, 10), Count: distribution[i]})
+	}
+
+	return withInfBucket(histogram, distribution)
+}
+
+func withInfBucket(histogram Histogram, distribution []int) Histogram {
+	histogram.Buckets = append(histogram.Buckets, Bucket{LE: "+Inf", Count: distribution[len(distribution)-1]})
+	return histogram
+}
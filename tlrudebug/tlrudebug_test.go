@@ -0,0 +1,47 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrudebug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	assert := assert.New(t)
+	cache := tlru.New(tlru.Config[string, int]{MaxSize: 10, TTL: time.Minute, EvictionPolicy: tlru.LRI})
+	cache.Set("entry-1", 1)
+	cache.Set("entry-1", 1)
+	cache.SetWithTimestamp("entry-2", 2, time.Now().Add(-50*time.Second))
+
+	server := httptest.NewServer(Handler(cache, Config{
+		TTLBuckets:     []time.Duration{30 * time.Second},
+		CounterBuckets: []int64{1},
+	}))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	assert.NoError(err)
+	defer response.Body.Close()
+	assert.Equal("application/json", response.Header.Get("Content-Type"))
+
+	var report Report
+	assert.NoError(json.NewDecoder(response.Body).Decode(&report))
+	assert.Equal(2, report.Entries)
+	assert.Equal([]Bucket{{LE: "30s", Count: 1}, {LE: "+Inf", Count: 1}}, report.RemainingTTL.Buckets)
+	assert.Equal([]Bucket{{LE: "1", Count: 1}, {LE: "+Inf", Count: 1}}, report.Counter.Buckets)
+	assert.Equal(len(defaultDurationBuckets)+1, len(report.Age.Buckets))
+	assert.Equal(Bucket{LE: "1s", Count: 2}, report.Age.Buckets[0])
+
+	response, err = http.Post(server.URL, "application/json", nil)
+	assert.NoError(err)
+	response.Body.Close()
+	assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+}
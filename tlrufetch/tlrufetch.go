@@ -0,0 +1,303 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrufetch fetches and caches the bodies of URLs
+// Concurrent fetches of the same URL are coalesced into a single request and
+// stale responses are revalidated via conditional requests(ETag/If-Modified-Since)
+// The freshness of responses can be derived from their Cache-Control, Expires and
+// Age headers via TTLFromHeaders
+package tlrufetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+const (
+	defaultTTL          = time.Minute
+	defaultFetchTimeout = 30 * time.Second
+)
+
+// Config of Fetcher
+type Config struct {
+	// HTTP client used for fetching. If not set http.DefaultClient is used
+	Client *http.Client
+	// Max number of cached responses
+	MaxSize int
+	// Time during which a cached response is served without revalidation.
+	// If not set it defaults to 1 minute
+	TTL time.Duration
+	// Time after TTL during which a stale response is kept for revalidation.
+	// If not set it defaults to 10 times the TTL
+	RevalidationWindow time.Duration
+	// CacheHeaders derives the time during which a response is served without
+	// revalidation from its headers via TTLFromHeaders instead of using TTL, which
+	// only applies to responses without a freshness lifetime. Responses with
+	// Cache-Control: no-store are not cached
+	CacheHeaders bool
+	// Max duration of a request. A request is shared by concurrent callers of Get so
+	// it doesn't depend on the context of any of them. It is canceled once all of them
+	// have returned. If not set it defaults to 30 seconds
+	FetchTimeout time.Duration
+}
+
+// Response is a cached response
+type Response struct {
+	// The URL the response has been fetched from
+	URL string
+	// The response headers
+	Header http.Header
+	// The response body
+	Body []byte
+	// The time the response was last fetched or revalidated
+	ValidatedAt time.Time
+	// Time after ValidatedAt during which the response is served without revalidation
+	TTL time.Duration
+}
+
+// Fetcher fetches and caches URLs
+type Fetcher struct {
+	cache *tlru.TLRU[string, *Response]
+	// stale holds the responses which have been removed from the cache once they became
+	// stale so that the request which replaces them can revalidate them
+	stale  *tlru.TLRU[string, *Response]
+	config Config
+}
+
+// New returns a new Fetcher
+func New(config Config) *Fetcher {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+	if config.RevalidationWindow <= 0 {
+		config.RevalidationWindow = 10 * config.TTL
+	}
+	if config.FetchTimeout <= 0 {
+		config.FetchTimeout = defaultFetchTimeout
+	}
+
+	f := &Fetcher{
+		stale: tlru.New(tlru.Config[string, *Response]{
+			MaxSize:        config.MaxSize,
+			TTL:            config.RevalidationWindow,
+			EvictionPolicy: tlru.LRI,
+		}),
+		config: config,
+	}
+	// Concurrent misses of a URL share a single invocation of the loader and stale
+	// responses are invalidated on lookup so that they are fetched again
+	f.cache = tlru.New(tlru.Config[string, *Response]{
+		MaxSize:        config.MaxSize,
+		TTL:            config.TTL + config.RevalidationWindow,
+		EvictionPolicy: tlru.LRI,
+		LoadTimeout:    config.FetchTimeout,
+		Loader:         f.load,
+		Validator: func(url string, response *Response) bool {
+			return response.isFresh()
+		},
+		OnEvict: f.onEvict,
+	})
+
+	return f
+}
+
+// Get returns the response of the provided URL
+// A fresh cached response is returned without any request. A stale one is
+// revalidated via a conditional request
+// Responses with a status code other than 200 are returned as an error and are not cached
+// Concurrent calls for the same URL share a single request. If the provided context is done
+// before the response arrives Get returns the error of the context and the request is
+// canceled once all the callers waiting for it have returned
+func (f *Fetcher) Get(ctx context.Context, url string) (*Response, error) {
+	response, err := f.cache.GetWithContext(ctx, url)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if f.isNoStore(response) {
+		f.cache.Delete(url)
+	}
+
+	return response, nil
+}
+
+// Invalidate removes the cached response of the provided URL
+func (f *Fetcher) Invalidate(url string) {
+	f.cache.Delete(url)
+	f.stale.Delete(url)
+}
+
+// load fetches the provided URL, or revalidates its stale response, on a miss of the cache
+func (f *Fetcher) load(ctx context.Context, url string) (*Response, error) {
+	var cached *Response
+	if cacheEntry := f.stale.Peek(url); cacheEntry != nil {
+		cached = cacheEntry.Value
+		// A response which outlived the TTL of the cache is still fresh until its own TTL elapses
+		if cached.isFresh() {
+			f.stale.Delete(url)
+			return cached, nil
+		}
+	}
+
+	response, err := f.fetch(ctx, url, cached)
+	if err != nil {
+		return nil, err
+	}
+	f.stale.Delete(url)
+
+	return response, nil
+}
+
+// onEvict keeps the responses which have been removed from the cache due to being stale
+// or expired until the end of their revalidation window
+func (f *Fetcher) onEvict(evictedEntry tlru.EvictedEntry[string, *Response]) {
+	if evictedEntry.Reason != tlru.EvictionReasonInvalidated && evictedEntry.Reason != tlru.EvictionReasonExpired {
+		return
+	}
+	response := evictedEntry.Value
+	if f.isNoStore(response) {
+		return
+	}
+	if ttl := time.Until(response.ValidatedAt.Add(response.TTL + f.config.RevalidationWindow)); ttl > 0 {
+		f.stale.SetWithTTL(evictedEntry.Key, response, ttl)
+	}
+}
+
+func (f *Fetcher) fetch(ctx context.Context, url string, cached *Response) (*Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	response, err := f.config.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && cached != nil:
+		revalidated := *cached
+		revalidated.ValidatedAt = time.Now().UTC()
+		revalidated.TTL = f.ttl(response.Header)
+		return &revalidated, nil
+	case response.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{
+			URL:         url,
+			Header:      response.Header,
+			Body:        body,
+			ValidatedAt: time.Now().UTC(),
+			TTL:         f.ttl(response.Header),
+		}, nil
+	default:
+		return nil, fmt.Errorf("tlrufetch: Unexpected status code %d for '%s'", response.StatusCode, url)
+	}
+}
+
+// ttl returns the time during which a response with the provided headers is served
+// without revalidation
+func (f *Fetcher) ttl(header http.Header) time.Duration {
+	if !f.config.CacheHeaders {
+		return f.config.TTL
+	}
+	if ttl := TTLFromHeaders(header); ttl >= 0 {
+		return ttl
+	}
+
+	return f.config.TTL
+}
+
+// isNoStore reports whether the provided response must not be cached
+func (f *Fetcher) isNoStore(response *Response) bool {
+	return f.config.CacheHeaders && hasDirective(response.Header, "no-store")
+}
+
+func (r *Response) isFresh() bool {
+	return time.Since(r.ValidatedAt) < r.TTL
+}
+
+// TTLFromHeaders returns the remaining freshness lifetime of a response with the
+// provided headers, i.e. its max-age, or the time between its Expires and Date
+// headers if max-age is missing, minus its Age
+// It returns 0 if the response must be revalidated before it is used, due to
+// Cache-Control: no-cache or no-store or because it is already stale, and a negative
+// duration if the headers don't specify a freshness lifetime so that a default applies
+func TTLFromHeaders(header http.Header) time.Duration {
+	if hasDirective(header, "no-store") || hasDirective(header, "no-cache") {
+		return 0
+	}
+
+	var lifetime time.Duration
+	if maxAge, ok := directive(header, "max-age"); ok {
+		seconds, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil {
+			return 0
+		}
+		lifetime = time.Duration(seconds) * time.Second
+	} else if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+		lifetime = expiresAt.Sub(date)
+	} else {
+		return -1
+	}
+
+	if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	if lifetime < 0 {
+		return 0
+	}
+
+	return lifetime
+}
+
+// directive returns the value of the provided Cache-Control directive and whether it is present
+func directive(header http.Header, name string) (string, bool) {
+	for _, cacheControl := range header.Values("Cache-Control") {
+		for _, field := range strings.Split(cacheControl, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			if strings.EqualFold(key, name) {
+				return strings.Trim(value, `"`), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func hasDirective(header http.Header, name string) bool {
+	_, ok := directive(header, name)
+	return ok
+}
@@ -0,0 +1,234 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrufetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetcherCoalescesConcurrentFetches(t *testing.T) {
+	assert := assert.New(t)
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	fetcher := New(Config{Client: server.Client(), TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := fetcher.Get(context.Background(), server.URL)
+			assert.NoError(err)
+			assert.Equal("body", string(response.Body))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	_, err := fetcher.Get(context.Background(), server.URL)
+	assert.NoError(err)
+	assert.Equal(int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestFetcherCallerCancellation(t *testing.T) {
+	assert := assert.New(t)
+	var requests int32
+	release := make(chan struct{})
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unresponsive" {
+			<-r.Context().Done()
+			close(canceled)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	fetcher := New(Config{Client: server.Client(), TTL: time.Minute})
+
+	// The caller which started the request gives up while another one still waits for it
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := fetcher.Get(ctx, server.URL)
+		firstDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	secondDone := make(chan *Response)
+	go func() {
+		response, err := fetcher.Get(context.Background(), server.URL)
+		assert.NoError(err)
+		secondDone <- response
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.Equal(context.Canceled, <-firstDone)
+
+	close(release)
+	assert.Equal("body", string((<-secondDone).Body))
+	assert.Equal(int32(1), atomic.LoadInt32(&requests))
+
+	// The request is canceled once all callers have given up
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := fetcher.Get(ctx, server.URL+"/unresponsive")
+	assert.Equal(context.DeadlineExceeded, err)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		assert.Fail("The request hasn't been canceled")
+	}
+}
+
+func TestFetcherRevalidatesStaleResponses(t *testing.T) {
+	assert := assert.New(t)
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	ttl := 10 * time.Millisecond
+	fetcher := New(Config{Client: server.Client(), TTL: ttl})
+
+	response, err := fetcher.Get(context.Background(), server.URL)
+	assert.NoError(err)
+	time.Sleep(2 * ttl)
+
+	revalidatedResponse, err := fetcher.Get(context.Background(), server.URL)
+	assert.NoError(err)
+	assert.Equal("body", string(revalidatedResponse.Body))
+	assert.True(revalidatedResponse.ValidatedAt.After(response.ValidatedAt))
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(int32(1), atomic.LoadInt32(&notModified))
+}
+
+func TestFetcherDoesNotCacheErrors(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	fetcher := New(Config{Client: server.Client()})
+	_, err := fetcher.Get(context.Background(), server.URL)
+	assert.Error(err)
+	assert.Nil(fetcher.cache.Peek(server.URL))
+}
+
+func TestTTLFromHeaders(t *testing.T) {
+	assert := assert.New(t)
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		header http.Header
+		ttl    time.Duration
+	}{
+		{http.Header{}, -1},
+		{http.Header{"Cache-Control": {"public, max-age=60"}}, time.Minute},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"20"}}, 40 * time.Second},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"90"}}, 0},
+		{http.Header{"Cache-Control": {`max-age="30"`}}, 30 * time.Second},
+		{http.Header{"Cache-Control": {"max-age=60, no-cache"}}, 0},
+		{http.Header{"Cache-Control": {"No-Store"}}, 0},
+		{http.Header{"Cache-Control": {"private"}}, -1},
+		{http.Header{"Cache-Control": {"max-age=invalid"}}, 0},
+		{http.Header{"Expires": {date.Add(time.Hour).Format(http.TimeFormat)}, "Date": {date.Format(http.TimeFormat)}}, time.Hour},
+		{http.Header{"Expires": {date.Add(time.Hour).Format(http.TimeFormat)}, "Date": {date.Format(http.TimeFormat)}, "Age": {"600"}}, 50 * time.Minute},
+		{http.Header{"Expires": {date.Add(time.Hour).Format(http.TimeFormat)}, "Date": {date.Format(http.TimeFormat)}, "Cache-Control": {"max-age=10"}}, 10 * time.Second},
+		{http.Header{"Expires": {"0"}}, 0},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(testCase.ttl, TTLFromHeaders(testCase.header), testCase.header)
+	}
+}
+
+func TestFetcherCacheHeaders(t *testing.T) {
+	assert := assert.New(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/no-cache":
+			w.Header().Set("Cache-Control", "no-cache")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	fetcher := New(Config{Client: server.Client(), TTL: time.Millisecond, CacheHeaders: true})
+	for _, path := range []string{"/fresh", "/no-cache", "/no-store", "/default"} {
+		atomic.StoreInt32(&requests, 0)
+		for i := 0; i < 2; i++ {
+			response, err := fetcher.Get(context.Background(), server.URL+path)
+			assert.NoError(err)
+			assert.Equal("body", string(response.Body))
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		switch path {
+		case "/fresh":
+			assert.Equal(int32(1), atomic.LoadInt32(&requests))
+			assert.Equal(time.Minute, fetcher.cache.Peek(server.URL+path).Value.TTL)
+		case "/no-store":
+			assert.Equal(int32(2), atomic.LoadInt32(&requests))
+			assert.Nil(fetcher.cache.Peek(server.URL + path))
+		default:
+			assert.Equal(int32(2), atomic.LoadInt32(&requests))
+			assert.NotNil(fetcher.cache.Peek(server.URL + path))
+		}
+	}
+}
+
+func TestFetcherResponseOutlivesCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	ttl := time.Millisecond
+	fetcher := New(Config{Client: server.Client(), TTL: ttl, RevalidationWindow: ttl, CacheHeaders: true})
+	response, err := fetcher.Get(context.Background(), server.URL)
+	assert.NoError(err)
+	time.Sleep(10 * ttl)
+
+	// The response is fresh for a minute so it is served without a request
+	// although the cache expired it
+	cachedResponse, err := fetcher.Get(context.Background(), server.URL)
+	assert.NoError(err)
+	assert.Equal(response, cachedResponse)
+	assert.Equal(int32(1), atomic.LoadInt32(&requests))
+}
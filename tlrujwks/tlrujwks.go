@@ -0,0 +1,287 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrujwks caches the public keys of a JSON Web Key Set(JWKS) endpoint
+// Keys are refreshed ahead of their expiration and stale keys keep being served
+// while a refresh is in progress(stale-while-revalidate)
+package tlrujwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+const (
+	defaultTTL                = time.Hour
+	defaultMinRefreshInterval = time.Minute
+)
+
+// ErrKeyNotFound is returned when the key set doesn't contain the requested key ID
+var ErrKeyNotFound = errors.New("tlrujwks: Key not found")
+
+// Config of KeySet
+type Config struct {
+	// URL of the JWKS endpoint
+	URL string
+	// HTTP client used for fetching the key set. If not set http.DefaultClient is used
+	Client *http.Client
+	// Time after which fetched keys are considered stale. If not set it defaults to 1 hour
+	TTL time.Duration
+	// Keys which are about to become stale within RefreshAhead trigger a background refresh.
+	// If not set it defaults to a fifth of the TTL
+	RefreshAhead time.Duration
+	// Time after TTL during which stale keys are still served while a background
+	// refresh is in progress. If not set stale keys are not served
+	StaleWhileRevalidate time.Duration
+	// Minimum interval between refreshes triggered by unknown key IDs.
+	// If not set it defaults to 1 minute
+	MinRefreshInterval time.Duration
+	// Optional callback which is invoked when a background refresh fails
+	OnRefreshError func(err error)
+}
+
+// KeySet resolves key IDs to public keys of a JWKS endpoint
+type KeySet struct {
+	cache          *tlru.TLRU[string, crypto.PublicKey]
+	config         Config
+	refreshMu      sync.Mutex
+	lastRefresh    time.Time
+	refreshing     int32
+	backgroundDone sync.WaitGroup
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// New returns a new KeySet
+func New(config Config) *KeySet {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+	if config.RefreshAhead <= 0 {
+		config.RefreshAhead = config.TTL / 5
+	}
+	if config.MinRefreshInterval <= 0 {
+		config.MinRefreshInterval = defaultMinRefreshInterval
+	}
+
+	return &KeySet{
+		// LRI is used so that LastUsedAt reflects the time a key was fetched
+		cache: tlru.New(tlru.Config[string, crypto.PublicKey]{
+			TTL:            config.TTL + config.StaleWhileRevalidate,
+			EvictionPolicy: tlru.LRI,
+		}),
+		config: config,
+	}
+}
+
+// Key returns the public key with the provided key ID
+// The key set is fetched synchronously only if the key is missing or too stale to be served
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if cacheEntry := k.cache.Get(kid); cacheEntry != nil {
+		if time.Since(cacheEntry.LastUsedAt) > k.config.TTL-k.config.RefreshAhead {
+			k.refreshInBackground()
+		}
+		return cacheEntry.Value, nil
+	}
+
+	if err := k.refresh(ctx, true); err != nil {
+		return nil, err
+	}
+	if cacheEntry := k.cache.Get(kid); cacheEntry != nil {
+		return cacheEntry.Value, nil
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// Refresh fetches the key set synchronously
+// Cached keys which are absent from the fetched key set are removed
+func (k *KeySet) Refresh(ctx context.Context) error {
+	return k.refresh(ctx, false)
+}
+
+// Close waits for in-flight background refreshes and removes all cached keys
+func (k *KeySet) Close() {
+	k.backgroundDone.Wait()
+	k.cache.Clear()
+}
+
+func (k *KeySet) refreshInBackground() {
+	if !atomic.CompareAndSwapInt32(&k.refreshing, 0, 1) {
+		return
+	}
+
+	k.backgroundDone.Add(1)
+	go func() {
+		defer k.backgroundDone.Done()
+		defer atomic.StoreInt32(&k.refreshing, 0)
+		if err := k.refresh(context.Background(), false); err != nil && k.config.OnRefreshError != nil {
+			k.config.OnRefreshError(err)
+		}
+	}()
+}
+
+func (k *KeySet) refresh(ctx context.Context, rateLimited bool) error {
+	startedAt := time.Now()
+	defer k.refreshMu.Unlock()
+	k.refreshMu.Lock()
+
+	// A concurrent refresh completed while waiting for the lock
+	if k.lastRefresh.After(startedAt) {
+		return nil
+	}
+	if rateLimited && time.Since(k.lastRefresh) < k.config.MinRefreshInterval {
+		return nil
+	}
+
+	keys, err := k.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	// Keys which have been removed from the key set are revoked and must not be served anymore
+	for _, kid := range k.cache.Keys() {
+		if _, exists := keys[kid]; !exists {
+			k.cache.Delete(kid)
+		}
+	}
+	for kid, key := range keys {
+		k.cache.Set(kid, key)
+	}
+	k.lastRefresh = time.Now()
+
+	return nil
+}
+
+func (k *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, k.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := k.config.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tlrujwks: Unexpected status code %d", response.StatusCode)
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&keySet); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("tlrujwks: Invalid key '%s': %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// publicKey parses the key. It returns nil for unsupported key types and curves
+// so that a key set can introduce new algorithms without failing its older clients
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 2 || e.Int64() > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve '%s'", jwk.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
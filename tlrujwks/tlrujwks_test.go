@@ -0,0 +1,161 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrujwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newJWKSServer(t *testing.T, rsaKey *rsa.PublicKey, edKey ed25519.PublicKey, requests *int32) *httptest.Server {
+	encode := base64.RawURLEncoding.EncodeToString
+	keySet := map[string]any{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": encode(edKey)},
+			{"kty": "oct", "kid": "symmetric-1", "k": "c2VjcmV0"},
+			{"kty": "EC", "kid": "ec-secp256k1", "crv": "secp256k1", "x": encode([]byte{1}), "y": encode([]byte{2})},
+			{"kty": "OKP", "kid": "okp-x25519", "crv": "X25519", "x": encode(edKey)},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		json.NewEncoder(w).Encode(keySet)
+	}))
+}
+
+func TestKeySet(t *testing.T) {
+	assert := assert.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(err)
+
+	var requests int32
+	server := newJWKSServer(t, &rsaKey.PublicKey, edKey, &requests)
+	defer server.Close()
+
+	keySet := New(Config{URL: server.URL, Client: server.Client(), TTL: time.Minute})
+	defer keySet.Close()
+
+	key, err := keySet.Key(context.Background(), "rsa-1")
+	assert.NoError(err)
+	assert.True(rsaKey.PublicKey.Equal(key))
+
+	key, err = keySet.Key(context.Background(), "ed-1")
+	assert.NoError(err)
+	assert.True(edKey.Equal(key))
+
+	for _, kid := range []string{"symmetric-1", "ec-secp256k1", "okp-x25519"} {
+		_, err = keySet.Key(context.Background(), kid)
+		assert.Equal(ErrKeyNotFound, err)
+	}
+	_, err = keySet.Key(context.Background(), "unknown")
+	assert.Equal(ErrKeyNotFound, err)
+
+	assert.Equal(int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestPublicKey(t *testing.T) {
+	assert := assert.New(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	for _, jwk := range []jsonWebKey{
+		{Kty: "EC", Kid: "ec-1", Crv: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(new(big.Int).Add(ecKey.Y, big.NewInt(1)).Bytes())},
+		{Kty: "RSA", Kid: "rsa-1", N: encode([]byte{1, 2, 3}), E: encode(new(big.Int).Lsh(big.NewInt(1), 64).Bytes())},
+		{Kty: "RSA", Kid: "rsa-2", N: encode([]byte{1, 2, 3}), E: encode([]byte{1})},
+	} {
+		_, err := jwk.publicKey()
+		assert.Error(err)
+	}
+
+	jwk := jsonWebKey{Kty: "EC", Kid: "ec-1", Crv: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(ecKey.Y.Bytes())}
+	key, err := jwk.publicKey()
+	assert.NoError(err)
+	assert.True(ecKey.PublicKey.Equal(key))
+}
+
+func TestKeySetRevokedKeys(t *testing.T) {
+	assert := assert.New(t)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(err)
+	revokedKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(err)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	var revoked int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := []map[string]string{{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": encode(edKey)}}
+		if atomic.LoadInt32(&revoked) == 0 {
+			keys = append(keys, map[string]string{"kty": "OKP", "kid": "ed-2", "crv": "Ed25519", "x": encode(revokedKey)})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer server.Close()
+
+	keySet := New(Config{URL: server.URL, Client: server.Client(), TTL: time.Minute})
+	defer keySet.Close()
+
+	key, err := keySet.Key(context.Background(), "ed-2")
+	assert.NoError(err)
+	assert.True(revokedKey.Equal(key))
+
+	atomic.StoreInt32(&revoked, 1)
+	assert.NoError(keySet.Refresh(context.Background()))
+
+	_, err = keySet.Key(context.Background(), "ed-2")
+	assert.Equal(ErrKeyNotFound, err)
+	key, err = keySet.Key(context.Background(), "ed-1")
+	assert.NoError(err)
+	assert.True(edKey.Equal(key))
+}
+
+func TestKeySetRefreshAheadAndStaleWhileRevalidate(t *testing.T) {
+	assert := assert.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(err)
+
+	var requests int32
+	server := newJWKSServer(t, &rsaKey.PublicKey, edKey, &requests)
+	defer server.Close()
+
+	ttl := 20 * time.Millisecond
+	keySet := New(Config{
+		URL:                  server.URL,
+		Client:               server.Client(),
+		TTL:                  ttl,
+		StaleWhileRevalidate: time.Minute,
+	})
+	defer keySet.Close()
+
+	assert.NoError(keySet.Refresh(context.Background()))
+	time.Sleep(2 * ttl)
+
+	// The stale key is served while the key set is refreshed in the background
+	key, err := keySet.Key(context.Background(), "ed-1")
+	assert.NoError(err)
+	assert.True(edKey.Equal(key))
+
+	keySet.Close()
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,182 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrulimit implements per-key rate limiters which keep their state in a tlru cache
+// The state of idle keys is evicted once it is equivalent to the state of a new key
+package tlrulimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+// TokenBucketConfig of TokenBucket
+type TokenBucketConfig struct {
+	// Number of tokens added to the bucket of a key per second
+	Rate float64
+	// Max number of tokens in the bucket of a key
+	Burst int
+	// Max number of tracked keys. When full the least recently used key is dropped
+	MaxKeys int
+}
+
+// TokenBucket is a per-key token bucket rate limiter
+type TokenBucket struct {
+	cache  *tlru.TLRU[string, *bucket]
+	config TokenBucketConfig
+}
+
+type bucket struct {
+	sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewTokenBucket returns a new TokenBucket rate limiter
+// It panics if Rate or Burst isn't positive
+func NewTokenBucket(config TokenBucketConfig) *TokenBucket {
+	if !(config.Rate > 0) || config.Burst <= 0 {
+		panic("tlrulimit: TokenBucketConfig.Rate and TokenBucketConfig.Burst must be positive")
+	}
+	// A bucket which has been idle long enough to be refilled is equal to a new bucket
+	ttl := time.Duration(float64(config.Burst) / config.Rate * float64(time.Second))
+
+	return &TokenBucket{
+		cache: tlru.New(tlru.Config[string, *bucket]{
+			MaxSize:                   config.MaxKeys,
+			TTL:                       ttl,
+			EvictionPolicy:            tlru.LRA,
+			GarbageCollectionInterval: ttl,
+		}),
+		config: config,
+	}
+}
+
+// Allow reports whether a single event of the provided key may happen now
+func (l *TokenBucket) Allow(key string) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n events of the provided key may happen now
+// The tokens are consumed only if the events are allowed
+func (l *TokenBucket) AllowN(key string, n int) bool {
+	now := time.Now()
+	b := getOrCreate(l.cache, key, func() *bucket {
+		return &bucket{tokens: float64(l.config.Burst), lastRefill: now}
+	})
+
+	defer b.Unlock()
+	b.Lock()
+
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(l.config.Burst), b.tokens+elapsed*l.config.Rate)
+		b.lastRefill = now
+	}
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+
+	return true
+}
+
+// SlidingWindowConfig of SlidingWindow
+type SlidingWindowConfig struct {
+	// Max number of events of a key per Window
+	Limit int
+	// Duration of the window
+	Window time.Duration
+	// Max number of tracked keys. When full the least recently used key is dropped
+	MaxKeys int
+}
+
+// SlidingWindow is a per-key sliding window rate limiter
+// It approximates the number of events in the sliding window by weighting
+// the count of the previous fixed window
+type SlidingWindow struct {
+	cache  *tlru.TLRU[string, *window]
+	config SlidingWindowConfig
+}
+
+type window struct {
+	sync.Mutex
+	start         time.Time
+	count         int
+	previousCount int
+}
+
+// NewSlidingWindow returns a new SlidingWindow rate limiter
+// It panics if Window isn't positive
+func NewSlidingWindow(config SlidingWindowConfig) *SlidingWindow {
+	if config.Window <= 0 {
+		panic("tlrulimit: SlidingWindowConfig.Window must be positive")
+	}
+	// Counts older than two windows don't affect the sliding window
+	ttl := 2 * config.Window
+
+	return &SlidingWindow{
+		cache: tlru.New(tlru.Config[string, *window]{
+			MaxSize:                   config.MaxKeys,
+			TTL:                       ttl,
+			EvictionPolicy:            tlru.LRA,
+			GarbageCollectionInterval: ttl,
+		}),
+		config: config,
+	}
+}
+
+// Allow reports whether a single event of the provided key may happen now
+func (l *SlidingWindow) Allow(key string) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n events of the provided key may happen now
+// The events are counted only if they are allowed
+func (l *SlidingWindow) AllowN(key string, n int) bool {
+	now := time.Now()
+	w := getOrCreate(l.cache, key, func() *window {
+		return &window{start: now.Truncate(l.config.Window)}
+	})
+
+	defer w.Unlock()
+	w.Lock()
+
+	currentStart := now.Truncate(l.config.Window)
+	switch elapsedWindows := int(currentStart.Sub(w.start) / l.config.Window); {
+	case elapsedWindows == 1:
+		w.previousCount = w.count
+		w.count = 0
+		w.start = currentStart
+	case elapsedWindows > 1:
+		w.previousCount = 0
+		w.count = 0
+		w.start = currentStart
+	}
+
+	previousWeight := 1 - float64(now.Sub(currentStart))/float64(l.config.Window)
+	estimated := float64(w.previousCount)*previousWeight + float64(w.count)
+	if estimated+float64(n) > float64(l.config.Limit) {
+		return false
+	}
+	w.count += n
+
+	return true
+}
+
+func getOrCreate[V any](cache *tlru.TLRU[string, V], key string, create func() V) V {
+	for {
+		if cacheEntry := cache.Get(key); cacheEntry != nil {
+			return cacheEntry.Value
+		}
+		value := create()
+		// Set fails only if the key has been concurrently inserted
+		if err := cache.Set(key, value); err == nil {
+			return value
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrulimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	assert := assert.New(t)
+	limiter := NewTokenBucket(TokenBucketConfig{Rate: 100, Burst: 3, MaxKeys: 10})
+
+	assert.True(limiter.AllowN("entry-1", 2))
+	assert.True(limiter.Allow("entry-1"))
+	assert.False(limiter.Allow("entry-1"))
+	assert.True(limiter.Allow("entry-2"))
+	assert.False(limiter.AllowN("entry-2", 4))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.True(limiter.Allow("entry-1"))
+}
+
+func TestTokenBucketConcurrentAllow(t *testing.T) {
+	assert := assert.New(t)
+	limiter := NewTokenBucket(TokenBucketConfig{Rate: 0.001, Burst: 50})
+
+	var (
+		allowed int64
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow("entry-1") {
+				atomic.AddInt64(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(int64(50), allowed)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	assert := assert.New(t)
+	window := 50 * time.Millisecond
+	limiter := NewSlidingWindow(SlidingWindowConfig{Limit: 3, Window: window, MaxKeys: 10})
+
+	assert.True(limiter.AllowN("entry-1", 3))
+	assert.False(limiter.Allow("entry-1"))
+	assert.True(limiter.Allow("entry-2"))
+
+	time.Sleep(3 * window)
+	assert.True(limiter.AllowN("entry-1", 3))
+	assert.False(limiter.Allow("entry-1"))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	assert := assert.New(t)
+	for _, config := range []TokenBucketConfig{
+		{Burst: 3},
+		{Rate: -1, Burst: 3},
+		{Rate: 100},
+		{Rate: 100, Burst: -3},
+	} {
+		assert.Panics(func() { NewTokenBucket(config) })
+	}
+	assert.Panics(func() { NewSlidingWindow(SlidingWindowConfig{Limit: 3}) })
+	assert.Panics(func() { NewSlidingWindow(SlidingWindowConfig{Limit: 3, Window: -time.Second}) })
+}
@@ -0,0 +1,224 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrumemcache serves a minimal subset of the memcached text protocol backed by a tlru cache
+// Supported commands are get, set, add, replace, delete, flush_all, version and quit
+// The exptime argument of storage commands is accepted but the TTL of the cache applies
+package tlrumemcache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/jahnestacado/tlru/v3/internal/tcpserver"
+)
+
+const (
+	maxKeyLength = 250
+	// maxItemSize is the max size in bytes of a stored value like the default of memcached
+	maxItemSize = 1024 * 1024
+	// maxLineLength is the max size in bytes of a command line which leaves room
+	// for retrieving hundreds of keys with a single get command
+	maxLineLength = 64 * 1024
+	version       = "tlru"
+)
+
+// ErrServerClosed is returned by the Serve and ListenAndServe methods after a call to Close
+var ErrServerClosed = tcpserver.ErrServerClosed
+
+// Item is the value stored in the cache for every memcached key
+type Item struct {
+	// Opaque client flags stored along with the value
+	Flags uint32 `json:"flags"`
+	// The stored data
+	Value []byte `json:"value"`
+}
+
+// Server serves memcached text protocol requests on top of a tlru cache
+type Server struct {
+	// Optional callback which is invoked with the panics that are recovered while serving
+	// a connection. The connection is closed after a panic. It must be set before Serve
+	OnPanic   func(err *tlru.PanicError)
+	cache     *tlru.TLRU[string, Item]
+	tcpServer *tcpserver.Server
+}
+
+// NewServer returns a new Server backed by the provided cache
+func NewServer(cache *tlru.TLRU[string, Item]) *Server {
+	s := &Server{cache: cache}
+	s.tcpServer = tcpserver.New(s.handleConnection, s.reportPanic)
+
+	return s
+}
+
+func (s *Server) reportPanic(value interface{}, stack []byte) {
+	if s.OnPanic != nil {
+		s.OnPanic(&tlru.PanicError{Callback: "handleConnection", Value: value, Stack: stack})
+	}
+}
+
+// ListenAndServe listens on the TCP network address addr and serves incoming connections
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts incoming connections on the provided listener
+// It blocks until the listener fails or the server is closed
+func (s *Server) Serve(listener net.Listener) error {
+	return s.tcpServer.Serve(listener)
+}
+
+// Close stops the listener, closes all active connections and waits for them to return
+func (s *Server) Close() error {
+	return s.tcpServer.Close()
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		line, err := tcpserver.ReadLine(reader, maxLineLength)
+		if err == tcpserver.ErrLineTooLong {
+			writer.WriteString("CLIENT_ERROR line too long\r\n")
+			writer.Flush()
+			return
+		}
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		quit := false
+		if len(args) == 0 {
+			writer.WriteString("ERROR\r\n")
+		} else {
+			quit = s.execute(reader, writer, args)
+		}
+		if err := writer.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+func (s *Server) execute(r *bufio.Reader, w *bufio.Writer, args []string) bool {
+	command := args[0]
+	args = args[1:]
+
+	switch command {
+	case "get":
+		if len(args) == 0 {
+			w.WriteString("ERROR\r\n")
+			break
+		}
+		for _, key := range args {
+			cacheEntry := s.cache.Get(key)
+			if cacheEntry == nil {
+				continue
+			}
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, cacheEntry.Value.Flags, len(cacheEntry.Value.Value))
+			w.Write(cacheEntry.Value.Value)
+			w.WriteString("\r\n")
+		}
+		w.WriteString("END\r\n")
+	case "set", "add", "replace":
+		return s.store(r, w, command, args)
+	case "delete":
+		if len(args) < 1 || len(args) > 2 {
+			w.WriteString("ERROR\r\n")
+			break
+		}
+		reply := "NOT_FOUND"
+		if s.cache.DeleteIfPresent(args[0]) {
+			reply = "DELETED"
+		}
+		writeReply(w, reply, isNoReply(args, 1))
+	case "flush_all":
+		s.cache.Clear()
+		writeReply(w, "OK", isNoReply(args, len(args)-1))
+	case "version":
+		w.WriteString("VERSION " + version + "\r\n")
+	case "quit":
+		return true
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+
+	return false
+}
+
+// store handles the "<command> <key> <flags> <exptime> <bytes> [noreply]" storage commands
+func (s *Server) store(r *bufio.Reader, w *bufio.Writer, command string, args []string) bool {
+	if len(args) < 4 || len(args) > 5 {
+		w.WriteString("ERROR\r\n")
+		return false
+	}
+
+	key := args[0]
+	flags, flagsErr := strconv.ParseUint(args[1], 10, 32)
+	_, exptimeErr := strconv.ParseInt(args[2], 10, 64)
+	size, sizeErr := strconv.Atoi(args[3])
+	if flagsErr != nil || exptimeErr != nil || sizeErr != nil || size < 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+	// The data block of an oversized item isn't read so the connection is closed
+	if size > maxItemSize {
+		w.WriteString("CLIENT_ERROR object too large for cache\r\n")
+		return true
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return true
+	}
+	if string(data[size:]) != "\r\n" {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+	if len(key) > maxKeyLength {
+		w.WriteString("CLIENT_ERROR key too long\r\n")
+		return false
+	}
+
+	noReply := isNoReply(args, 4)
+	item := Item{Flags: uint32(flags), Value: data[:size]}
+	stored := true
+	switch command {
+	case "add":
+		stored = s.cache.SetIfAbsent(key, item)
+	case "replace":
+		stored = s.cache.SetIfPresent(key, item)
+	default:
+		if _, _, err := s.cache.Swap(key, item); err != nil {
+			writeReply(w, "SERVER_ERROR "+err.Error(), noReply)
+			return false
+		}
+	}
+	if !stored {
+		writeReply(w, "NOT_STORED", noReply)
+		return false
+	}
+	writeReply(w, "STORED", noReply)
+
+	return false
+}
+
+func isNoReply(args []string, index int) bool {
+	return index >= 0 && index < len(args) && args[index] == "noreply"
+}
+
+func writeReply(w *bufio.Writer, reply string, noReply bool) {
+	if !noReply {
+		w.WriteString(reply + "\r\n")
+	}
+}
@@ -0,0 +1,136 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrumemcache
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, config tlru.Config[string, Item]) (net.Conn, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(tlru.New(config))
+	go server.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn, func() {
+		conn.Close()
+		server.Close()
+	}
+}
+
+func readLine(t *testing.T, reader *bufio.Reader) string {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return line
+}
+
+func TestServerCommands(t *testing.T) {
+	assert := assert.New(t)
+	conn, stop := startServer(t, tlru.Config[string, Item]{
+		MaxSize:        10,
+		TTL:            time.Minute,
+		EvictionPolicy: tlru.LRI,
+	})
+	defer stop()
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("set entry-1 42 0 5\r\nvalue\r\n"))
+	assert.Equal("STORED\r\n", readLine(t, reader))
+
+	conn.Write([]byte("add entry-1 0 0 5\r\nvalue\r\n"))
+	assert.Equal("NOT_STORED\r\n", readLine(t, reader))
+
+	conn.Write([]byte("replace entry-2 0 0 5\r\nvalue\r\n"))
+	assert.Equal("NOT_STORED\r\n", readLine(t, reader))
+
+	conn.Write([]byte("add entry-2 7 0 3\r\nabc\r\n"))
+	assert.Equal("STORED\r\n", readLine(t, reader))
+
+	conn.Write([]byte("get entry-1 non-existent entry-2\r\n"))
+	assert.Equal("VALUE entry-1 42 5\r\n", readLine(t, reader))
+	assert.Equal("value\r\n", readLine(t, reader))
+	assert.Equal("VALUE entry-2 7 3\r\n", readLine(t, reader))
+	assert.Equal("abc\r\n", readLine(t, reader))
+	assert.Equal("END\r\n", readLine(t, reader))
+
+	conn.Write([]byte("delete entry-1 noreply\r\n"))
+	conn.Write([]byte("delete entry-1\r\n"))
+	assert.Equal("NOT_FOUND\r\n", readLine(t, reader))
+
+	conn.Write([]byte("flush_all\r\n"))
+	assert.Equal("OK\r\n", readLine(t, reader))
+
+	conn.Write([]byte("get entry-2\r\n"))
+	assert.Equal("END\r\n", readLine(t, reader))
+
+	conn.Write([]byte("version\r\n"))
+	assert.Equal("VERSION tlru\r\n", readLine(t, reader))
+
+	conn.Write([]byte("incr entry-1 1\r\n"))
+	assert.Equal("ERROR\r\n", readLine(t, reader))
+}
+
+func TestServerSetExistingKey(t *testing.T) {
+	assert := assert.New(t)
+	for _, config := range []tlru.Config[string, Item]{
+		{MaxSize: 10, TTL: time.Minute, EvictionPolicy: tlru.LRA},
+		{MaxSize: 10, TTL: time.Minute, EvictionPolicy: tlru.LRI},
+	} {
+		conn, stop := startServer(t, config)
+		reader := bufio.NewReader(conn)
+
+		conn.Write([]byte("set entry-1 0 0 5\r\nvalue\r\n"))
+		assert.Equal("STORED\r\n", readLine(t, reader))
+
+		conn.Write([]byte("set entry-1 1 0 3\r\nabc\r\n"))
+		assert.Equal("STORED\r\n", readLine(t, reader))
+
+		conn.Write([]byte("replace entry-1 2 0 3\r\ndef\r\n"))
+		assert.Equal("STORED\r\n", readLine(t, reader))
+
+		conn.Write([]byte("get entry-1\r\n"))
+		assert.Equal("VALUE entry-1 2 3\r\n", readLine(t, reader))
+		assert.Equal("def\r\n", readLine(t, reader))
+		assert.Equal("END\r\n", readLine(t, reader))
+		stop()
+	}
+}
+
+func TestServerInvalidItemSize(t *testing.T) {
+	assert := assert.New(t)
+	for _, command := range []string{
+		"set entry-1 0 0 4611686018427387904\r\n",
+		"set entry-1 0 0 9223372036854775807\r\n",
+		"set entry-1 0 0 99999999999999999999\r\n",
+		"set entry-1 0 0 -5\r\n",
+		"set entry-1 0 0 five\r\n",
+		"get " + strings.Repeat("a", maxLineLength) + "\r\n",
+	} {
+		conn, stop := startServer(t, tlru.Config[string, Item]{MaxSize: 10, TTL: time.Minute})
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(command))
+		assert.Equal("CLIENT_ERROR", readLine(t, reader)[:12])
+		_, err := reader.ReadByte()
+		assert.Equal(io.EOF, err)
+		stop()
+	}
+}
@@ -0,0 +1,200 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlrupb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/jahnestacado/tlru/v3"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the Value message
+const (
+	valueStringField protowire.Number = iota + 1
+	valueBytesField
+	valueIntField
+	valueUintField
+	valueDoubleField
+	valueBoolField
+	valueCustomField
+)
+
+// Field numbers of the Custom message
+const (
+	customTypeField protowire.Number = iota + 1
+	customDataField
+)
+
+// appendValue appends the Value message encoding of v to b
+// Values whose type is registered in the provided registry are encoded as a Custom message
+func appendValue[T any](b []byte, v T, registry *tlru.TypeRegistry) ([]byte, error) {
+	rv := reflect.ValueOf(&v).Elem()
+	if registry != nil {
+		name, data, err := registry.Encode(v)
+		if err == nil {
+			var custom []byte
+			custom = protowire.AppendTag(custom, customTypeField, protowire.BytesType)
+			custom = protowire.AppendString(custom, name)
+			custom = protowire.AppendTag(custom, customDataField, protowire.BytesType)
+			custom = protowire.AppendBytes(custom, data)
+			b = protowire.AppendTag(b, valueCustomField, protowire.BytesType)
+			return protowire.AppendBytes(b, custom), nil
+		}
+		if !errors.Is(err, tlru.ErrUnregisteredType) {
+			return nil, err
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		b = protowire.AppendTag(b, valueStringField, protowire.BytesType)
+		b = protowire.AppendString(b, rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, fmt.Errorf("tlrupb: Type %s is not registered", rv.Type())
+		}
+		b = protowire.AppendTag(b, valueBytesField, protowire.BytesType)
+		b = protowire.AppendBytes(b, rv.Bytes())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b = protowire.AppendTag(b, valueIntField, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeZigZag(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b = protowire.AppendTag(b, valueUintField, protowire.VarintType)
+		b = protowire.AppendVarint(b, rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		b = protowire.AppendTag(b, valueDoubleField, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(rv.Float()))
+	case reflect.Bool:
+		b = protowire.AppendTag(b, valueBoolField, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(rv.Bool()))
+	default:
+		return nil, fmt.Errorf("tlrupb: Type %s is not registered", rv.Type())
+	}
+
+	return b, nil
+}
+
+// consumeValue decodes a Value message into v
+// Custom messages are decoded via the provided registry
+func consumeValue[T any](b []byte, v *T, registry *tlru.TypeRegistry) error {
+	rv := reflect.ValueOf(v).Elem()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case num == valueStringField && typ == protowire.BytesType && rv.Kind() == reflect.String:
+			s, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			rv.SetString(s)
+			b = b[n:]
+		case num == valueBytesField && typ == protowire.BytesType && rv.Kind() == reflect.Slice &&
+			rv.Type().Elem().Kind() == reflect.Uint8:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			rv.SetBytes(append([]byte{}, data...))
+			b = b[n:]
+		case num == valueIntField && typ == protowire.VarintType && rv.CanInt():
+			x, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			rv.SetInt(protowire.DecodeZigZag(x))
+			b = b[n:]
+		case num == valueUintField && typ == protowire.VarintType && rv.CanUint():
+			x, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			rv.SetUint(x)
+			b = b[n:]
+		case num == valueDoubleField && typ == protowire.Fixed64Type && rv.CanFloat():
+			x, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			rv.SetFloat(math.Float64frombits(x))
+			b = b[n:]
+		case num == valueBoolField && typ == protowire.VarintType && rv.Kind() == reflect.Bool:
+			x, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			rv.SetBool(protowire.DecodeBool(x))
+			b = b[n:]
+		case num == valueCustomField && typ == protowire.BytesType:
+			custom, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := consumeCustom(custom, rv, registry); err != nil {
+				return err
+			}
+			b = b[n:]
+		case num >= valueStringField && num <= valueCustomField:
+			return fmt.Errorf("tlrupb: Cannot decode field %d into type %s", num, rv.Type())
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+
+	return nil
+}
+
+func consumeCustom(b []byte, rv reflect.Value, registry *tlru.TypeRegistry) error {
+	var (
+		name string
+		data []byte
+	)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == customTypeField && typ == protowire.BytesType:
+			name, n = protowire.ConsumeString(b)
+		case num == customDataField && typ == protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	if registry == nil {
+		return fmt.Errorf("tlrupb: Cannot decode type '%s' without a TypeRegistry. %w", name, tlru.ErrUnregisteredType)
+	}
+	decoded, err := registry.Decode(name, data)
+	if err != nil {
+		return err
+	}
+	decodedValue := reflect.ValueOf(decoded)
+	if !decodedValue.IsValid() || !decodedValue.Type().AssignableTo(rv.Type()) {
+		return fmt.Errorf("tlrupb: Cannot decode type '%s' into type %s", name, rv.Type())
+	}
+	rv.Set(decodedValue)
+
+	return nil
+}
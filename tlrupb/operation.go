@@ -0,0 +1,156 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlrupb
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jahnestacado/tlru/v3"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the Operation message
+const (
+	operationTypeField protowire.Number = iota + 1
+	operationKeyField
+	operationEventField
+	operationValueField
+	operationPreviousValueField
+	operationLastUsedAtField
+	operationOccurredAtField
+	operationCoalescedField
+)
+
+// MarshalOperation returns the Operation message encoding of the provided operation
+// The same key and value types as in MarshalEvictedEntry are supported
+func MarshalOperation[K comparable, V any](operation tlru.Operation[K, V]) ([]byte, error) {
+	return MarshalOperationWithRegistry(nil, operation)
+}
+
+// MarshalOperationWithRegistry is identical to MarshalOperation but it encodes the keys
+// and values whose types are registered in the provided TypeRegistry as a Custom message
+func MarshalOperationWithRegistry[K comparable, V any](registry *tlru.TypeRegistry, operation tlru.Operation[K, V]) ([]byte, error) {
+	var b []byte
+	b = protowire.AppendTag(b, operationTypeField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(operation.Type))
+	b, err := appendValueField(b, operationKeyField, operation.Key, registry)
+	if err != nil {
+		return nil, err
+	}
+	b = protowire.AppendTag(b, operationEventField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(operation.Event))
+	if b, err = appendValueField(b, operationValueField, operation.Value, registry); err != nil {
+		return nil, err
+	}
+	if b, err = appendValueField(b, operationPreviousValueField, operation.PreviousValue, registry); err != nil {
+		return nil, err
+	}
+	b = appendTimestamp(b, operationLastUsedAtField, operation.LastUsedAt)
+	b = appendTimestamp(b, operationOccurredAtField, operation.OccurredAt)
+	b = protowire.AppendTag(b, operationCoalescedField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(operation.Coalesced))
+
+	return b, nil
+}
+
+// UnmarshalOperation decodes an Operation message
+func UnmarshalOperation[K comparable, V any](b []byte) (tlru.Operation[K, V], error) {
+	return UnmarshalOperationWithRegistry[K, V](nil, b)
+}
+
+// UnmarshalOperationWithRegistry decodes an Operation message whose Custom messages
+// have been encoded by MarshalOperationWithRegistry with an equivalent TypeRegistry
+func UnmarshalOperationWithRegistry[K comparable, V any](registry *tlru.TypeRegistry, b []byte) (tlru.Operation[K, V], error) {
+	var operation tlru.Operation[K, V]
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return operation, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var err error
+		switch {
+		case typ == protowire.VarintType && num == operationTypeField:
+			var operationType uint64
+			operationType, n = protowire.ConsumeVarint(b)
+			switch operationType {
+			case 0:
+				operation.Type = tlru.OperationSet
+			case 1:
+				operation.Type = tlru.OperationDelete
+			case 2:
+				operation.Type = tlru.OperationClear
+			case 3:
+				operation.Type = tlru.OperationExpiring
+			default:
+				err = fmt.Errorf("tlrupb: Unknown OperationType %d", operationType)
+			}
+		case typ == protowire.BytesType && num == operationKeyField:
+			var key []byte
+			if key, n = protowire.ConsumeBytes(b); n >= 0 {
+				err = consumeValue(key, &operation.Key, registry)
+			}
+		case typ == protowire.VarintType && num == operationEventField:
+			var event uint64
+			event, n = protowire.ConsumeVarint(b)
+			switch event {
+			case 0:
+				operation.Event = tlru.EventNone
+			case 1:
+				operation.Event = tlru.EventInserted
+			case 2:
+				operation.Event = tlru.EventUpdated
+			default:
+				err = fmt.Errorf("tlrupb: Unknown EventType %d", event)
+			}
+		case typ == protowire.BytesType && num == operationValueField:
+			var value []byte
+			if value, n = protowire.ConsumeBytes(b); n >= 0 {
+				err = consumeValue(value, &operation.Value, registry)
+			}
+		case typ == protowire.BytesType && num == operationPreviousValueField:
+			var previousValue []byte
+			if previousValue, n = protowire.ConsumeBytes(b); n >= 0 {
+				err = consumeValue(previousValue, &operation.PreviousValue, registry)
+			}
+		case typ == protowire.BytesType && num == operationLastUsedAtField:
+			operation.LastUsedAt, n, err = consumeTimestamp(b)
+		case typ == protowire.BytesType && num == operationOccurredAtField:
+			operation.OccurredAt, n, err = consumeTimestamp(b)
+		case typ == protowire.VarintType && num == operationCoalescedField:
+			var coalesced uint64
+			coalesced, n = protowire.ConsumeVarint(b)
+			operation.Coalesced = int(coalesced)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return operation, protowire.ParseError(n)
+		}
+		if err != nil {
+			return operation, err
+		}
+		b = b[n:]
+	}
+
+	return operation, nil
+}
+
+// appendValueField appends v as a Value message field unless it is the zero value of its
+// type, since the key and the values of an Operation are only set for some of its types
+func appendValueField[T any](b []byte, num protowire.Number, v T, registry *tlru.TypeRegistry) ([]byte, error) {
+	if reflect.ValueOf(&v).Elem().IsZero() {
+		return b, nil
+	}
+	value, err := appendValue(nil, v, registry)
+	if err != nil {
+		return nil, err
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendBytes(b, value), nil
+}
@@ -0,0 +1,302 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrupb encodes tlru eviction events and operations as protobuf messages
+// The schema is defined in tlru.proto so that consumers in any language can
+// generate their own decoders
+package tlrupb
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the EvictedEntry message
+const (
+	evictedEntryKeyField protowire.Number = iota + 1
+	evictedEntryValueField
+	evictedEntryCounterField
+	evictedEntryLastUsedAtField
+	evictedEntryCreatedAtField
+	evictedEntryEvictedAtField
+	evictedEntryReasonField
+	evictedEntryVersionField
+	evictedEntryMetadataField
+	evictedEntryTTLField
+)
+
+// Field numbers of the entries of a map field
+const (
+	mapEntryKeyField protowire.Number = iota + 1
+	mapEntryValueField
+)
+
+// Field numbers of the Timestamp message
+const (
+	timestampSecondsField protowire.Number = iota + 1
+	timestampNanosField
+)
+
+// Field numbers of the Duration message
+const (
+	durationSecondsField protowire.Number = iota + 1
+	durationNanosField
+)
+
+// MarshalEvictedEntry returns the EvictedEntry message encoding of the provided entry
+// Strings, byte slices, integers, floats and booleans(including named types with such
+// an underlying type) are supported as keys and values. See MarshalEvictedEntryWithRegistry
+// for other types
+func MarshalEvictedEntry[K comparable, V any](entry tlru.EvictedEntry[K, V]) ([]byte, error) {
+	return MarshalEvictedEntryWithRegistry(nil, entry)
+}
+
+// MarshalEvictedEntryWithRegistry is identical to MarshalEvictedEntry but it encodes the keys
+// and values whose types are registered in the provided TypeRegistry as a Custom message
+func MarshalEvictedEntryWithRegistry[K comparable, V any](registry *tlru.TypeRegistry, entry tlru.EvictedEntry[K, V]) ([]byte, error) {
+	key, err := appendValue(nil, entry.Key, registry)
+	if err != nil {
+		return nil, err
+	}
+	value, err := appendValue(nil, entry.Value, registry)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, evictedEntryKeyField, protowire.BytesType)
+	b = protowire.AppendBytes(b, key)
+	b = protowire.AppendTag(b, evictedEntryValueField, protowire.BytesType)
+	b = protowire.AppendBytes(b, value)
+	b = protowire.AppendTag(b, evictedEntryCounterField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(entry.Counter))
+	b = appendTimestamp(b, evictedEntryLastUsedAtField, entry.LastUsedAt)
+	b = appendTimestamp(b, evictedEntryCreatedAtField, entry.CreatedAt)
+	b = appendTimestamp(b, evictedEntryEvictedAtField, entry.EvictedAt)
+	b = protowire.AppendTag(b, evictedEntryReasonField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(entry.Reason))
+	b = protowire.AppendTag(b, evictedEntryVersionField, protowire.VarintType)
+	b = protowire.AppendVarint(b, entry.Version)
+	b = appendMetadata(b, entry.Metadata)
+	b = appendDuration(b, evictedEntryTTLField, entry.TTL)
+
+	return b, nil
+}
+
+// UnmarshalEvictedEntry decodes an EvictedEntry message
+func UnmarshalEvictedEntry[K comparable, V any](b []byte) (tlru.EvictedEntry[K, V], error) {
+	return UnmarshalEvictedEntryWithRegistry[K, V](nil, b)
+}
+
+// UnmarshalEvictedEntryWithRegistry decodes an EvictedEntry message whose Custom messages
+// have been encoded by MarshalEvictedEntryWithRegistry with an equivalent TypeRegistry
+func UnmarshalEvictedEntryWithRegistry[K comparable, V any](registry *tlru.TypeRegistry, b []byte) (tlru.EvictedEntry[K, V], error) {
+	var entry tlru.EvictedEntry[K, V]
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return entry, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var err error
+		switch {
+		case typ == protowire.BytesType && num == evictedEntryKeyField:
+			var key []byte
+			if key, n = protowire.ConsumeBytes(b); n >= 0 {
+				err = consumeValue(key, &entry.Key, registry)
+			}
+		case typ == protowire.BytesType && num == evictedEntryValueField:
+			var value []byte
+			if value, n = protowire.ConsumeBytes(b); n >= 0 {
+				err = consumeValue(value, &entry.Value, registry)
+			}
+		case typ == protowire.VarintType && num == evictedEntryCounterField:
+			var counter uint64
+			counter, n = protowire.ConsumeVarint(b)
+			entry.Counter = int64(counter)
+		case typ == protowire.BytesType && num == evictedEntryLastUsedAtField:
+			entry.LastUsedAt, n, err = consumeTimestamp(b)
+		case typ == protowire.BytesType && num == evictedEntryCreatedAtField:
+			entry.CreatedAt, n, err = consumeTimestamp(b)
+		case typ == protowire.BytesType && num == evictedEntryEvictedAtField:
+			entry.EvictedAt, n, err = consumeTimestamp(b)
+		case typ == protowire.VarintType && num == evictedEntryReasonField:
+			var reason uint64
+			reason, n = protowire.ConsumeVarint(b)
+			switch reason {
+			case 0:
+				entry.Reason = tlru.EvictionReasonDropped
+			case 1:
+				entry.Reason = tlru.EvictionReasonExpired
+			case 2:
+				entry.Reason = tlru.EvictionReasonDeleted
+			case 3:
+				entry.Reason = tlru.EvictionReasonInvalidated
+			default:
+				err = fmt.Errorf("tlrupb: Unknown EvictionReason %d", reason)
+			}
+		case typ == protowire.VarintType && num == evictedEntryVersionField:
+			entry.Version, n = protowire.ConsumeVarint(b)
+		case typ == protowire.BytesType && num == evictedEntryMetadataField:
+			if entry.Metadata == nil {
+				entry.Metadata = make(map[string]string)
+			}
+			n, err = consumeMetadataEntry(b, entry.Metadata)
+		case typ == protowire.BytesType && num == evictedEntryTTLField:
+			entry.TTL, n, err = consumeDuration(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return entry, protowire.ParseError(n)
+		}
+		if err != nil {
+			return entry, err
+		}
+		b = b[n:]
+	}
+
+	return entry, nil
+}
+
+// appendMetadata appends the entries of the metadata map field sorted by key
+func appendMetadata(b []byte, metadata map[string]string) []byte {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var mapEntry []byte
+		mapEntry = protowire.AppendTag(mapEntry, mapEntryKeyField, protowire.BytesType)
+		mapEntry = protowire.AppendString(mapEntry, key)
+		mapEntry = protowire.AppendTag(mapEntry, mapEntryValueField, protowire.BytesType)
+		mapEntry = protowire.AppendString(mapEntry, metadata[key])
+		b = protowire.AppendTag(b, evictedEntryMetadataField, protowire.BytesType)
+		b = protowire.AppendBytes(b, mapEntry)
+	}
+
+	return b
+}
+
+func consumeMetadataEntry(b []byte, metadata map[string]string) (int, error) {
+	mapEntry, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, nil
+	}
+
+	var key, value string
+	for len(mapEntry) > 0 {
+		num, typ, m := protowire.ConsumeTag(mapEntry)
+		if m < 0 {
+			return n, protowire.ParseError(m)
+		}
+		mapEntry = mapEntry[m:]
+		switch {
+		case typ == protowire.BytesType && num == mapEntryKeyField:
+			key, m = protowire.ConsumeString(mapEntry)
+		case typ == protowire.BytesType && num == mapEntryValueField:
+			value, m = protowire.ConsumeString(mapEntry)
+		default:
+			m = protowire.ConsumeFieldValue(num, typ, mapEntry)
+		}
+		if m < 0 {
+			return n, protowire.ParseError(m)
+		}
+		mapEntry = mapEntry[m:]
+	}
+	metadata[key] = value
+
+	return n, nil
+}
+
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	var timestamp []byte
+	timestamp = protowire.AppendTag(timestamp, timestampSecondsField, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(t.Unix()))
+	timestamp = protowire.AppendTag(timestamp, timestampNanosField, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(t.Nanosecond()))
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendBytes(b, timestamp)
+}
+
+func consumeTimestamp(b []byte) (time.Time, int, error) {
+	timestamp, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return time.Time{}, n, nil
+	}
+
+	var seconds, nanos uint64
+	for len(timestamp) > 0 {
+		num, typ, m := protowire.ConsumeTag(timestamp)
+		if m < 0 {
+			return time.Time{}, n, protowire.ParseError(m)
+		}
+		timestamp = timestamp[m:]
+		switch {
+		case typ == protowire.VarintType && num == timestampSecondsField:
+			seconds, m = protowire.ConsumeVarint(timestamp)
+		case typ == protowire.VarintType && num == timestampNanosField:
+			nanos, m = protowire.ConsumeVarint(timestamp)
+		default:
+			m = protowire.ConsumeFieldValue(num, typ, timestamp)
+		}
+		if m < 0 {
+			return time.Time{}, n, protowire.ParseError(m)
+		}
+		timestamp = timestamp[m:]
+	}
+
+	return time.Unix(int64(seconds), int64(nanos)).UTC(), n, nil
+}
+
+func appendDuration(b []byte, num protowire.Number, d time.Duration) []byte {
+	var duration []byte
+	duration = protowire.AppendTag(duration, durationSecondsField, protowire.VarintType)
+	duration = protowire.AppendVarint(duration, uint64(d/time.Second))
+	duration = protowire.AppendTag(duration, durationNanosField, protowire.VarintType)
+	duration = protowire.AppendVarint(duration, uint64(d%time.Second))
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendBytes(b, duration)
+}
+
+func consumeDuration(b []byte) (time.Duration, int, error) {
+	duration, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return 0, n, nil
+	}
+
+	var seconds, nanos uint64
+	for len(duration) > 0 {
+		num, typ, m := protowire.ConsumeTag(duration)
+		if m < 0 {
+			return 0, n, protowire.ParseError(m)
+		}
+		duration = duration[m:]
+		switch {
+		case typ == protowire.VarintType && num == durationSecondsField:
+			seconds, m = protowire.ConsumeVarint(duration)
+		case typ == protowire.VarintType && num == durationNanosField:
+			nanos, m = protowire.ConsumeVarint(duration)
+		default:
+			m = protowire.ConsumeFieldValue(num, typ, duration)
+		}
+		if m < 0 {
+			return 0, n, protowire.ParseError(m)
+		}
+		duration = duration[m:]
+	}
+
+	return time.Duration(int64(seconds))*time.Second + time.Duration(int32(nanos)), n, nil
+}
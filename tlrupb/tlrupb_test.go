@@ -0,0 +1,167 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrupb
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func newEvictedEntry[K comparable, V any](key K, value V) tlru.EvictedEntry[K, V] {
+	now := time.Now().UTC()
+	return tlru.EvictedEntry[K, V]{
+		CacheEntry: tlru.CacheEntry[K, V]{
+			Key:        key,
+			Value:      value,
+			Counter:    3,
+			LastUsedAt: now.Add(-time.Second),
+			CreatedAt:  now.Add(-time.Minute),
+			Version:    5,
+			Metadata:   map[string]string{"tenant": "acme", "source": "db"},
+			TTL:        90*time.Minute + 250*time.Millisecond,
+		},
+		EvictedAt: now,
+		Reason:    tlru.EvictionReasonExpired,
+	}
+}
+
+func TestMarshalAndUnmarshalEvictedEntry(t *testing.T) {
+	assert := assert.New(t)
+
+	stringEntry := newEvictedEntry("entry-1", "value")
+	b, err := MarshalEvictedEntry(stringEntry)
+	assert.NoError(err)
+	decodedStringEntry, err := UnmarshalEvictedEntry[string, string](b)
+	assert.NoError(err)
+	assert.Equal(stringEntry, decodedStringEntry)
+
+	intEntry := newEvictedEntry(int64(-42), 3.14)
+	b, err = MarshalEvictedEntry(intEntry)
+	assert.NoError(err)
+	decodedIntEntry, err := UnmarshalEvictedEntry[int64, float64](b)
+	assert.NoError(err)
+	assert.Equal(intEntry, decodedIntEntry)
+
+	bytesEntry := newEvictedEntry(uint8(7), []byte("value"))
+	b, err = MarshalEvictedEntry(bytesEntry)
+	assert.NoError(err)
+	decodedBytesEntry, err := UnmarshalEvictedEntry[uint8, []byte](b)
+	assert.NoError(err)
+	assert.Equal(bytesEntry, decodedBytesEntry)
+}
+
+func TestMarshalEvictedEntryWithRegisteredCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	entry := newEvictedEntry(true, point{X: 1, Y: 2})
+	_, err := MarshalEvictedEntry(entry)
+	assert.Error(err)
+
+	registry := tlru.NewTypeRegistry()
+	_, err = MarshalEvictedEntryWithRegistry(registry, entry)
+	assert.Error(err)
+
+	tlru.RegisterType(registry, "point", func(p point) ([]byte, error) {
+		return json.Marshal(p)
+	}, func(data []byte) (point, error) {
+		var p point
+		err := json.Unmarshal(data, &p)
+		return p, err
+	})
+
+	b, err := MarshalEvictedEntryWithRegistry(registry, entry)
+	assert.NoError(err)
+	decodedEntry, err := UnmarshalEvictedEntryWithRegistry[bool, point](registry, b)
+	assert.NoError(err)
+	assert.Equal(entry, decodedEntry)
+
+	_, err = UnmarshalEvictedEntry[bool, point](b)
+	assert.True(errors.Is(err, tlru.ErrUnregisteredType))
+
+	anyEntry := newEvictedEntry[string, any]("entry-1", point{X: 3, Y: 4})
+	b, err = MarshalEvictedEntryWithRegistry(registry, anyEntry)
+	assert.NoError(err)
+	decodedAnyEntry, err := UnmarshalEvictedEntryWithRegistry[string, any](registry, b)
+	assert.NoError(err)
+	assert.Equal(anyEntry, decodedAnyEntry)
+}
+
+func TestUnmarshalEvictedEntryWithIncompatibleType(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := MarshalEvictedEntry(newEvictedEntry("entry-1", "value"))
+	assert.NoError(err)
+
+	_, err = UnmarshalEvictedEntry[string, int](b)
+	assert.Error(err)
+}
+
+func TestMarshalAndUnmarshalOperation(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now().UTC()
+
+	for _, operation := range []tlru.Operation[string, []byte]{
+		{
+			Type:          tlru.OperationSet,
+			Key:           "entry-1",
+			Event:         tlru.EventUpdated,
+			Value:         []byte("value"),
+			PreviousValue: []byte("previous"),
+			LastUsedAt:    now.Add(-time.Second),
+			OccurredAt:    now,
+			Coalesced:     2,
+		},
+		{Type: tlru.OperationDelete, Key: "entry-1", OccurredAt: now},
+		{Type: tlru.OperationClear, OccurredAt: now},
+		{Type: tlru.OperationExpiring, Key: "entry-1", Value: []byte("value"), LastUsedAt: now, OccurredAt: now},
+	} {
+		b, err := MarshalOperation(operation)
+		assert.NoError(err)
+		decodedOperation, err := UnmarshalOperation[string, []byte](b)
+		assert.NoError(err)
+		assert.Equal(operation, decodedOperation)
+	}
+}
+
+func TestMarshalOperationWithRegisteredCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	operation := tlru.Operation[string, point]{
+		Type:       tlru.OperationSet,
+		Key:        "entry-1",
+		Event:      tlru.EventInserted,
+		Value:      point{X: 1, Y: 2},
+		OccurredAt: time.Now().UTC(),
+	}
+	_, err := MarshalOperation(operation)
+	assert.Error(err)
+
+	registry := tlru.NewTypeRegistry()
+	tlru.RegisterType(registry, "point", func(p point) ([]byte, error) {
+		return json.Marshal(p)
+	}, func(data []byte) (point, error) {
+		var p point
+		err := json.Unmarshal(data, &p)
+		return p, err
+	})
+
+	b, err := MarshalOperationWithRegistry(registry, operation)
+	assert.NoError(err)
+	decodedOperation, err := UnmarshalOperationWithRegistry[string, point](registry, b)
+	assert.NoError(err)
+	assert.Equal(operation, decodedOperation)
+
+	_, err = UnmarshalOperation[string, point](b)
+	assert.True(errors.Is(err, tlru.ErrUnregisteredType))
+}
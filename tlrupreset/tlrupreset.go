@@ -0,0 +1,58 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrupreset provides ready-made caches for values which are expensive
+// to build from their key, such as compiled regular expressions and templates
+package tlrupreset
+
+import (
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+// CompileCache caches values which are compiled from their key on a miss
+// Compilation errors are returned to the caller and are not cached
+type CompileCache[V any] struct {
+	cache   *tlru.TLRU[string, V]
+	compile func(string) (V, error)
+}
+
+// NewCompileCache returns a new CompileCache which uses the LRA EvictionPolicy
+// and the provided compile function to build missing values
+func NewCompileCache[V any](maxSize int, ttl time.Duration, compile func(string) (V, error)) *CompileCache[V] {
+	return &CompileCache[V]{
+		cache: tlru.New(tlru.Config[string, V]{
+			MaxSize:        maxSize,
+			TTL:            ttl,
+			EvictionPolicy: tlru.LRA,
+		}),
+		compile: compile,
+	}
+}
+
+// Get returns the cached value of the provided key or compiles and caches it on a miss
+func (c *CompileCache[V]) Get(key string) (V, error) {
+	return c.cache.GetOrCompute(key, c.compile)
+}
+
+// Cache returns the underlying cache
+func (c *CompileCache[V]) Cache() *tlru.TLRU[string, V] {
+	return c.cache
+}
+
+// NewRegexpCache returns a CompileCache of regular expressions keyed by their pattern
+func NewRegexpCache(maxSize int, ttl time.Duration) *CompileCache[*regexp.Regexp] {
+	return NewCompileCache(maxSize, ttl, regexp.Compile)
+}
+
+// NewTemplateCache returns a CompileCache of text templates keyed by their text
+// The provided funcs(optional) are added to every parsed template
+func NewTemplateCache(maxSize int, ttl time.Duration, funcs template.FuncMap) *CompileCache[*template.Template] {
+	return NewCompileCache(maxSize, ttl, func(text string) (*template.Template, error) {
+		return template.New("").Funcs(funcs).Parse(text)
+	})
+}
@@ -0,0 +1,47 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrupreset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexpCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewRegexpCache(10, time.Minute)
+
+	re, err := cache.Get("^entry-[0-9]+$")
+	assert.NoError(err)
+	assert.True(re.MatchString("entry-1"))
+
+	cachedRe, err := cache.Get("^entry-[0-9]+$")
+	assert.NoError(err)
+	assert.Same(re, cachedRe)
+
+	_, err = cache.Get("entry-[")
+	assert.Error(err)
+	assert.False(cache.Cache().Has("entry-["))
+}
+
+func TestTemplateCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewTemplateCache(10, time.Minute, template.FuncMap{"upper": strings.ToUpper})
+
+	tmpl, err := cache.Get("Hello {{ upper . }}")
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(tmpl.Execute(&buf, "gopher"))
+	assert.Equal("Hello GOPHER", buf.String())
+
+	cachedTmpl, err := cache.Get("Hello {{ upper . }}")
+	assert.NoError(err)
+	assert.Same(tmpl, cachedTmpl)
+}
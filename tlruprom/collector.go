@@ -0,0 +1,85 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlruprom
+
+import (
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector exports the hit ratio, the size, the evictions per reason and the garbage
+// collection passes of a cache which are read from its Stats on every scrape
+// The metrics are labeled with the cache label whose value is tlru.Config.Name
+// so that multiple caches can be registered to the same prometheus.Registerer
+// It implements prometheus.Collector
+type Collector[K comparable, V any] struct {
+	cache             *tlru.TLRU[K, V]
+	hitRatio          *prometheus.Desc
+	entries           *prometheus.Desc
+	evictions         *prometheus.Desc
+	garbageCollection *prometheus.Desc
+}
+
+// NewCollector returns a new Collector of the provided cache which has to be
+// registered to a prometheus.Registerer. Config.Buckets is ignored
+func NewCollector[K comparable, V any](cache *tlru.TLRU[K, V], config Config) *Collector[K, V] {
+	if config.Namespace == "" {
+		config.Namespace = "tlru"
+	}
+	constLabels := prometheus.Labels{"cache": cache.Config().Name}
+	for name, value := range config.ConstLabels {
+		constLabels[name] = value
+	}
+
+	return &Collector[K, V]{
+		cache: cache,
+		hitRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(config.Namespace, "", "hit_ratio"),
+			"Ratio of the calls of Get which found a non-expired entry.",
+			nil, constLabels,
+		),
+		entries: prometheus.NewDesc(
+			prometheus.BuildFQName(config.Namespace, "", "entries"),
+			"Number of entries in the cache including the expired ones which haven't been evicted yet.",
+			nil, constLabels,
+		),
+		evictions: prometheus.NewDesc(
+			prometheus.BuildFQName(config.Namespace, "", "evictions_total"),
+			"Number of entries which have been evicted.",
+			[]string{"reason"}, constLabels,
+		),
+		garbageCollection: prometheus.NewDesc(
+			prometheus.BuildFQName(config.Namespace, "", "garbage_collection_duration_seconds"),
+			"Time spent in garbage collection passes.",
+			nil, constLabels,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector[K, V]) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hitRatio
+	ch <- c.entries
+	ch <- c.evictions
+	ch <- c.garbageCollection
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector[K, V]) Collect(ch chan<- prometheus.Metric) {
+	stats := c.cache.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hitRatio, prometheus.GaugeValue, stats.HitRatio)
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(c.cache.Len()))
+	evictions := map[string]uint64{
+		tlru.EvictionReasonDropped.String():     stats.Drops,
+		tlru.EvictionReasonExpired.String():     stats.Expirations,
+		tlru.EvictionReasonDeleted.String():     stats.Deletes,
+		tlru.EvictionReasonInvalidated.String(): stats.Invalidations,
+	}
+	for reason, count := range evictions {
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(count), reason)
+	}
+	ch <- prometheus.MustNewConstSummary(c.garbageCollection, stats.GarbageCollections,
+		stats.GarbageCollectionTime.Seconds(), nil)
+}
@@ -0,0 +1,77 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlruprom exposes Prometheus metrics about the entries evicted from a tlru cache
+// and about the operations of a cache via Collector
+package tlruprom
+
+import (
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config of Metrics
+type Config struct {
+	// Namespace of the metrics. If not set it defaults to "tlru"
+	Namespace string
+	// Optional constant labels which are attached to all metrics, e.g. the cache name
+	ConstLabels prometheus.Labels
+	// Buckets of the histograms in seconds. If not set prometheus.DefBuckets is used
+	Buckets []float64
+}
+
+// Metrics records the age (EvictedAt-CreatedAt) and the idle time (EvictedAt-LastUsedAt)
+// of evicted entries in histograms segmented by eviction reason
+// It implements prometheus.Collector
+type Metrics[K comparable, V any] struct {
+	age  *prometheus.HistogramVec
+	idle *prometheus.HistogramVec
+}
+
+// New returns new Metrics which have to be registered to a prometheus.Registerer
+func New[K comparable, V any](config Config) *Metrics[K, V] {
+	if config.Namespace == "" {
+		config.Namespace = "tlru"
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	return &Metrics[K, V]{
+		age: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   config.Namespace,
+			Name:        "evicted_entry_age_seconds",
+			Help:        "Time between the creation and the eviction of evicted entries.",
+			ConstLabels: config.ConstLabels,
+			Buckets:     config.Buckets,
+		}, []string{"reason"}),
+		idle: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   config.Namespace,
+			Name:        "evicted_entry_idle_seconds",
+			Help:        "Time between the last use and the eviction of evicted entries.",
+			ConstLabels: config.ConstLabels,
+			Buckets:     config.Buckets,
+		}, []string{"reason"}),
+	}
+}
+
+// Observe records the provided EvictedEntry
+// It can be passed to tlru.DrainEvictions in order to record all evictions of a cache
+func (m *Metrics[K, V]) Observe(evictedEntry tlru.EvictedEntry[K, V]) {
+	reason := evictedEntry.Reason.String()
+	m.age.WithLabelValues(reason).Observe(evictedEntry.EvictedAt.Sub(evictedEntry.CreatedAt).Seconds())
+	m.idle.WithLabelValues(reason).Observe(evictedEntry.EvictedAt.Sub(evictedEntry.LastUsedAt).Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics[K, V]) Describe(ch chan<- *prometheus.Desc) {
+	m.age.Describe(ch)
+	m.idle.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics[K, V]) Collect(ch chan<- prometheus.Metric) {
+	m.age.Collect(ch)
+	m.idle.Collect(ch)
+}
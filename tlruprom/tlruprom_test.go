@@ -0,0 +1,132 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlruprom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEvictedEntry(age time.Duration, idle time.Duration, evictedEntry tlru.EvictedEntry[string, int]) tlru.EvictedEntry[string, int] {
+	evictedEntry.EvictedAt = time.Now()
+	evictedEntry.CreatedAt = evictedEntry.EvictedAt.Add(-age)
+	evictedEntry.LastUsedAt = evictedEntry.EvictedAt.Add(-idle)
+	return evictedEntry
+}
+
+func TestMetricsObserve(t *testing.T) {
+	assert := assert.New(t)
+	metrics := New[string, int](Config{Buckets: []float64{1, 60}})
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(registry.Register(metrics))
+
+	metrics.Observe(newEvictedEntry(90*time.Second, 30*time.Second, tlru.EvictedEntry[string, int]{Reason: tlru.EvictionReasonDropped}))
+	metrics.Observe(newEvictedEntry(2*time.Minute, 2*time.Minute, tlru.EvictedEntry[string, int]{Reason: tlru.EvictionReasonExpired}))
+	metrics.Observe(newEvictedEntry(500*time.Millisecond, 500*time.Millisecond, tlru.EvictedEntry[string, int]{Reason: tlru.EvictionReasonDeleted}))
+
+	expected := `
+# HELP tlru_evicted_entry_age_seconds Time between the creation and the eviction of evicted entries.
+# TYPE tlru_evicted_entry_age_seconds histogram
+tlru_evicted_entry_age_seconds_bucket{reason="Deleted",le="1"} 1
+tlru_evicted_entry_age_seconds_bucket{reason="Deleted",le="60"} 1
+tlru_evicted_entry_age_seconds_bucket{reason="Deleted",le="+Inf"} 1
+tlru_evicted_entry_age_seconds_sum{reason="Deleted"} 0.5
+tlru_evicted_entry_age_seconds_count{reason="Deleted"} 1
+tlru_evicted_entry_age_seconds_bucket{reason="Dropped",le="1"} 0
+tlru_evicted_entry_age_seconds_bucket{reason="Dropped",le="60"} 0
+tlru_evicted_entry_age_seconds_bucket{reason="Dropped",le="+Inf"} 1
+tlru_evicted_entry_age_seconds_sum{reason="Dropped"} 90
+tlru_evicted_entry_age_seconds_count{reason="Dropped"} 1
+tlru_evicted_entry_age_seconds_bucket{reason="Expired",le="1"} 0
+tlru_evicted_entry_age_seconds_bucket{reason="Expired",le="60"} 0
+tlru_evicted_entry_age_seconds_bucket{reason="Expired",le="+Inf"} 1
+tlru_evicted_entry_age_seconds_sum{reason="Expired"} 120
+tlru_evicted_entry_age_seconds_count{reason="Expired"} 1
+# HELP tlru_evicted_entry_idle_seconds Time between the last use and the eviction of evicted entries.
+# TYPE tlru_evicted_entry_idle_seconds histogram
+tlru_evicted_entry_idle_seconds_bucket{reason="Deleted",le="1"} 1
+tlru_evicted_entry_idle_seconds_bucket{reason="Deleted",le="60"} 1
+tlru_evicted_entry_idle_seconds_bucket{reason="Deleted",le="+Inf"} 1
+tlru_evicted_entry_idle_seconds_sum{reason="Deleted"} 0.5
+tlru_evicted_entry_idle_seconds_count{reason="Deleted"} 1
+tlru_evicted_entry_idle_seconds_bucket{reason="Dropped",le="1"} 0
+tlru_evicted_entry_idle_seconds_bucket{reason="Dropped",le="60"} 1
+tlru_evicted_entry_idle_seconds_bucket{reason="Dropped",le="+Inf"} 1
+tlru_evicted_entry_idle_seconds_sum{reason="Dropped"} 30
+tlru_evicted_entry_idle_seconds_count{reason="Dropped"} 1
+tlru_evicted_entry_idle_seconds_bucket{reason="Expired",le="1"} 0
+tlru_evicted_entry_idle_seconds_bucket{reason="Expired",le="60"} 0
+tlru_evicted_entry_idle_seconds_bucket{reason="Expired",le="+Inf"} 1
+tlru_evicted_entry_idle_seconds_sum{reason="Expired"} 120
+tlru_evicted_entry_idle_seconds_count{reason="Expired"} 1
+`
+	assert.NoError(testutil.GatherAndCompare(registry, strings.NewReader(expected)))
+}
+
+func TestMetricsWithDrainEvictions(t *testing.T) {
+	assert := assert.New(t)
+	metrics := New[string, int](Config{})
+
+	evictionChannel := make(chan tlru.EvictedEntry[string, int])
+	cache := tlru.New(tlru.Config[string, int]{
+		MaxSize:         1,
+		TTL:             time.Minute,
+		EvictionChannel: &evictionChannel,
+	})
+	done := tlru.DrainEvictions(context.Background(), cache, metrics.Observe)
+
+	cache.Set("entry-1", 1)
+	cache.Set("entry-2", 2)
+	cache.Delete("entry-2")
+	close(evictionChannel)
+	<-done
+
+	assert.Equal(4, testutil.CollectAndCount(metrics))
+}
+
+func TestCollector(t *testing.T) {
+	assert := assert.New(t)
+	cache := tlru.New(tlru.Config[string, int]{MaxSize: 1, TTL: time.Minute, Name: "sessions"})
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(registry.Register(NewCollector(cache, Config{})))
+	assert.NoError(registry.Register(NewCollector(tlru.New(tlru.Config[string, int]{MaxSize: 1, TTL: time.Minute}), Config{})))
+
+	cache.Set("entry-1", 1)
+	cache.Get("entry-1")
+	cache.Get("entry-2")
+	cache.Get("entry-1")
+	cache.Set("entry-2", 2)
+	cache.Delete("entry-2")
+	cache.Get("entry-2")
+
+	expected := `
+# HELP tlru_entries Number of entries in the cache including the expired ones which haven't been evicted yet.
+# TYPE tlru_entries gauge
+tlru_entries{cache=""} 0
+tlru_entries{cache="sessions"} 0
+# HELP tlru_evictions_total Number of entries which have been evicted.
+# TYPE tlru_evictions_total counter
+tlru_evictions_total{cache="",reason="Deleted"} 0
+tlru_evictions_total{cache="",reason="Dropped"} 0
+tlru_evictions_total{cache="",reason="Expired"} 0
+tlru_evictions_total{cache="",reason="Invalidated"} 0
+tlru_evictions_total{cache="sessions",reason="Deleted"} 1
+tlru_evictions_total{cache="sessions",reason="Dropped"} 1
+tlru_evictions_total{cache="sessions",reason="Expired"} 0
+tlru_evictions_total{cache="sessions",reason="Invalidated"} 0
+# HELP tlru_hit_ratio Ratio of the calls of Get which found a non-expired entry.
+# TYPE tlru_hit_ratio gauge
+tlru_hit_ratio{cache=""} 0
+tlru_hit_ratio{cache="sessions"} 0.5
+`
+	assert.NoError(testutil.GatherAndCompare(registry, strings.NewReader(expected), "tlru_entries", "tlru_evictions_total", "tlru_hit_ratio"))
+	assert.Equal(1, testutil.CollectAndCount(NewCollector(cache, Config{}), "tlru_garbage_collection_duration_seconds"))
+}
@@ -0,0 +1,60 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlrupublish
+
+import (
+	"context"
+)
+
+// NATSConn is the subset of *nats.Conn used by the NATS Sink
+type NATSConn interface {
+	Publish(subject string, data []byte) error
+	FlushWithContext(ctx context.Context) error
+}
+
+// NewNATSSink returns a Sink which publishes every message to the provided subject
+// and flushes the connection after each batch
+func NewNATSSink(conn NATSConn, subject string) Sink {
+	return SinkFunc(func(ctx context.Context, messages []Message) error {
+		for _, message := range messages {
+			if err := conn.Publish(subject, message.Value); err != nil {
+				return err
+			}
+		}
+
+		return conn.FlushWithContext(ctx)
+	})
+}
+
+// KafkaMessage is a message addressed to a Kafka topic
+type KafkaMessage struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// KafkaWriter writes a batch of messages to Kafka
+// It is typically a thin wrapper around the WriteMessages method of a kafka-go Writer
+// or the SendMessages method of a sarama SyncProducer
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, messages ...KafkaMessage) error
+}
+
+// NewKafkaSink returns a Sink which writes every batch to the provided topic
+// The key of the evicted entry is used as the message key
+func NewKafkaSink(writer KafkaWriter, topic string) Sink {
+	return SinkFunc(func(ctx context.Context, messages []Message) error {
+		kafkaMessages := make([]KafkaMessage, 0, len(messages))
+		for _, message := range messages {
+			kafkaMessages = append(kafkaMessages, KafkaMessage{
+				Topic: topic,
+				Key:   message.Key,
+				Value: message.Value,
+			})
+		}
+
+		return writer.WriteMessages(ctx, kafkaMessages...)
+	})
+}
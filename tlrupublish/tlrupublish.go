@@ -0,0 +1,177 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrupublish publishes eviction events of a tlru cache to message brokers
+// such as Kafka or NATS with batching and retry
+package tlrupublish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/jahnestacado/tlru/v3/internal/batch"
+	"github.com/jahnestacado/tlru/v3/tlrupb"
+)
+
+// Message is an encoded eviction event
+type Message struct {
+	// The key of the evicted entry. It can be used for partitioning
+	Key []byte
+	// The encoded EvictedEntry
+	Value []byte
+}
+
+// Sink delivers batches of messages to a message broker
+type Sink interface {
+	Publish(ctx context.Context, messages []Message) error
+}
+
+// SinkFunc is an adapter to allow the use of ordinary functions as a Sink
+type SinkFunc func(ctx context.Context, messages []Message) error
+
+// Publish calls f(ctx, messages)
+func (f SinkFunc) Publish(ctx context.Context, messages []Message) error {
+	return f(ctx, messages)
+}
+
+// Config of Publisher
+type Config[K comparable, V any] struct {
+	// Max number of messages per batch. If not set it defaults to 100
+	BatchSize int
+	// Max time a message waits in a batch before the batch is published even if it is not full.
+	// If not set it defaults to 1 second
+	FlushInterval time.Duration
+	// Number of retries of a failed batch. If not set it defaults to 3.
+	// A negative value disables retries
+	MaxRetries int
+	// Initial backoff between retries which doubles on every retry.
+	// If not set it defaults to 100 milliseconds
+	RetryBackoff time.Duration
+	// Encodes an EvictedEntry. If not set the protobuf encoding of the tlrupb package is used
+	Encoder func(tlru.EvictedEntry[K, V]) ([]byte, error)
+	// Optional callback which is invoked with the error and the messages of a batch
+	// that couldn't be published after all retries. It is also invoked by Listen without
+	// messages when an EvictedEntry can't be encoded or enqueued
+	OnError func(err error, messages []Message)
+}
+
+var _ tlru.EvictionSink[string, any] = (*Publisher[string, any])(nil)
+
+// Publisher batches eviction events and publishes them to a Sink
+// It implements tlru.EvictionSink so that it can be used with tlru.DrainEvictionsToSink
+type Publisher[K comparable, V any] struct {
+	config    Config[K, V]
+	batcher   *batch.Batcher[Message]
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New returns a new Publisher which publishes to the provided Sink
+func New[K comparable, V any](sink Sink, config Config[K, V]) *Publisher[K, V] {
+	if config.Encoder == nil {
+		config.Encoder = tlrupb.MarshalEvictedEntry[K, V]
+	}
+
+	return &Publisher[K, V]{
+		config: config,
+		batcher: batch.New(batch.Config[Message]{
+			BatchSize:     config.BatchSize,
+			FlushInterval: config.FlushInterval,
+			MaxRetries:    config.MaxRetries,
+			RetryBackoff:  config.RetryBackoff,
+			Write:         sink.Publish,
+			OnError:       config.OnError,
+		}),
+		done: make(chan struct{}),
+	}
+}
+
+// Listen publishes every EvictedEntry received from the provided channel
+// It blocks until the channel is closed or the Publisher is closed
+func (p *Publisher[K, V]) Listen(evictionChannel <-chan tlru.EvictedEntry[K, V]) {
+	for {
+		select {
+		case evictedEntry, ok := <-evictionChannel:
+			if !ok {
+				return
+			}
+			if err := p.Publish(evictedEntry); err != nil && p.config.OnError != nil {
+				p.config.OnError(err, nil)
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Publish encodes and enqueues the provided EvictedEntry
+// The pending batch is published once it reaches Config.BatchSize
+func (p *Publisher[K, V]) Publish(evictedEntry tlru.EvictedEntry[K, V]) error {
+	message, err := p.encode(evictedEntry)
+	if err != nil {
+		return err
+	}
+
+	return p.add(message, "Publish")
+}
+
+// WriteBatch encodes and enqueues the provided entries like Publish
+// None of the entries is enqueued if one of them can't be encoded
+func (p *Publisher[K, V]) WriteBatch(evictedEntries []tlru.EvictedEntry[K, V]) error {
+	messages := make([]Message, 0, len(evictedEntries))
+	for _, evictedEntry := range evictedEntries {
+		message, err := p.encode(evictedEntry)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, message)
+	}
+	for _, message := range messages {
+		if err := p.add(message, "WriteBatch"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher[K, V]) encode(evictedEntry tlru.EvictedEntry[K, V]) (Message, error) {
+	value, err := p.config.Encoder(evictedEntry)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Key: []byte(fmt.Sprintf("%v", evictedEntry.Key)), Value: value}, nil
+}
+
+func (p *Publisher[K, V]) add(message Message, method string) error {
+	if err := p.batcher.Add(context.Background(), message); err != nil {
+		if errors.Is(err, batch.ErrClosed) {
+			return fmt.Errorf("tlrupublish.%s: Publisher is closed", method)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Flush publishes all pending messages
+func (p *Publisher[K, V]) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close stops the periodic flushing and publishes all pending messages
+func (p *Publisher[K, V]) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.done)
+		err = p.batcher.Close(context.Background())
+	})
+
+	return err
+}
@@ -0,0 +1,189 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrupublish
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/jahnestacado/tlru/v3/tlrupb"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	sync.Mutex
+	batches  [][]Message
+	failures int
+}
+
+func (s *recordingSink) Publish(ctx context.Context, messages []Message) error {
+	defer s.Unlock()
+	s.Lock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, messages)
+
+	return nil
+}
+
+type natsConn struct {
+	subjects []string
+	flushes  int
+}
+
+func (c *natsConn) Publish(subject string, data []byte) error {
+	c.subjects = append(c.subjects, subject)
+	return nil
+}
+
+func (c *natsConn) FlushWithContext(ctx context.Context) error {
+	c.flushes++
+	return nil
+}
+
+type kafkaWriter struct {
+	messages []KafkaMessage
+}
+
+func (w *kafkaWriter) WriteMessages(ctx context.Context, messages ...KafkaMessage) error {
+	w.messages = append(w.messages, messages...)
+	return nil
+}
+
+func TestPublisherListen(t *testing.T) {
+	assert := assert.New(t)
+	sink := &recordingSink{}
+	publisher := New(sink, Config[string, int]{BatchSize: 2, FlushInterval: time.Hour})
+
+	evictionChannel := make(chan tlru.EvictedEntry[string, int])
+	cache := tlru.New(tlru.Config[string, int]{
+		MaxSize:         1,
+		TTL:             time.Minute,
+		EvictionChannel: &evictionChannel,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		publisher.Listen(evictionChannel)
+		close(done)
+	}()
+
+	for i := 0; i < 4; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+	close(evictionChannel)
+	<-done
+	assert.NoError(publisher.Close())
+
+	assert.Equal(2, len(sink.batches))
+	assert.Equal(2, len(sink.batches[0]))
+	assert.Equal(1, len(sink.batches[1]))
+	assert.Equal("0", string(sink.batches[0][0].Key))
+
+	evictedEntry, err := tlrupb.UnmarshalEvictedEntry[string, int](sink.batches[1][0].Value)
+	assert.NoError(err)
+	assert.Equal("2", evictedEntry.Key)
+	assert.Equal(2, evictedEntry.Value)
+	assert.Equal(tlru.EvictionReasonDropped, evictedEntry.Reason)
+}
+
+func TestPublisherListenErrors(t *testing.T) {
+	assert := assert.New(t)
+	errs := make(chan error, 1)
+	publisher := New(&recordingSink{}, Config[string, int]{
+		Encoder: func(evictedEntry tlru.EvictedEntry[string, int]) ([]byte, error) {
+			return nil, errors.New("unsupported")
+		},
+		OnError: func(err error, messages []Message) {
+			assert.Nil(messages)
+			errs <- err
+		},
+	})
+	defer publisher.Close()
+
+	evictionChannel := make(chan tlru.EvictedEntry[string, int], 1)
+	evictionChannel <- tlru.EvictedEntry[string, int]{CacheEntry: tlru.CacheEntry[string, int]{Key: "entry-1"}}
+	close(evictionChannel)
+	publisher.Listen(evictionChannel)
+	assert.EqualError(<-errs, "unsupported")
+}
+
+func TestPublisherAsEvictionSink(t *testing.T) {
+	assert := assert.New(t)
+	sink := &recordingSink{}
+	publisher := New(sink, Config[string, int]{BatchSize: 10, FlushInterval: time.Hour})
+	evictionChannel := make(chan tlru.EvictedEntry[string, int])
+	cache := tlru.New(tlru.Config[string, int]{
+		MaxSize:         1,
+		TTL:             time.Minute,
+		EvictionChannel: &evictionChannel,
+	})
+
+	done := tlru.DrainEvictionsToSink[string, int](context.Background(), cache, publisher, tlru.SinkOptions[string, int]{BatchSize: 3})
+	for i := 0; i < 4; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+	close(evictionChannel)
+	<-done
+	assert.NoError(publisher.Close())
+
+	assert.Equal(1, len(sink.batches))
+	assert.Equal(3, len(sink.batches[0]))
+	assert.Equal("2", string(sink.batches[0][2].Key))
+
+	assert.Error(publisher.WriteBatch([]tlru.EvictedEntry[string, int]{{}}))
+}
+
+func TestPublisherRetry(t *testing.T) {
+	assert := assert.New(t)
+	sink := &recordingSink{failures: 2}
+	publisher := New(sink, Config[string, int]{RetryBackoff: time.Millisecond})
+	defer publisher.Close()
+
+	publisher.Publish(tlru.EvictedEntry[string, int]{CacheEntry: tlru.CacheEntry[string, int]{Key: "entry-1"}})
+	assert.NoError(publisher.Flush(context.Background()))
+	assert.Equal(1, len(sink.batches))
+}
+
+func TestPublisherRetryExhausted(t *testing.T) {
+	assert := assert.New(t)
+	sink := &recordingSink{failures: 3}
+	var failedMessages []Message
+	publisher := New(sink, Config[string, int]{
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnError: func(err error, messages []Message) {
+			failedMessages = messages
+		},
+	})
+	defer publisher.Close()
+
+	publisher.Publish(tlru.EvictedEntry[string, int]{CacheEntry: tlru.CacheEntry[string, int]{Key: "entry-1"}})
+	assert.Error(publisher.Flush(context.Background()))
+	assert.Equal(0, len(sink.batches))
+	assert.Equal(1, len(failedMessages))
+}
+
+func TestNATSAndKafkaSinks(t *testing.T) {
+	assert := assert.New(t)
+	messages := []Message{{Key: []byte("entry-1"), Value: []byte("1")}, {Key: []byte("entry-2"), Value: []byte("2")}}
+
+	conn := &natsConn{}
+	assert.NoError(NewNATSSink(conn, "evictions").Publish(context.Background(), messages))
+	assert.Equal([]string{"evictions", "evictions"}, conn.subjects)
+	assert.Equal(1, conn.flushes)
+
+	writer := &kafkaWriter{}
+	assert.NoError(NewKafkaSink(writer, "evictions").Publish(context.Background(), messages))
+	assert.Equal(2, len(writer.messages))
+	assert.Equal("evictions", writer.messages[1].Topic)
+	assert.Equal([]byte("entry-2"), writer.messages[1].Key)
+}
@@ -0,0 +1,79 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrureplica keeps a warm standby replica of a tlru cache by applying
+// the operations emitted to the OperationChannel of the source cache
+package tlrureplica
+
+import (
+	"context"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+// Target is the cache that operations are applied to
+// It is implemented by tlru.TLRU and can be implemented by remote clients
+type Target[K comparable, V any] interface {
+	SetWithTimestamp(key K, value V, timestamp time.Time) error
+	Delete(key K)
+	Clear()
+}
+
+// Config of Replicator
+type Config[K comparable, V any] struct {
+	// Optional callback which is invoked with the error and the operation
+	// that couldn't be applied to the Target
+	OnError func(err error, operation tlru.Operation[K, V])
+}
+
+// Replicator applies the operations of a source cache to a Target
+// Evictions due to TTL or MaxSize are not replicated since the Target evicts
+// its own entries. The source and the Target should therefore use the same Config
+type Replicator[K comparable, V any] struct {
+	target Target[K, V]
+	config Config[K, V]
+}
+
+// New returns a new Replicator which applies operations to the provided Target
+func New[K comparable, V any](target Target[K, V], config Config[K, V]) *Replicator[K, V] {
+	return &Replicator[K, V]{target: target, config: config}
+}
+
+// Run applies every operation received from the provided channel to the Target
+// It blocks until the channel is closed or the context is done
+func (r *Replicator[K, V]) Run(ctx context.Context, operationChannel <-chan tlru.Operation[K, V]) error {
+	for {
+		select {
+		case operation, ok := <-operationChannel:
+			if !ok {
+				return nil
+			}
+			if err := r.Apply(operation); err != nil && r.config.OnError != nil {
+				r.config.OnError(err, operation)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Apply applies a single operation to the Target
+// An OperationSet for a key that already exists in a Target with the LRA EvictionPolicy
+// replaces the existing entry
+func (r *Replicator[K, V]) Apply(operation tlru.Operation[K, V]) error {
+	switch operation.Type {
+	case tlru.OperationSet:
+		if err := r.target.SetWithTimestamp(operation.Key, operation.Value, operation.LastUsedAt); err != nil {
+			r.target.Delete(operation.Key)
+			return r.target.SetWithTimestamp(operation.Key, operation.Value, operation.LastUsedAt)
+		}
+	case tlru.OperationDelete:
+		r.target.Delete(operation.Key)
+	case tlru.OperationClear:
+		r.target.Clear()
+	}
+
+	return nil
+}
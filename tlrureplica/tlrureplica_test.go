@@ -0,0 +1,64 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrureplica
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicatorRun(t *testing.T) {
+	assert := assert.New(t)
+	configs := []tlru.Config[string, int]{
+		{MaxSize: 10, TTL: time.Minute, EvictionPolicy: tlru.LRA},
+		{MaxSize: 10, TTL: time.Minute, EvictionPolicy: tlru.LRI},
+	}
+	for _, config := range configs {
+		target := tlru.New(config)
+		operationChannel := make(chan tlru.Operation[string, int], 10)
+		config.OperationChannel = &operationChannel
+		source := tlru.New(config)
+
+		source.Set("entry-1", 1)
+		source.Set("entry-2", 2)
+		source.Set("entry-3", 3)
+		source.Delete("entry-2")
+		source.Delete("non-existent-key")
+		close(operationChannel)
+
+		assert.NoError(New[string, int](target, Config[string, int]{}).Run(context.Background(), operationChannel))
+		assert.ElementsMatch([]string{"entry-1", "entry-3"}, target.Keys())
+		assert.Equal(source.Peek("entry-1").LastUsedAt, target.Peek("entry-1").LastUsedAt)
+	}
+}
+
+func TestReplicatorApply(t *testing.T) {
+	assert := assert.New(t)
+	target := tlru.New(tlru.Config[string, int]{
+		MaxSize:        10,
+		TTL:            time.Minute,
+		EvictionPolicy: tlru.LRA,
+	})
+	replicator := New[string, int](target, Config[string, int]{})
+
+	now := time.Now().UTC()
+	assert.NoError(replicator.Apply(tlru.Operation[string, int]{Type: tlru.OperationSet, Key: "entry-1", Value: 1, LastUsedAt: now}))
+	assert.NoError(replicator.Apply(tlru.Operation[string, int]{Type: tlru.OperationSet, Key: "entry-1", Value: 2, LastUsedAt: now}))
+	assert.Equal(2, target.Get("entry-1").Value)
+
+	assert.NoError(replicator.Apply(tlru.Operation[string, int]{Type: tlru.OperationClear}))
+	assert.Equal(0, target.Len())
+}
+
+func TestReplicatorRunContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	replicator := New[string, int](tlru.New(tlru.Config[string, int]{MaxSize: 1, TTL: time.Minute}), Config[string, int]{})
+	assert.Equal(t, context.Canceled, replicator.Run(ctx, make(chan tlru.Operation[string, int])))
+}
@@ -0,0 +1,304 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlruresp serves a subset of the Redis protocol (RESP) backed by a tlru cache
+// Supported commands are PING, QUIT, GET, SET, DEL, EXISTS, TTL, PTTL and KEYS
+package tlruresp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/jahnestacado/tlru/v3/internal/tcpserver"
+)
+
+const (
+	// maxMultibulkLength is the max number of arguments of a command
+	maxMultibulkLength = 1024 * 1024
+	// maxBulkLength is the max size in bytes of an argument of a command
+	maxBulkLength = 64 * 1024 * 1024
+	// maxPreallocatedArgs bounds the arguments allocated upfront for a command since
+	// the announced multibulk length can't be trusted until they have been read
+	maxPreallocatedArgs = 1024
+	// maxLineLength is the max size in bytes of an inline command or a header line
+	// like the inline request limit of Redis
+	maxLineLength = 64 * 1024
+)
+
+// ErrServerClosed is returned by the Serve and ListenAndServe methods after a call to Close
+var ErrServerClosed = tcpserver.ErrServerClosed
+
+// Server serves RESP requests on top of a tlru cache
+type Server struct {
+	// Optional callback which is invoked with the panics that are recovered while serving
+	// a connection. The connection is closed after a panic. It must be set before Serve
+	OnPanic   func(err *tlru.PanicError)
+	cache     *tlru.TLRU[string, []byte]
+	tcpServer *tcpserver.Server
+}
+
+// NewServer returns a new Server backed by the provided cache
+func NewServer(cache *tlru.TLRU[string, []byte]) *Server {
+	s := &Server{cache: cache}
+	s.tcpServer = tcpserver.New(s.handleConnection, s.reportPanic)
+
+	return s
+}
+
+func (s *Server) reportPanic(value interface{}, stack []byte) {
+	if s.OnPanic != nil {
+		s.OnPanic(&tlru.PanicError{Callback: "handleConnection", Value: value, Stack: stack})
+	}
+}
+
+// ListenAndServe listens on the TCP network address addr and serves incoming connections
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts incoming connections on the provided listener
+// It blocks until the listener fails or the server is closed
+func (s *Server) Serve(listener net.Listener) error {
+	return s.tcpServer.Serve(listener)
+}
+
+// Close stops the listener, closes all active connections and waits for them to return
+func (s *Server) Close() error {
+	return s.tcpServer.Close()
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			if err != io.EOF {
+				writeError(writer, "ERR Protocol error: "+err.Error())
+				writer.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(writer, args)
+		if err := writer.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+func (s *Server) execute(w *bufio.Writer, args []string) bool {
+	name := args[0]
+	command := strings.ToUpper(name)
+	args = args[1:]
+
+	switch command {
+	case "PING":
+		if len(args) > 0 {
+			writeBulkString(w, []byte(args[0]))
+		} else {
+			writeSimpleString(w, "PONG")
+		}
+	case "QUIT":
+		writeSimpleString(w, "OK")
+		return true
+	case "GET":
+		if !checkArity(w, command, args, 1) {
+			break
+		}
+		cacheEntry := s.cache.Get(args[0])
+		if cacheEntry == nil {
+			writeNull(w)
+		} else {
+			writeBulkString(w, cacheEntry.Value)
+		}
+	case "SET":
+		if !checkArity(w, command, args, 2) {
+			break
+		}
+		err := s.cache.Set(args[0], []byte(args[1]))
+		// SET overwrites existing keys like Redis does, which Set rejects in the LRA EvictionPolicy
+		if errors.Is(err, tlru.ErrReplacementNotAllowed) {
+			_, _, err = s.cache.Swap(args[0], []byte(args[1]))
+		}
+		if err != nil {
+			writeError(w, "ERR "+err.Error())
+		} else {
+			writeSimpleString(w, "OK")
+		}
+	case "DEL":
+		if len(args) == 0 {
+			writeArityError(w, command)
+			break
+		}
+		deleted := 0
+		for _, key := range args {
+			if s.cache.DeleteIfPresent(key) {
+				deleted++
+			}
+		}
+		writeInteger(w, int64(deleted))
+	case "EXISTS":
+		if len(args) == 0 {
+			writeArityError(w, command)
+			break
+		}
+		existing := 0
+		for _, key := range args {
+			if s.cache.Peek(key) != nil {
+				existing++
+			}
+		}
+		writeInteger(w, int64(existing))
+	case "TTL", "PTTL":
+		if !checkArity(w, command, args, 1) {
+			break
+		}
+		unit := time.Second
+		if command == "PTTL" {
+			unit = time.Millisecond
+		}
+		writeInteger(w, s.remainingTTL(args[0], unit))
+	case "KEYS":
+		if !checkArity(w, command, args, 1) {
+			break
+		}
+		keys, err := tlru.KeysMatching(s.cache, args[0])
+		if err != nil {
+			writeError(w, "ERR "+err.Error())
+			break
+		}
+		writeArray(w, keys)
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+
+	return false
+}
+
+func (s *Server) remainingTTL(key string, unit time.Duration) int64 {
+	remaining, exists := s.cache.RemainingTTL(key)
+	if !exists {
+		return -2
+	}
+
+	return int64(math.Ceil(float64(remaining) / float64(unit)))
+}
+
+func checkArity(w *bufio.Writer, command string, args []string, expected int) bool {
+	if len(args) != expected {
+		writeArityError(w, command)
+		return false
+	}
+
+	return true
+}
+
+func writeArityError(w *bufio.Writer, command string) {
+	writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(command)))
+}
+
+func writeSimpleString(w *bufio.Writer, s string) {
+	w.WriteString("+" + s + "\r\n")
+}
+
+func writeError(w *bufio.Writer, s string) {
+	w.WriteString("-" + strings.ReplaceAll(s, "\r\n", " ") + "\r\n")
+}
+
+func writeInteger(w *bufio.Writer, i int64) {
+	w.WriteString(":" + strconv.FormatInt(i, 10) + "\r\n")
+}
+
+func writeNull(w *bufio.Writer) {
+	w.WriteString("$-1\r\n")
+}
+
+func writeBulkString(w *bufio.Writer, b []byte) {
+	w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func writeArray(w *bufio.Writer, items []string) {
+	w.WriteString("*" + strconv.Itoa(len(items)) + "\r\n")
+	for _, item := range items {
+		writeBulkString(w, []byte(item))
+	}
+}
+
+// readCommand reads either a RESP array of bulk strings or an inline command
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count < 0 || count > maxMultibulkLength {
+		return nil, fmt.Errorf("invalid multibulk length")
+	}
+
+	preallocated := count
+	if preallocated > maxPreallocatedArgs {
+		preallocated = maxPreallocatedArgs
+	}
+	args := make([]string, 0, preallocated)
+	for i := 0; i < count; i++ {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("expected '$', got '%s'", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size < 0 || size > maxBulkLength {
+			return nil, fmt.Errorf("invalid bulk length")
+		}
+		// The buffer grows with the received bytes rather than the announced size
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r, int64(size)+2); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		args = append(args, string(buf.Bytes()[:size]))
+	}
+
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := tcpserver.ReadLine(r, maxLineLength)
+	if err == tcpserver.ErrLineTooLong {
+		return "", fmt.Errorf("too big request line")
+	}
+
+	return line, err
+}
@@ -0,0 +1,201 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlruresp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, config tlru.Config[string, []byte]) (net.Conn, func()) {
+	return serveCache(t, tlru.New(config))
+}
+
+func serveCache(t *testing.T, cache *tlru.TLRU[string, []byte]) (net.Conn, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(cache)
+	go server.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn, func() {
+		conn.Close()
+		server.Close()
+	}
+}
+
+func readReply(t *testing.T, reader *bufio.Reader) string {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line[0] == '$' && line != "$-1\r\n" {
+		value, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return line + value
+	}
+
+	return line
+}
+
+func TestServerCommands(t *testing.T) {
+	assert := assert.New(t)
+	conn, stop := startServer(t, tlru.Config[string, []byte]{
+		MaxSize:        10,
+		TTL:            time.Minute,
+		EvictionPolicy: tlru.LRI,
+	})
+	defer stop()
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	assert.Equal("+PONG\r\n", readReply(t, reader))
+
+	conn.Write([]byte("*3\r\n$3\r\nSET\r\n$7\r\nentry-1\r\n$5\r\nvalue\r\n"))
+	assert.Equal("+OK\r\n", readReply(t, reader))
+
+	conn.Write([]byte("*2\r\n$3\r\nGET\r\n$7\r\nentry-1\r\n"))
+	assert.Equal("$5\r\nvalue\r\n", readReply(t, reader))
+
+	conn.Write([]byte("GET non-existent\r\n"))
+	assert.Equal("$-1\r\n", readReply(t, reader))
+
+	conn.Write([]byte("TTL entry-1\r\n"))
+	assert.Equal(":60\r\n", readReply(t, reader))
+
+	conn.Write([]byte("TTL non-existent\r\n"))
+	assert.Equal(":-2\r\n", readReply(t, reader))
+
+	conn.Write([]byte("KEYS entry-*\r\n"))
+	assert.Equal("*1\r\n", readReply(t, reader))
+	assert.Equal("$7\r\nentry-1\r\n", readReply(t, reader))
+
+	conn.Write([]byte("DEL entry-1 non-existent\r\n"))
+	assert.Equal(":1\r\n", readReply(t, reader))
+
+	conn.Write([]byte("EXISTS entry-1\r\n"))
+	assert.Equal(":0\r\n", readReply(t, reader))
+
+	conn.Write([]byte("GET\r\n"))
+	assert.Equal("-ERR wrong number of arguments for 'get' command\r\n", readReply(t, reader))
+
+	conn.Write([]byte("FLUSHALL\r\n"))
+	assert.Equal("-ERR unknown command 'FLUSHALL'\r\n", readReply(t, reader))
+
+	conn.Write([]byte("QUIT\r\n"))
+	assert.Equal("+OK\r\n", readReply(t, reader))
+}
+
+func TestServerSetDuplicateKeyLRA(t *testing.T) {
+	assert := assert.New(t)
+	conn, stop := startServer(t, tlru.Config[string, []byte]{
+		MaxSize:        10,
+		TTL:            time.Minute,
+		EvictionPolicy: tlru.LRA,
+	})
+	defer stop()
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("SET entry-1 value\r\n"))
+	assert.Equal("+OK\r\n", readReply(t, reader))
+
+	conn.Write([]byte("SET entry-1 other\r\n"))
+	assert.Equal("+OK\r\n", readReply(t, reader))
+
+	conn.Write([]byte("GET entry-1\r\n"))
+	assert.Equal("$5\r\nother\r\n", readReply(t, reader))
+}
+
+func TestServerTTLOfEntry(t *testing.T) {
+	assert := assert.New(t)
+	cache := tlru.New(tlru.Config[string, []byte]{
+		MaxSize: 10,
+		TTL:     time.Minute,
+	})
+	cache.SetWithTTL("entry-1", []byte("value"), 10*time.Second)
+	cache.SetWithTimestamp("entry-2", []byte("value"), time.Now().Add(-time.Hour))
+	conn, stop := serveCache(t, cache)
+	defer stop()
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("TTL entry-1\r\n"))
+	assert.Equal(":10\r\n", readReply(t, reader))
+
+	conn.Write([]byte("PTTL entry-1\r\n"))
+	pttl := readReply(t, reader)
+	assert.Equal(":", pttl[:1])
+	assert.NotEqual(":60000\r\n", pttl)
+
+	// Expired entries are missing like in Redis
+	conn.Write([]byte("DEL entry-2\r\n"))
+	assert.Equal(":0\r\n", readReply(t, reader))
+}
+
+func TestServerOversizedCommand(t *testing.T) {
+	assert := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	server := NewServer(tlru.New(tlru.Config[string, []byte]{MaxSize: 10, TTL: time.Minute}))
+	go server.Serve(listener)
+	defer server.Close()
+
+	for _, command := range []string{
+		"*4611686018427387904\r\n",
+		"*1\r\n$4611686018427387904\r\n",
+		"*1\r\n$9223372036854775807\r\n",
+		"*1\r\n$-5\r\n",
+		"*99999999999999999999\r\n",
+		strings.Repeat("a", maxLineLength) + "\r\n",
+		"*1\r\n$" + strings.Repeat("1", maxLineLength) + "\r\n",
+	} {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		assert.NoError(err)
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(command))
+		assert.Equal("-ERR Protocol error", readReply(t, reader)[:19])
+		_, err = reader.ReadByte()
+		assert.Equal(io.EOF, err)
+		conn.Close()
+	}
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+	conn.Write([]byte("PING\r\n"))
+	assert.Equal("+PONG\r\n", readReply(t, bufio.NewReader(conn)))
+}
+
+func TestServerClose(t *testing.T) {
+	assert := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	server := NewServer(tlru.New(tlru.Config[string, []byte]{TTL: time.Minute}))
+
+	done := make(chan error)
+	go func() {
+		done <- server.Serve(listener)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+
+	server.Close()
+	assert.Equal(ErrServerClosed, <-done)
+}
@@ -0,0 +1,165 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrusession implements a web session store backed by a tlru cache
+// Sessions expire after being idle for Config.IdleTimeout. Every Get or Save
+// of a session prolongs its lifetime(rolling expiration)
+package tlrusession
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+const (
+	defaultIdleTimeout = 30 * time.Minute
+	sessionIDLength    = 32
+)
+
+// Config of Store
+type Config struct {
+	// Max number of sessions. When the store is full the least recently used session is dropped
+	MaxSessions int
+	// Time after which an unused session expires. If not set it defaults to 30 minutes
+	IdleTimeout time.Duration
+	// Optional callback which is invoked when a session expires or is dropped.
+	// It is not invoked for destroyed sessions
+	OnEvicted func(session Session)
+}
+
+// Session holds the values of a user session
+type Session struct {
+	// The unique identifier of the session
+	ID string
+	// The values stored in the session
+	Values map[string]any
+	// The time the session was first saved
+	CreatedAt time.Time
+}
+
+type sessionState struct {
+	sync.Mutex
+	values map[string]any
+}
+
+// Store keeps sessions in memory
+type Store struct {
+	cache     *tlru.TLRU[string, *sessionState]
+	config    Config
+	closeOnce sync.Once
+}
+
+// NewStore returns a new session Store
+func NewStore(config Config) *Store {
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+
+	store := &Store{config: config}
+	cacheConfig := tlru.Config[string, *sessionState]{
+		MaxSize:                   config.MaxSessions,
+		TTL:                       config.IdleTimeout,
+		EvictionPolicy:            tlru.LRA,
+		GarbageCollectionInterval: config.IdleTimeout,
+	}
+	if config.OnEvicted != nil {
+		cacheConfig.OnEvict = store.onEvict
+	}
+	store.cache = tlru.New(cacheConfig)
+
+	return store
+}
+
+// New returns a new unsaved Session with a random ID
+func (s *Store) New() *Session {
+	return &Session{
+		ID:        newSessionID(),
+		Values:    make(map[string]any),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Get returns the session with the provided ID and prolongs its lifetime
+// It returns false if the session doesn't exist or has expired
+func (s *Store) Get(id string) (*Session, bool) {
+	cacheEntry := s.cache.Get(id)
+	if cacheEntry == nil {
+		return nil, false
+	}
+
+	return cacheEntry.Value.toSession(id, cacheEntry.CreatedAt), true
+}
+
+// Save stores the values of the provided session and prolongs its lifetime
+// It returns an error if the session can't be stored, e.g. after Close
+func (s *Store) Save(session *Session) error {
+	state := &sessionState{values: copyValues(session.Values)}
+	if _, _, err := s.cache.Swap(session.ID, state); err != nil {
+		return fmt.Errorf("tlrusession.Save: %w", err)
+	}
+
+	return nil
+}
+
+// Destroy removes the session with the provided ID
+func (s *Store) Destroy(id string) {
+	s.cache.Delete(id)
+}
+
+// Len returns the number of stored sessions
+// Expired sessions which haven't been evicted yet are included
+func (s *Store) Len() int {
+	return s.cache.Len()
+}
+
+// Close removes all sessions without invoking Config.OnEvicted and closes the Store
+// The Store must not be used after Close
+func (s *Store) Close() {
+	s.closeOnce.Do(func() {
+		s.cache.Clear()
+		s.cache.CloseAndExport()
+	})
+}
+
+// onEvict invokes Config.OnEvicted for the sessions which have expired or have been dropped
+func (s *Store) onEvict(evictedEntry tlru.EvictedEntry[string, *sessionState]) {
+	if evictedEntry.Reason == tlru.EvictionReasonDeleted {
+		return
+	}
+	s.config.OnEvicted(*evictedEntry.Value.toSession(evictedEntry.Key, evictedEntry.CreatedAt))
+}
+
+func (state *sessionState) toSession(id string, createdAt time.Time) *Session {
+	defer state.Unlock()
+	state.Lock()
+
+	return &Session{
+		ID:        id,
+		Values:    copyValues(state.values),
+		CreatedAt: createdAt,
+	}
+}
+
+func copyValues(values map[string]any) map[string]any {
+	copied := make(map[string]any, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+func newSessionID() string {
+	b := make([]byte, sessionIDLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,96 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrusession
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreGetSaveDestroy(t *testing.T) {
+	assert := assert.New(t)
+	store := NewStore(Config{MaxSessions: 10, IdleTimeout: time.Minute})
+	defer store.Close()
+
+	session := store.New()
+	assert.Equal(64, len(session.ID))
+	_, exists := store.Get(session.ID)
+	assert.False(exists)
+
+	session.Values["user"] = "gopher"
+	assert.NoError(store.Save(session))
+
+	storedSession, exists := store.Get(session.ID)
+	assert.True(exists)
+	assert.Equal("gopher", storedSession.Values["user"])
+
+	storedSession.Values["user"] = "admin"
+	_, exists = store.Get(session.ID)
+	assert.True(exists)
+	assert.NoError(store.Save(storedSession))
+
+	updatedSession, _ := store.Get(session.ID)
+	assert.Equal("admin", updatedSession.Values["user"])
+	assert.Equal(1, store.Len())
+
+	store.Destroy(session.ID)
+	_, exists = store.Get(session.ID)
+	assert.False(exists)
+}
+
+func TestStoreRollingExpiration(t *testing.T) {
+	assert := assert.New(t)
+	idleTimeout := 50 * time.Millisecond
+	evicted := make(chan Session, 1)
+	store := NewStore(Config{
+		IdleTimeout: idleTimeout,
+		OnEvicted: func(session Session) {
+			evicted <- session
+		},
+	})
+	defer store.Close()
+
+	session := store.New()
+	session.Values["user"] = "gopher"
+	assert.NoError(store.Save(session))
+
+	for i := 0; i < 4; i++ {
+		time.Sleep(idleTimeout / 2)
+		_, exists := store.Get(session.ID)
+		assert.True(exists)
+	}
+
+	time.Sleep(2 * idleTimeout)
+	_, exists := store.Get(session.ID)
+	assert.False(exists)
+
+	evictedSession := <-evicted
+	assert.Equal(session.ID, evictedSession.ID)
+	assert.Equal("gopher", evictedSession.Values["user"])
+}
+
+func TestStoreClose(t *testing.T) {
+	assert := assert.New(t)
+	idleTimeout := 10 * time.Millisecond
+	var evicted int32
+	store := NewStore(Config{
+		IdleTimeout: idleTimeout,
+		OnEvicted: func(session Session) {
+			atomic.AddInt32(&evicted, 1)
+		},
+	})
+
+	assert.NoError(store.Save(store.New()))
+	assert.Eventually(func() bool { return atomic.LoadInt32(&evicted) == 1 }, time.Second, time.Millisecond)
+
+	// Sessions which expire while closing don't block Close
+	assert.NoError(store.Save(store.New()))
+	time.Sleep(2 * idleTimeout)
+	store.Close()
+	assert.Equal(0, store.Len())
+	assert.Error(store.Save(store.New()))
+}
@@ -0,0 +1,122 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+// Package tlrutest provides generators of realistic key streams and helpers which
+// replay them against a cache so that its configuration can be validated in tests
+package tlrutest
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/jahnestacado/tlru/v3"
+)
+
+// Zipf returns a stream of length keys drawn from keys distinct keys with a zipfian
+// distribution, where a few keys receive most of the accesses
+// The skew must be greater than 1 and the higher it is the hotter the hottest keys are
+func Zipf(seed int64, skew float64, keys int, length int) []string {
+	random := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(random, skew, 1, uint64(keys-1))
+	stream := make([]string, length)
+	for i := range stream {
+		stream[i] = Key(int(zipf.Uint64()))
+	}
+
+	return stream
+}
+
+// Scan returns a stream of length keys which iterates over keys distinct keys
+// sequentially and starts over once all of them have been accessed
+func Scan(keys int, length int) []string {
+	stream := make([]string, length)
+	for i := range stream {
+		stream[i] = Key(i % keys)
+	}
+
+	return stream
+}
+
+// Bursts returns a stream of length keys in which a key picked uniformly from keys
+// distinct keys is accessed burst consecutive times before the next key is picked
+func Bursts(seed int64, keys int, burst int, length int) []string {
+	random := rand.New(rand.NewSource(seed))
+	stream := make([]string, 0, length)
+	for len(stream) < length {
+		key := Key(random.Intn(keys))
+		for i := 0; i < burst && len(stream) < length; i++ {
+			stream = append(stream, key)
+		}
+	}
+
+	return stream
+}
+
+// Interleave returns a stream which contains the keys of all provided streams with
+// the order of every stream preserved, picking the next key from a random stream
+func Interleave(seed int64, streams ...[]string) []string {
+	random := rand.New(rand.NewSource(seed))
+	positions := make([]int, len(streams))
+	remaining := make([]int, 0, len(streams))
+	length := 0
+	for i, stream := range streams {
+		length += len(stream)
+		if len(stream) > 0 {
+			remaining = append(remaining, i)
+		}
+	}
+
+	interleaved := make([]string, 0, length)
+	for len(remaining) > 0 {
+		r := random.Intn(len(remaining))
+		i := remaining[r]
+		interleaved = append(interleaved, streams[i][positions[i]])
+		positions[i]++
+		if positions[i] == len(streams[i]) {
+			remaining = append(remaining[:r], remaining[r+1:]...)
+		}
+	}
+
+	return interleaved
+}
+
+// Key returns the key of the provided index in the generated streams
+func Key(i int) string {
+	return "key-" + strconv.Itoa(i)
+}
+
+// HitRatio replays the stream against the cache and returns the ratio of the
+// accesses which were hits. Missing keys are inserted with the provided value
+// like a read-through cache would do
+func HitRatio[V any](cache *tlru.TLRU[string, V], stream []string, value V) float64 {
+	if len(stream) == 0 {
+		return 0
+	}
+
+	hits := 0
+	for _, key := range stream {
+		if cache.Get(key) != nil {
+			hits++
+			continue
+		}
+		cache.Set(key, value)
+	}
+
+	return float64(hits) / float64(len(stream))
+}
+
+// AssertHitRatio replays the stream against the cache via HitRatio and fails
+// the test if the hit ratio is lower than min
+func AssertHitRatio[V any](t testing.TB, cache *tlru.TLRU[string, V], stream []string, min float64) bool {
+	t.Helper()
+
+	var value V
+	if hitRatio := HitRatio(cache, stream, value); hitRatio < min {
+		t.Errorf("tlrutest: hit ratio %.4f is lower than %.4f", hitRatio, min)
+		return false
+	}
+
+	return true
+}
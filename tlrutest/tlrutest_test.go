@@ -0,0 +1,66 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlrutest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jahnestacado/tlru/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCache(config tlru.Config[string, int]) *tlru.TLRU[string, int] {
+	return tlru.New(config)
+}
+
+func TestStreams(t *testing.T) {
+	assert := assert.New(t)
+
+	zipf := Zipf(1, 1.2, 1000, 10000)
+	assert.Equal(10000, len(zipf))
+	assert.Equal(zipf, Zipf(1, 1.2, 1000, 10000))
+	counts := make(map[string]int)
+	for _, key := range zipf {
+		counts[key]++
+	}
+	assert.True(counts[Key(0)] > counts[Key(10)])
+
+	assert.Equal([]string{Key(0), Key(1), Key(2), Key(0), Key(1)}, Scan(3, 5))
+
+	bursts := Bursts(1, 100, 3, 7)
+	assert.Equal(7, len(bursts))
+	assert.Equal(bursts[0], bursts[1])
+	assert.Equal(bursts[1], bursts[2])
+
+	interleaved := Interleave(1, Scan(2, 2), []string{"a", "b"}, nil)
+	assert.ElementsMatch([]string{Key(0), Key(1), "a", "b"}, interleaved)
+	assert.True(indexOf(interleaved, Key(0)) < indexOf(interleaved, Key(1)))
+	assert.True(indexOf(interleaved, "a") < indexOf(interleaved, "b"))
+}
+
+func TestHitRatio(t *testing.T) {
+	assert := assert.New(t)
+	config := tlru.Config[string, int]{MaxSize: 10, TTL: time.Minute, EvictionPolicy: tlru.LRI}
+
+	assert.Equal(0.0, HitRatio(newCache(config), Scan(11, 110), 0))
+	assert.Equal(0.9, HitRatio(newCache(config), Scan(10, 100), 0))
+	assert.Equal(0.0, HitRatio(newCache(config), nil, 0))
+
+	assert.True(AssertHitRatio(t, newCache(config), Bursts(1, 1000, 10, 1000), 0.9))
+
+	mock := &testing.T{}
+	assert.False(AssertHitRatio(mock, newCache(config), Scan(11, 110), 0.1))
+	assert.True(mock.Failed())
+}
+
+func indexOf(stream []string, key string) int {
+	for i, k := range stream {
+		if k == key {
+			return i
+		}
+	}
+
+	return -1
+}
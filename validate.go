@@ -0,0 +1,43 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import "sync/atomic"
+
+// validate invokes Config.Validator with the provided entry outside of the lock of
+// the cache. If the entry is invalid it is evicted with EvictionReasonInvalidated
+// unless it has been replaced in the meantime and nil is returned
+// A panicking Validator is reported to Config.OnPanic and treated as a failure
+func (c *TLRU[K, V]) validate(cacheEntry *CacheEntry[K, V]) *CacheEntry[K, V] {
+	if cacheEntry == nil || c.config.Validator == nil {
+		return cacheEntry
+	}
+	valid := false
+	c.protect("Validator", func() {
+		valid = c.config.Validator(cacheEntry.Key, cacheEntry.Value)
+	})
+	if valid {
+		return cacheEntry
+	}
+
+	defer c.unlock()
+	c.Lock()
+	if linkedNode, exists := c.cache[cacheEntry.Key]; exists {
+		if _, version := linkedNode.readVersionedValue(); version == cacheEntry.Version {
+			c.evictEntry(linkedNode, EvictionReasonInvalidated)
+		}
+	}
+
+	return nil
+}
+
+// countGet counts the result of a lookup as a hit or a miss of Get
+func (c *TLRU[K, V]) countGet(cacheEntry *CacheEntry[K, V]) {
+	if cacheEntry == nil {
+		atomic.AddUint64(&c.operations.getMisses, 1)
+		return
+	}
+	atomic.AddUint64(&c.operations.getHits, 1)
+}
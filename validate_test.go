@@ -0,0 +1,59 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheValidator(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		alive := map[string]bool{entry1.Key: true, entry2.Key: false, entry3.Key: false, entry4.Key: true}
+		var cache *TLRU[string, int]
+		config := Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+			Validator: func(key string, value int) bool {
+				cache.Len()
+				if key == entry4.Key {
+					panic("validator failed")
+				}
+				return alive[key]
+			},
+			OnPanic: func(err *PanicError) {},
+		}
+		cache = New(config)
+		cache.SetMany([]Entry[string, int]{entry1, entry2, entry3, entry4})
+
+		assert.Equal(entry1.Value, cache.Get(entry1.Key).Value)
+		assert.Nil(cache.Get(entry2.Key))
+		evictedEntry := <-evictionChannel
+		assert.Equal(entry2.Key, evictedEntry.Key)
+		assert.Equal(EvictionReasonInvalidated, evictedEntry.Reason)
+
+		value, err := cache.GetOrCompute(entry3.Key, func(key string) (int, error) {
+			alive[key] = true
+			return 30, nil
+		})
+		assert.NoError(err)
+		assert.Equal(30, value)
+
+		cacheEntries := cache.GetMany([]string{entry1.Key, entry4.Key})
+		assert.Equal(1, len(cacheEntries))
+		assert.Nil(cache.GetConsistent(entry4.Key))
+		assert.NotNil(cache.Peek(entry1.Key))
+
+		stats := cache.Stats()
+		assert.Equal(uint64(3), stats.Invalidations)
+		assert.Equal(uint64(2), stats.GetHits)
+		assert.Equal(uint64(4), stats.GetMisses)
+	}
+}
@@ -0,0 +1,118 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// VerificationReport summarizes a verification pass over the entries of the cache
+type VerificationReport[K comparable] struct {
+	// Number of entries which have been verified
+	Verified int
+	// The entries which failed the verification and have been evicted
+	Failures []KeyError[K]
+	// Time spent in the pass
+	Duration time.Duration
+	// The error of the context if the pass stopped before all entries were verified
+	Err error
+}
+
+// Verify invokes verify with every entry of the cache outside of the lock of the cache
+// and evicts the entries for which it returns an error with EvictionReasonInvalidated,
+// unless they have been replaced in the meantime. It stops once ctx is done
+// Entries which are inserted during the pass are not verified
+func (c *TLRU[K, V]) Verify(ctx context.Context, verify func(key K, value V) error) VerificationReport[K] {
+	c.RLock()
+	keys := make([]K, 0, len(c.cache))
+	for key := range c.cache {
+		keys = append(keys, key)
+	}
+	c.RUnlock()
+
+	return c.verify(ctx, keys, verify)
+}
+
+// verify invokes verify with the entries of the provided keys which are still cached
+func (c *TLRU[K, V]) verify(ctx context.Context, keys []K, verify func(key K, value V) error) VerificationReport[K] {
+	var report VerificationReport[K]
+	startedAt := time.Now()
+	for _, key := range keys {
+		if report.Err = ctx.Err(); report.Err != nil {
+			break
+		}
+		c.RLock()
+		linkedNode, exists := c.cache[key]
+		var value V
+		var version uint64
+		if exists {
+			value, version = linkedNode.readVersionedValue()
+		}
+		c.RUnlock()
+		if !exists {
+			continue
+		}
+
+		report.Verified++
+		err := verify(key, value)
+		if err == nil {
+			continue
+		}
+		report.Failures = append(report.Failures, KeyError[K]{Key: key, Err: err})
+		c.Lock()
+		if c.cache[key] == linkedNode {
+			if _, currentVersion := linkedNode.readVersionedValue(); currentVersion == version {
+				c.evictEntry(linkedNode, EvictionReasonInvalidated)
+			}
+		}
+		c.unlock()
+	}
+	report.Duration = time.Since(startedAt)
+
+	return report
+}
+
+// stateKeys returns the keys of the provided StateEntries
+func stateKeys[K comparable, V any](stateEntries []StateEntry[K, V]) []K {
+	keys := make([]K, len(stateEntries))
+	for i, stateEntry := range stateEntries {
+		keys[i] = stateEntry.Key
+	}
+
+	return keys
+}
+
+// verifyRestored starts a background pass of Config.Verifier over the provided keys
+// and hands its report over to Config.OnVerified
+// It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) verifyRestored(keys []K) {
+	if c.config.Verifier == nil || c.closed || len(keys) == 0 {
+		return
+	}
+	verifier, onVerified := c.config.Verifier, c.config.OnVerified
+	atomic.AddInt32(&c.runningVerifiers, 1)
+	go func() {
+		defer atomic.AddInt32(&c.runningVerifiers, -1)
+		report := c.verify(context.Background(), keys, func(key K, value V) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					panicErr := &PanicError{Callback: "Verifier", Value: recovered, Stack: debug.Stack()}
+					c.reportPanic(panicErr)
+					err = panicErr
+				}
+			}()
+
+			return verifier(key, value)
+		})
+		if onVerified != nil {
+			c.protect("OnVerified", func() {
+				onVerified(report)
+			})
+		}
+	}()
+}
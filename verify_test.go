@@ -0,0 +1,97 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errCorrupted = errors.New("corrupted")
+
+func TestLRUCacheVerify(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		evictionChannel := make(chan EvictedEntry[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:         10,
+			TTL:             time.Minute,
+			EvictionPolicy:  policy,
+			EvictionChannel: &evictionChannel,
+		}
+		cache := New(config)
+		cache.SetMany([]Entry[string, int]{entry1, entry2, entry3})
+
+		report := cache.Verify(context.Background(), func(key string, value int) error {
+			if key == entry2.Key {
+				return errCorrupted
+			}
+			return nil
+		})
+		assert.Equal(3, report.Verified)
+		assert.Equal([]KeyError[string]{{Key: entry2.Key, Err: errCorrupted}}, report.Failures)
+		assert.NoError(report.Err)
+		assert.Equal(2, cache.Len())
+		assert.Nil(cache.Peek(entry2.Key))
+		evictedEntry := <-evictionChannel
+		assert.Equal(entry2.Key, evictedEntry.Key)
+		assert.Equal(EvictionReasonInvalidated, evictedEntry.Reason)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		report = cache.Verify(ctx, func(key string, value int) error {
+			return errCorrupted
+		})
+		assert.Equal(0, report.Verified)
+		assert.True(errors.Is(report.Err, context.Canceled))
+		assert.Equal(2, cache.Len())
+	}
+}
+
+func TestLRUCacheVerifier(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		reports := make(chan VerificationReport[string], 1)
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+			Verifier: func(key string, value int) error {
+				if key == entry3.Key {
+					panic("verifier failed")
+				}
+				if key == entry1.Key {
+					return errCorrupted
+				}
+				return nil
+			},
+			OnVerified: func(report VerificationReport[string]) {
+				reports <- report
+			},
+			OnPanic: func(err *PanicError) {},
+		}
+		source := New(Config[string, int]{MaxSize: 10, TTL: time.Minute, EvictionPolicy: policy})
+		source.SetMany([]Entry[string, int]{entry1, entry2, entry3})
+
+		cache := New(config)
+		assert.NoError(cache.SetState(source.GetState()))
+		report := <-reports
+		assert.Equal(3, report.Verified)
+		assert.Equal(2, len(report.Failures))
+		assert.Equal(1, cache.Len())
+		assert.NotNil(cache.Peek(entry2.Key))
+
+		assert.NoError(cache.Warmup(context.Background(), source.GetState(), WarmupOptions[string, int]{}))
+		report = <-reports
+		assert.Equal(3, report.Verified)
+		assert.Equal(1, cache.Len())
+		assert.Eventually(func() bool {
+			return cache.Status().Verifiers == 0
+		}, time.Second, time.Millisecond)
+	}
+}
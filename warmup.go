@@ -0,0 +1,151 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultWarmupBatchSize is the number of entries restored per acquisition of the lock
+// when WarmupOptions.BatchSize is not set
+const defaultWarmupBatchSize = 1000
+
+// WarmupOptions configures a Warmup
+type WarmupOptions[K comparable, V any] struct {
+	// Number of entries restored per acquisition of the lock of the cache
+	// Default is 1000
+	BatchSize int
+	// Optional callback which is invoked after every restored batch
+	OnProgress func(progress WarmupProgress)
+	// Optional function which returns the size in bytes of an entry
+	// It is used for WarmupProgress.Bytes which stays zero if it is not set
+	Size func(stateEntry StateEntry[K, V]) int
+}
+
+// WarmupProgress reports how far a Warmup has got
+type WarmupProgress struct {
+	// Number of processed entries of the State
+	Loaded int
+	// Number of entries of the State
+	Total int
+	// Sum of WarmupOptions.Size of the processed entries
+	Bytes int64
+	// Estimated time until all entries are processed based on the rate so far
+	ETA time.Duration
+}
+
+// Warmup sets the internal State of the cache like SetState does but restores the
+// entries in batches so that the cache keeps on serving in between, reports the
+// progress via WarmupOptions.OnProgress and stops as soon as ctx is done
+// On cancellation the already restored entries are kept and the error of ctx is returned
+// Entries which are set while Warmup is running are considered more recent than the
+// ones of the State and are neither replaced nor demoted by it
+func (c *TLRU[K, V]) Warmup(ctx context.Context, state State[K, V], options WarmupOptions[K, V]) error {
+	if err := c.startWarmup(state); err != nil {
+		return err
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultWarmupBatchSize
+	}
+	progress := WarmupProgress{Total: len(state.Entries)}
+	defer func() {
+		c.Lock()
+		c.verifyRestored(stateKeys(state.Entries[:progress.Loaded]))
+		c.unlock()
+	}()
+	startedAt := time.Now()
+	for progress.Loaded < progress.Total {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("tlru.Warmup: %w", err)
+		}
+		end := progress.Loaded + batchSize
+		if end > progress.Total {
+			end = progress.Total
+		}
+		batch := state.Entries[progress.Loaded:end]
+		if err := c.warmupBatch(batch); err != nil {
+			return err
+		}
+
+		if options.Size != nil {
+			for _, stateEntry := range batch {
+				progress.Bytes += int64(options.Size(stateEntry))
+			}
+		}
+		progress.Loaded = end
+		elapsed := time.Since(startedAt)
+		progress.ETA = elapsed * time.Duration(progress.Total-progress.Loaded) / time.Duration(progress.Loaded)
+		if options.OnProgress != nil {
+			options.OnProgress(progress)
+		}
+	}
+
+	return nil
+}
+
+// startWarmup validates the State and empties the cache
+func (c *TLRU[K, V]) startWarmup(state State[K, V]) error {
+	defer c.unlock()
+	c.Lock()
+	if state.EvictionPolicy != c.config.EvictionPolicy {
+		return fmt.Errorf("tlru.Warmup: Incompatible state EvictionPolicy %s", state.EvictionPolicy.String())
+	}
+	if c.closed {
+		return fmt.Errorf("tlru.Warmup: %w", ErrClosed)
+	}
+	if c.config.OversizedState == OversizedStateError && c.config.MaxSize != 0 && len(state.Entries) > c.config.MaxSize {
+		return fmt.Errorf("tlru.Warmup: State has %d entries and MaxSize is %d. %w", len(state.Entries), c.config.MaxSize, ErrStateTooLarge)
+	}
+	c.clear()
+
+	return nil
+}
+
+// warmupBatch appends the entries of the batch to the tail of the list of the cache
+// skipping the ones whose key has been set since the Warmup started
+func (c *TLRU[K, V]) warmupBatch(batch []StateEntry[K, V]) error {
+	defer c.unlock()
+	c.Lock()
+	if c.closed {
+		return fmt.Errorf("tlru.Warmup: %w", ErrClosed)
+	}
+
+	for _, stateEntry := range batch {
+		if _, exists := c.cache[stateEntry.Key]; exists {
+			continue
+		}
+		counter, createdAt := c.restoredCounterAndCreatedAt(stateEntry)
+		ttl, customTTL := c.entryTTL(stateEntry.TTL)
+		rehydratedNode := &doublyLinkedNode[K, V]{
+			key:        stateEntry.Key,
+			value:      stateEntry.Value,
+			version:    c.nextVersion(),
+			sequence:   c.nextSequence(),
+			lock:       c.newEntryLock(),
+			counter:    counter,
+			lastUsedAt: stateEntry.LastUsedAt,
+			expiresAt:  c.limitLifetime(c.deadline(stateEntry.LastUsedAt, ttl), createdAt, ttl),
+			createdAt:  createdAt,
+			metadata:   stateEntry.Metadata,
+			ttl:        ttl,
+			customTTL:  customTTL,
+		}
+		rehydratedNode.previous = c.sentinel.previous
+		rehydratedNode.next = c.sentinel
+		c.sentinel.previous.next = rehydratedNode
+		c.sentinel.previous = rehydratedNode
+		c.cache[rehydratedNode.key] = rehydratedNode
+		c.index(rehydratedNode)
+		c.scheduleExpiration(rehydratedNode)
+	}
+	c.shrink(c.config.MaxSize)
+	c.ensureGarbageCollection()
+
+	return nil
+}
@@ -0,0 +1,109 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheWarmup(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		source := New(config)
+		for i := 0; i < 5; i++ {
+			source.Set(fmt.Sprintf("key-%d", i), i)
+		}
+		state := source.GetState()
+
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		var progresses []WarmupProgress
+		err := cache.Warmup(context.Background(), state, WarmupOptions[string, int]{
+			BatchSize: 2,
+			Size: func(stateEntry StateEntry[string, int]) int {
+				return len(stateEntry.Key)
+			},
+			OnProgress: func(progress WarmupProgress) {
+				progresses = append(progresses, progress)
+			},
+		})
+		assert.NoError(err)
+		assert.Equal(state.Entries, cache.GetState().Entries)
+		assert.False(cache.Has(entry1.Key))
+
+		assert.Equal(3, len(progresses))
+		assert.Equal(2, progresses[0].Loaded)
+		assert.Equal(int64(10), progresses[0].Bytes)
+		assert.Equal(5, progresses[2].Loaded)
+		assert.Equal(5, progresses[2].Total)
+		assert.Equal(int64(25), progresses[2].Bytes)
+		assert.Equal(time.Duration(0), progresses[2].ETA)
+	}
+}
+
+func TestLRUCacheWarmupCancellation(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        10,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		source := New(config)
+		for i := 0; i < 5; i++ {
+			source.Set(fmt.Sprintf("key-%d", i), i)
+		}
+		state := source.GetState()
+
+		cache := New(config)
+		ctx, cancel := context.WithCancel(context.Background())
+		err := cache.Warmup(ctx, state, WarmupOptions[string, int]{
+			BatchSize: 2,
+			OnProgress: func(progress WarmupProgress) {
+				cache.Set(state.Entries[4].Key, 40)
+				cancel()
+			},
+		})
+		assert.True(errors.Is(err, context.Canceled))
+		assert.Equal(3, cache.Len())
+		assert.Equal(state.Entries[0].Key, cache.GetState().Entries[1].Key)
+		assert.Equal(40, cache.Peek(state.Entries[4].Key).Value)
+	}
+}
+
+func TestLRUCacheWarmupOversizedState(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		config := Config[string, int]{
+			MaxSize:        2,
+			TTL:            time.Minute,
+			EvictionPolicy: policy,
+		}
+		source := New(Config[string, int]{MaxSize: 3, TTL: time.Minute, EvictionPolicy: policy})
+		source.Set(entry1.Key, entry1.Value)
+		source.Set(entry2.Key, entry2.Value)
+		source.Set(entry3.Key, entry3.Value)
+		state := source.GetState()
+
+		cache := New(config)
+		assert.NoError(cache.Warmup(context.Background(), state, WarmupOptions[string, int]{BatchSize: 1}))
+		assert.Equal(state.Entries[:2], cache.GetState().Entries)
+
+		config.OversizedState = OversizedStateError
+		cache = New(config)
+		err := cache.Warmup(context.Background(), state, WarmupOptions[string, int]{})
+		assert.True(errors.Is(err, ErrStateTooLarge))
+	}
+}
@@ -0,0 +1,58 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import "time"
+
+// minExpiryWarningInterval bounds the frequency of the checks of Config.ExpiryWarning
+const minExpiryWarningInterval = 10 * time.Millisecond
+
+// scheduleExpiryWarning (re)arms the check of Config.ExpiryWarning
+// It must be called while holding the lock of the cache
+func (c *TLRU[K, V]) scheduleExpiryWarning() {
+	if c.warningTimer != nil {
+		c.warningTimer.Stop()
+		c.warningTimer = nil
+	}
+	c.warningGeneration++
+	if c.config.ExpiryWarning <= 0 || c.config.ExpiryWarning >= 1 || c.config.OperationChannel == nil || c.closed {
+		return
+	}
+
+	interval := time.Duration(float64(c.config.TTL) * (1 - c.config.ExpiryWarning) / 2)
+	if interval < minExpiryWarningInterval {
+		interval = minExpiryWarningInterval
+	}
+	generation := c.warningGeneration
+	c.warningTimer = time.AfterFunc(interval, func() {
+		c.warnExpiring(generation)
+	})
+}
+
+// warnExpiring emits an OperationExpiring for every entry whose deadline is within the last
+// 1 - Config.ExpiryWarning of its TTL and schedules the next check unless it has been rescheduled
+func (c *TLRU[K, V]) warnExpiring(generation uint64) {
+	defer c.unlock()
+	c.Lock()
+	if generation != c.warningGeneration {
+		return
+	}
+
+	now := time.Now()
+	for nextNode := c.sentinel.next; nextNode != c.sentinel; nextNode = nextNode.next {
+		warnAfter := now.Add(time.Duration(float64(nextNode.ttl) * (1 - c.config.ExpiryWarning)))
+		if nextNode.isExpired(now) || nextNode.expiresAt.After(warnAfter) || nextNode.warnedFor.Equal(nextNode.expiresAt) {
+			continue
+		}
+		nextNode.warnedFor = nextNode.expiresAt
+		c.emitOperation(Operation[K, V]{
+			Type:       OperationExpiring,
+			Key:        nextNode.key,
+			Value:      nextNode.readValue(),
+			LastUsedAt: nextNode.lastUsedAt,
+		})
+	}
+	c.scheduleExpiryWarning()
+}
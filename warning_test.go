@@ -0,0 +1,43 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheExpiryWarning(t *testing.T) {
+	assert := assert.New(t)
+	for _, policy := range policies {
+		operationChannel := make(chan Operation[string, int], 10)
+		config := Config[string, int]{
+			MaxSize:          3,
+			TTL:              100 * time.Millisecond,
+			EvictionPolicy:   policy,
+			OperationChannel: &operationChannel,
+			ExpiryWarning:    0.5,
+		}
+		cache := New(config)
+		cache.Set(entry1.Key, entry1.Value)
+		startedAt := time.Now()
+		<-operationChannel
+
+		operation := <-operationChannel
+		assert.Equal(OperationExpiring, operation.Type)
+		assert.Equal(entry1.Key, operation.Key)
+		assert.Equal(entry1.Value, operation.Value)
+		assert.True(time.Since(startedAt) >= 50*time.Millisecond)
+		assert.True(time.Since(startedAt) < config.TTL)
+
+		time.Sleep(config.TTL)
+		assert.Equal(0, len(operationChannel))
+
+		_, err := cache.CloseAndExport()
+		assert.NoError(err)
+		assert.Nil(cache.warningTimer)
+	}
+}
@@ -0,0 +1,119 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+
+package tlru
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	defaultWatchdogWindow     = time.Minute
+	defaultWatchdogWindows    = 5
+	defaultWatchdogThreshold  = 0.1
+	defaultWatchdogMinLookups = 100
+)
+
+// HitRatioAlert describes a degradation of the hit ratio detected by WatchHitRatio
+type HitRatioAlert struct {
+	// Hit ratio of the last window
+	HitRatio float64 `json:"hit_ratio"`
+	// Mean hit ratio of the previous windows
+	Baseline float64 `json:"baseline"`
+	// Number of lookups of the last window
+	Lookups uint64 `json:"lookups"`
+	// The time the last window ended at
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// WatchdogOptions configure WatchHitRatio
+type WatchdogOptions struct {
+	// Length of a window. If not set it defaults to 1 minute
+	Window time.Duration
+	// Number of previous windows the baseline hit ratio is computed from.
+	// If not set it defaults to 5
+	Windows int
+	// Drop of the hit ratio below the baseline which triggers an alert, e.g. 0.1 alerts
+	// when the hit ratio falls from 0.9 to below 0.8. If not set it defaults to 0.1
+	Threshold float64
+	// Min number of lookups of a window for it to be taken into account.
+	// If not set it defaults to 100
+	MinLookups uint64
+	// Optional callback which is invoked with every alert. If not set alerts are logged
+	OnDegraded func(alert HitRatioAlert)
+}
+
+// WatchHitRatio tracks the hit ratio of the lookups of the provided cache via Get and its variants,
+// including GetOrCompute, over consecutive windows in a new goroutine. Whenever the hit ratio of
+// a window falls below the mean hit ratio of the previous windows by more than the threshold an
+// alert is raised, which helps detecting workload drift or misconfiguration. Windows with too few
+// lookups are skipped. A panic of WatchdogOptions.OnDegraded is reported to Config.OnPanic
+// Watching stops when the context is done. The returned channel is closed once watching has stopped
+func WatchHitRatio[K comparable, V any](ctx context.Context, cache *TLRU[K, V], options WatchdogOptions) <-chan struct{} {
+	if options.Window <= 0 {
+		options.Window = defaultWatchdogWindow
+	}
+	if options.Windows <= 0 {
+		options.Windows = defaultWatchdogWindows
+	}
+	if options.Threshold <= 0 {
+		options.Threshold = defaultWatchdogThreshold
+	}
+	if options.MinLookups == 0 {
+		options.MinLookups = defaultWatchdogMinLookups
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(options.Window)
+		defer ticker.Stop()
+
+		previous := cache.Stats()
+		ratios := make([]float64, 0, options.Windows)
+		for {
+			select {
+			case now := <-ticker.C:
+				current := cache.Stats()
+				hits, misses := current.GetHits-previous.GetHits, current.GetMisses-previous.GetMisses
+				previous = current
+				if hits+misses < options.MinLookups {
+					continue
+				}
+
+				ratio := float64(hits) / float64(hits+misses)
+				if len(ratios) == options.Windows {
+					baseline := mean(ratios)
+					if baseline-ratio > options.Threshold {
+						alert := HitRatioAlert{HitRatio: ratio, Baseline: baseline, Lookups: hits + misses, DetectedAt: now.UTC()}
+						cache.protect("OnDegraded", func() {
+							if options.OnDegraded == nil {
+								log.Printf("tlru: Hit ratio %.3f of the last %d lookups is below the baseline %.3f", alert.HitRatio, alert.Lookups, alert.Baseline)
+								return
+							}
+							options.OnDegraded(alert)
+						})
+					}
+					ratios = ratios[1:]
+				}
+				ratios = append(ratios, ratio)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return done
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum / float64(len(values))
+}
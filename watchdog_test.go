@@ -0,0 +1,71 @@
+// * tlru <https://github.com/jahnestacado/tlru>
+// * Copyright (c) 2020 Ioannis Tzanellis
+// * Licensed under the MIT License (MIT).
+package tlru
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchHitRatio(t *testing.T) {
+	assert := assert.New(t)
+	cache := New(Config[string, int]{MaxSize: 10, TTL: time.Minute})
+	loader := func(key string) (int, error) {
+		return len(key), nil
+	}
+
+	alerts := make(chan HitRatioAlert, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := WatchHitRatio(ctx, cache, WatchdogOptions{
+		Window:     20 * time.Millisecond,
+		Windows:    2,
+		MinLookups: 10,
+		OnDegraded: func(alert HitRatioAlert) {
+			alerts <- alert
+		},
+	})
+
+	var misses int32
+	stop := make(chan struct{})
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			key := entry1.Key
+			if atomic.LoadInt32(&misses) == 1 {
+				key = fmt.Sprint(i)
+			}
+			// Lookups via Get and GetOrCompute are both tracked
+			if i%2 == 0 {
+				cache.Get(key)
+			} else {
+				cache.GetOrCompute(key, loader)
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(0, len(alerts))
+	atomic.StoreInt32(&misses, 1)
+	select {
+	case alert := <-alerts:
+		assert.True(alert.Baseline-alert.HitRatio > 0.1)
+		assert.True(alert.Lookups >= 10)
+	case <-time.After(time.Second):
+		assert.Fail("no alert has been raised")
+	}
+	close(stop)
+
+	cancel()
+	<-done
+}